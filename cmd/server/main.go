@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -13,9 +14,12 @@ import (
 	"GameServer/internal/infrastructure/config"
 	"GameServer/internal/infrastructure/container"
 	"GameServer/internal/infrastructure/database"
+	"GameServer/internal/interfaces/admin"
+	"GameServer/internal/interfaces/gateway"
 	"GameServer/internal/interfaces/websocket"
 	"GameServer/pkg/logger"
 	"GameServer/pkg/metrics"
+	"GameServer/pkg/netpolicy"
 )
 
 func main() {
@@ -56,9 +60,12 @@ func main() {
 		log.Fatalf("Error checking table structure: %v", err)
 	}
 
-	// Set all users offline on startup
-	if err := dbConnection.SetAllUsersOffline(); err != nil {
-		log.Printf("Warning: Failed to set users offline: %v", err)
+	// Set all users offline on startup. With a shared backplane other nodes
+	// may still hold sessions, so only a single node may reset everyone.
+	if cfg.Backplane.Driver == config.BackplaneDriverMemory {
+		if err := dbConnection.SetAllUsersOffline(); err != nil {
+			log.Printf("Warning: Failed to set users offline: %v", err)
+		}
 	}
 
 	log.Println("Database initialization completed successfully!")
@@ -73,36 +80,141 @@ func main() {
 	logger.Info("Dependency injection container initialized")
 
 	// Create WebSocket hub
-	hub := websocket.NewHub(container.GetWebSocketServices())
+	hub := websocket.NewHub(container.GetWebSocketServices(), cfg.WebSocket, container.Backplane)
 	go hub.Run()
 
-	logger.Info("WebSocket hub started")
+	// Let services push events to clients
+	container.RankingService.SetPublisher(hub)
+	container.PlayerService.SetNotifier(hub)
+	container.MailService.SetNotifier(hub)
+
+	// Recalculate ranking positions and announce them periodically
+	go refreshRankings(container, cfg.Gameplay.RankingRefreshInterval)
+
+	logger.Info("WebSocket hub started", map[string]interface{}{
+		"node_id":   cfg.Backplane.NodeID,
+		"backplane": cfg.Backplane.Driver,
+	})
 
 	// Setup HTTP routes
 	setupRoutes(hub, container, cfg)
 
+	// Restrict who may reach the client and admin endpoints
+	policyConfig, err := loadNetworkPolicy(cfg.Network)
+	if err != nil {
+		log.Fatalf("Failed to load network policy: %v", err)
+	}
+	policy, err := netpolicy.New(policyConfig, adminPathPrefixes...)
+	if err != nil {
+		log.Fatalf("Invalid network policy: %v", err)
+	}
+	go watchNetworkPolicy(policy, cfg.Network)
+
 	// Start server
 	serverAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("Server starting on %s", serverAddr)
 	log.Printf("WebSocket endpoint: ws://%s/ws", serverAddr)
 
-	// Graceful shutdown
+	server := &http.Server{
+		Addr:         serverAddr,
+		Handler:      policy.Middleware(http.DefaultServeMux),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
 	go func() {
-		if err := http.ListenAndServe(serverAddr, nil); err != nil {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
 
 	// Wait for shutdown signal
-	waitForShutdown(hub, dbConnection)
+	waitForShutdown(server, hub, dbConnection, cfg)
 	log.Println("Server shutdown completed")
 }
 
+// adminPathPrefixes are the operational endpoints guarded by the admin rules
+var adminPathPrefixes = []string{"/metrics", "/admin/"}
+
+// loadNetworkPolicy builds the network policy from configuration, reading
+// the policy file when one is configured
+func loadNetworkPolicy(cfg config.NetworkConfig) (netpolicy.Config, error) {
+	policy := netpolicy.Config{
+		Client:         netpolicy.Rules{Allow: cfg.ClientAllow, Deny: cfg.ClientDeny},
+		Admin:          netpolicy.Rules{Allow: cfg.AdminAllow, Deny: cfg.AdminDeny},
+		TrustedProxies: cfg.TrustedProxies,
+	}
+	if cfg.PolicyFile == "" {
+		return policy, nil
+	}
+
+	data, err := os.ReadFile(cfg.PolicyFile)
+	if err != nil {
+		return policy, err
+	}
+	policy = netpolicy.Config{}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse %s: %w", cfg.PolicyFile, err)
+	}
+	return policy, nil
+}
+
+// watchNetworkPolicy reloads the network policy file on SIGHUP
+func watchNetworkPolicy(policy *netpolicy.Policy, cfg config.NetworkConfig) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	for range c {
+		if cfg.PolicyFile == "" {
+			log.Println("SIGHUP received but NET_POLICY_FILE is not set, network policy unchanged")
+			continue
+		}
+
+		policyConfig, err := loadNetworkPolicy(cfg)
+		if err == nil {
+			err = policy.Update(policyConfig)
+		}
+		if err != nil {
+			log.Printf("Warning: Failed to reload network policy, keeping the current one: %v", err)
+			continue
+		}
+		log.Printf("Network policy reloaded from %s", cfg.PolicyFile)
+	}
+}
+
+// refreshRankings recalculates the ranking positions at every interval
+func refreshRankings(container *container.Container, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		if err := container.RankingService.RefreshAllRankings(ctx); err != nil {
+			log.Printf("Warning: Failed to refresh rankings: %v", err)
+		}
+		cancel()
+	}
+}
+
 // setupRoutes configures all HTTP routes
 func setupRoutes(hub *websocket.Hub, container *container.Container, cfg *config.Config) {
 	// WebSocket endpoint
 	http.HandleFunc("/ws", hub.HandleWebSocket)
 
+	// Server-Sent Events fallback for clients that cannot use WebSocket
+	http.HandleFunc("GET /sse", hub.HandleSSE)
+	http.HandleFunc("POST /sse/{clientId}", hub.HandleSSEMessage)
+
+	// HTTP gateway to the same message handlers
+	gateway.NewGateway(hub, container.AuthService).RegisterRoutes(http.DefaultServeMux)
+
+	// Admin endpoints, restricted by the admin network policy and tokens
+	if len(cfg.Security.AdminTokens) == 0 {
+		log.Println("Warning: no ADMIN_TOKENS configured, the admin API will refuse every request")
+	}
+	admin.NewAPI(container.MailService, hub, cfg.Security.AdminTokens).RegisterRoutes(http.DefaultServeMux)
+
 	// Health check endpoint
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -132,6 +244,8 @@ func setupRoutes(hub *websocket.Hub, container *container.Container, cfg *config
 			"architecture": "Clean Architecture with DDD",
 			"endpoints": map[string]string{
 				"websocket": "/ws",
+				"sse":       "/sse",
+				"api":       "/api/{type}/{action}",
 				"health":    "/health",
 				"metrics":   "/metrics",
 				"info":      "/info",
@@ -159,69 +273,44 @@ func setupRoutes(hub *websocket.Hub, container *container.Container, cfg *config
 }
 
 // waitForShutdown waits for interrupt signals for graceful shutdown
-func waitForShutdown(hub *websocket.Hub, dbConnection *database.Connection) {
+func waitForShutdown(server *http.Server, hub *websocket.Hub, dbConnection *database.Connection, cfg *config.Config) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
-	
+
 	log.Println("Shutdown signal received, starting graceful shutdown...")
-	
-	// Close WebSocket hub to disconnect all clients and set users offline
-	if hub != nil {
-		log.Println("Closing WebSocket hub...")
-		closeHub(hub)
-	}
-	
-	// Set all remaining users offline in database
-	if dbConnection != nil {
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Drain WebSocket and SSE clients: notify, finish in-flight work, flush
+	// and log out. SSE streams are ordinary HTTP requests, so the HTTP server
+	// only finishes shutting down once the hub has ended them.
+	hubDone := make(chan struct{})
+	go func() {
+		defer close(hubDone)
+		if hub != nil {
+			log.Println("Draining WebSocket hub...")
+			if err := hub.Shutdown(ctx, cfg.Server.ReconnectDelay); err != nil {
+				log.Printf("Warning: WebSocket hub did not drain cleanly: %v", err)
+			}
+		}
+	}()
+
+	// Stop accepting new connections and WebSocket upgrades
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Warning: HTTP server shutdown: %v", err)
+	}
+	<-hubDone
+
+	// Set all remaining users offline in database. The hub has already logged
+	// out its own users; other nodes keep theirs when a backplane is shared.
+	if dbConnection != nil && cfg.Backplane.Driver == config.BackplaneDriverMemory {
 		log.Println("Setting all users offline...")
 		if err := dbConnection.SetAllUsersOffline(); err != nil {
 			log.Printf("Warning: Failed to set users offline during shutdown: %v", err)
 		}
 	}
-	
+
 	log.Println("Graceful shutdown completed")
 }
-
-// closeHub closes the hub and disconnects all clients
-func closeHub(hub *websocket.Hub) {
-	// Send close signal to all connected clients
-	hub.Mutex.Lock()
-	defer hub.Mutex.Unlock()
-	
-	log.Printf("Disconnecting %d connected clients...", len(hub.Clients))
-	
-	for client := range hub.Clients {
-		if client.UserID > 0 {
-			// Set user offline through auth service
-			if hub.Services != nil && hub.Services.AuthService != nil {
-				if err := hub.Services.AuthService.Logout(client.UserID); err != nil {
-					log.Printf("Warning: Failed to logout user %d during shutdown: %v", client.UserID, err)
-				} else {
-					log.Printf("User %d logged out during shutdown", client.UserID)
-				}
-			}
-		}
-		
-		// Close client connection
-		select {
-		case client.Send <- []byte(`{"success":false,"code":1008,"message":"Server shutting down","data":null}`):
-		default:
-		}
-		close(client.Send)
-		
-		// Close WebSocket connection
-		if client.Conn != nil {
-			client.Conn.Close()
-		}
-	}
-	
-	// Clear all client mappings
-	for client := range hub.Clients {
-		delete(hub.Clients, client)
-	}
-	
-	for userID := range hub.UserClients {
-		delete(hub.UserClients, userID)
-	}
-}
\ No newline at end of file
@@ -14,9 +14,19 @@ type RegisterRequest struct {
 
 // LoginResponse represents login response data
 type LoginResponse struct {
-	UserID   int    `json:"userid"`
-	Username string `json:"username"`
-	Token    string `json:"token,omitempty"` // Optional token for future use
+	UserID           int    `json:"userid"`
+	Username         string `json:"username"`
+	Token            string `json:"token,omitempty"`      // Optional token for future use
+	SigningKey       string `json:"signingKey,omitempty"` // Session key for signing sensitive messages
+	CheckInAvailable bool   `json:"checkInAvailable"`     // Today's check-in reward has not been claimed
+}
+
+// TokenResponse represents an issued HTTP API token
+type TokenResponse struct {
+	UserID    int    `json:"userid"`
+	Username  string `json:"username"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expiresAt"`
 }
 
 // RegisterResponse represents registration response data
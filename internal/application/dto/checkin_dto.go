@@ -0,0 +1,40 @@
+package dto
+
+// RewardResponse represents currencies and items granted to a player
+type RewardResponse struct {
+	Currencies map[string]int `json:"currencies,omitempty"` // Currency to amount
+	Items      map[int]int    `json:"items,omitempty"`      // Item ID to count
+}
+
+// CheckInDayResponse represents one day of the check-in calendar
+type CheckInDayResponse struct {
+	Day     int             `json:"day"`
+	Reward  *RewardResponse `json:"reward"`
+	Claimed bool            `json:"claimed"` // Claimed in the current cycle
+}
+
+// CheckInStatusResponse represents a player's check-in state
+type CheckInStatusResponse struct {
+	CheckedIn      bool                  `json:"checkedIn"` // Already checked in today
+	Streak         int                   `json:"streak"`
+	TotalDays      int                   `json:"totalDays"`
+	MakeUpDays     int                   `json:"makeUpDays"` // Missed days that can still be made up
+	MakeUpCurrency string                `json:"makeUpCurrency,omitempty"`
+	MakeUpCost     int                   `json:"makeUpCost,omitempty"` // Per day made up
+	Calendar       []*CheckInDayResponse `json:"calendar"`
+}
+
+// CheckInClaimRequest represents a request to check in
+type CheckInClaimRequest struct {
+	UserID int  `json:"userid"`
+	MakeUp bool `json:"makeUp,omitempty"` // Check in for the oldest missed day instead of today
+}
+
+// CheckInClaimResponse represents the outcome of a check-in
+type CheckInClaimResponse struct {
+	Day       int             `json:"day"` // Calendar day whose reward was granted
+	Streak    int             `json:"streak"`
+	TotalDays int             `json:"totalDays"`
+	MakeUp    bool            `json:"makeUp"`
+	Reward    *RewardResponse `json:"reward"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+// InventoryItemResponse represents a stack of items the player holds
+type InventoryItemResponse struct {
+	ItemID   int    `json:"itemId"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Count    int    `json:"count"`
+	MaxStack int    `json:"maxStack"`
+	Usable   bool   `json:"usable"`
+}
+
+// UseItemRequest represents a request to use items from a stack
+type UseItemRequest struct {
+	UserID int `json:"userid"`
+	ItemID int `json:"itemId"`
+	Count  int `json:"count,omitempty"` // Defaults to 1
+}
+
+// UseItemResponse represents the outcome of using items
+type UseItemResponse struct {
+	ItemID   int                     `json:"itemId"`
+	Count    int                     `json:"count"`
+	Effect   string                  `json:"effect"`
+	Amount   int                     `json:"amount"`             // Total effect amount for all items used
+	Energy   *EnergyResponse         `json:"energy,omitempty"`   // Set by restore_energy items
+	Progress *GainExperienceResponse `json:"progress,omitempty"` // Set by grant_experience items
+}
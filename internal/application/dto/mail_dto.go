@@ -0,0 +1,66 @@
+package dto
+
+// MailAttachmentsData represents the currencies, items and equipment a mail grants
+type MailAttachmentsData struct {
+	Currencies map[string]int   `json:"currencies,omitempty"` // Currency to amount
+	Items      map[int]int      `json:"items,omitempty"`      // Item ID to count
+	Equipment  []*EquipmentData `json:"equipment,omitempty"`
+}
+
+// MailResponse represents a mail in the player's mailbox
+type MailResponse struct {
+	MailID      int                  `json:"mailId"`
+	Kind        string               `json:"kind"`
+	SenderID    int                  `json:"senderId,omitempty"`
+	SenderName  string               `json:"senderName"`
+	Title       string               `json:"title"`
+	Body        string               `json:"body,omitempty"` // Only returned when reading a mail
+	Attachments *MailAttachmentsData `json:"attachments,omitempty"`
+	Read        bool                 `json:"read"`
+	Claimed     bool                 `json:"claimed"`
+	ExpiresAt   int64                `json:"expiresAt"`
+	CreatedAt   int64                `json:"createdAt"`
+}
+
+// MailActionRequest represents a request to read, claim or delete a mail
+type MailActionRequest struct {
+	UserID int `json:"userid"`
+	MailID int `json:"mailId"`
+}
+
+// MailClaimResponse represents claimed mail attachments
+type MailClaimResponse struct {
+	MailID    int                  `json:"mailId"`
+	Reward    *RewardResponse      `json:"reward"`
+	Equipment []*EquipmentResponse `json:"equipment,omitempty"` // Created equipment with its new IDs
+}
+
+// SendMailRequest represents a player sending mail to a friend
+type SendMailRequest struct {
+	UserID   int    `json:"userid"`
+	ToUserID int    `json:"toUserId"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+}
+
+// SendMailResponse represents a sent mail
+type SendMailResponse struct {
+	MailID int `json:"mailId"`
+}
+
+// BulkMailRequest represents system mail sent through the admin API
+type BulkMailRequest struct {
+	Title       string               `json:"title"`
+	Body        string               `json:"body"`
+	SenderName  string               `json:"senderName,omitempty"` // Defaults to "system"
+	Attachments *MailAttachmentsData `json:"attachments,omitempty"`
+	ExpiresAt   int64                `json:"expiresAt,omitempty"` // Unix time; defaults to now plus the mail TTL
+	UserIDs     []int                `json:"userIds,omitempty"`   // Empty for every user
+	MinLevel    int                  `json:"minLevel,omitempty"`
+	MaxLevel    int                  `json:"maxLevel,omitempty"`
+}
+
+// BulkMailResponse represents how many mailboxes received a bulk mail
+type BulkMailResponse struct {
+	Sent int `json:"sent"`
+}
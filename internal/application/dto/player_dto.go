@@ -11,11 +11,81 @@ type PlayerInfoResponse struct {
 
 // UpdatePlayerRequest represents update player request
 type UpdatePlayerRequest struct {
-	UserID      int  `json:"userid"`
-	Level       *int `json:"level,omitempty"`
-	Experience  *int `json:"experience,omitempty"`
-	GameLevel   *int `json:"gamelevel,omitempty"`
-	BloodEnergy *int `json:"bloodenergy,omitempty"`
+	UserID      int    `json:"userid"`
+	Level       *int   `json:"level,omitempty"`
+	Experience  *int   `json:"experience,omitempty"`
+	GameLevel   *int   `json:"gamelevel,omitempty"`
+	BloodEnergy *int   `json:"bloodenergy,omitempty"`
+	Payload     []byte `json:"-"` // Raw request data, kept for the suspicion log
+}
+
+// GainExperienceRequest represents a request to award experience
+type GainExperienceRequest struct {
+	UserID  int    `json:"userid"`
+	Source  string `json:"source"`
+	Amount  int    `json:"amount"`
+	Payload []byte `json:"-"` // Raw request data, kept for the suspicion log
+}
+
+// GainExperienceResponse represents the player's progress after gaining experience
+type GainExperienceResponse struct {
+	Level        int  `json:"level"`
+	Experience   int  `json:"experience"`
+	NextLevelExp int  `json:"nextLevelExp"` // Experience needed to reach the next level; 0 at the maximum level
+	LevelsGained int  `json:"levelsGained"`
+	MaxLevel     bool `json:"maxLevel"`
+}
+
+// ConsumeEnergyRequest represents a request to spend blood energy
+type ConsumeEnergyRequest struct {
+	UserID int `json:"userid"`
+	Amount int `json:"amount"`
+}
+
+// EnergyResponse represents the player's blood energy after regeneration
+type EnergyResponse struct {
+	BloodEnergy    int `json:"bloodenergy"`
+	MaxBloodEnergy int `json:"maxBloodEnergy"`
+	NextRegenIn    int `json:"nextRegenIn"` // Seconds until the next point regenerates; 0 when not regenerating
+	PurchasesLeft  int `json:"purchasesLeft"`
+}
+
+// CompleteStageRequest represents a request to record a stage clear
+type CompleteStageRequest struct {
+	UserID    int    `json:"userid"`
+	Stage     int    `json:"stage"`
+	ClearTime int    `json:"clearTime"` // Milliseconds
+	Stars     int    `json:"stars"`
+	Payload   []byte `json:"-"` // Raw request data, kept for the suspicion log
+}
+
+// CompleteStageResponse represents the stage record and rewards after a clear
+type CompleteStageResponse struct {
+	Stage            int                     `json:"stage"`
+	Stars            int                     `json:"stars"`
+	BestTime         int                     `json:"bestTime"`
+	ClearCount       int                     `json:"clearCount"`
+	FirstClear       bool                    `json:"firstClear"`
+	RewardExperience int                     `json:"rewardExperience"`
+	GameLevel        int                     `json:"gamelevel"`
+	Progress         *GainExperienceResponse `json:"progress,omitempty"` // Level and experience after the reward
+	Reward           *RewardResponse         `json:"reward,omitempty"`   // Currencies and items granted
+}
+
+// StageResponse represents one stage of the stage map
+type StageResponse struct {
+	Stage      int  `json:"stage"`
+	Unlocked   bool `json:"unlocked"`
+	Stars      int  `json:"stars"`
+	BestTime   int  `json:"bestTime"`
+	ClearCount int  `json:"clearCount"`
+}
+
+// StageMapResponse represents every stage with the player's records
+type StageMapResponse struct {
+	GameLevel  int              `json:"gamelevel"`
+	StageCount int              `json:"stageCount"`
+	Stages     []*StageResponse `json:"stages"`
 }
 
 // EquipmentResponse represents equipment response
@@ -0,0 +1,37 @@
+package dto
+
+// GetProfileRequest represents a request to view another player's profile
+type GetProfileRequest struct {
+	ViewerID int `json:"-"`
+	UserID   int `json:"userid"` // The player whose profile is viewed
+}
+
+// ProfileResponse represents a player's public profile. Parts the player
+// chose to hide are left out and flagged.
+type ProfileResponse struct {
+	UserID          int                  `json:"userid"`
+	Username        string               `json:"username"`
+	Level           int                  `json:"level"`
+	GameLevel       int                  `json:"gamelevel"`
+	Equipment       []*EquipmentResponse `json:"equipment,omitempty"`      // Equipped items
+	EquipmentStats  map[string]int       `json:"equipmentStats,omitempty"` // Totals of the equipped items
+	Rankings        map[string]int       `json:"rankings,omitempty"`       // Rank type to position
+	EquipmentHidden bool                 `json:"equipmentHidden"`
+	RankingsHidden  bool                 `json:"rankingsHidden"`
+}
+
+// PrivacySettingsResponse represents a user's privacy settings
+type PrivacySettingsResponse struct {
+	FriendsOnly   bool `json:"friendsOnly"`
+	HideEquipment bool `json:"hideEquipment"`
+	HideRankings  bool `json:"hideRankings"`
+}
+
+// UpdatePrivacyRequest represents a request to change privacy settings;
+// settings left out are unchanged
+type UpdatePrivacyRequest struct {
+	UserID        int   `json:"userid"`
+	FriendsOnly   *bool `json:"friendsOnly,omitempty"`
+	HideEquipment *bool `json:"hideEquipment,omitempty"`
+	HideRankings  *bool `json:"hideRankings,omitempty"`
+}
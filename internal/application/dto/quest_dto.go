@@ -0,0 +1,27 @@
+package dto
+
+// QuestResponse represents a quest or achievement and the player's progress
+type QuestResponse struct {
+	QuestID   int             `json:"questId"`
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind"`
+	Event     string          `json:"event"`
+	Progress  int             `json:"progress"`
+	Target    int             `json:"target"`
+	Completed bool            `json:"completed"`
+	Claimed   bool            `json:"claimed"`
+	Reward    *RewardResponse `json:"reward"`
+	ResetsAt  int64           `json:"resetsAt,omitempty"` // Unix time of the next reset of daily and weekly quests
+}
+
+// QuestClaimRequest represents a request to claim a completed quest's reward
+type QuestClaimRequest struct {
+	UserID  int `json:"userid"`
+	QuestID int `json:"questId"`
+}
+
+// QuestClaimResponse represents a claimed quest reward
+type QuestClaimResponse struct {
+	QuestID int             `json:"questId"`
+	Reward  *RewardResponse `json:"reward"`
+}
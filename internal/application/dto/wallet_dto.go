@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// WalletBalanceResponse represents a user's balance of every currency
+type WalletBalanceResponse struct {
+	Balances map[string]int `json:"balances"`
+}
+
+// WalletHistoryRequest represents a request for a page of ledger entries
+type WalletHistoryRequest struct {
+	UserID   int    `json:"userid"`
+	Currency string `json:"currency,omitempty"` // Empty for all currencies
+	BeforeID int    `json:"beforeId,omitempty"` // Return entries older than this one
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// LedgerEntryResponse represents one change to a balance
+type LedgerEntryResponse struct {
+	ID           int       `json:"id"`
+	Currency     string    `json:"currency"`
+	Amount       int       `json:"amount"`
+	BalanceAfter int       `json:"balanceAfter"`
+	Reason       string    `json:"reason"`
+	ReferenceID  string    `json:"referenceId"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WalletHistoryResponse represents a page of ledger entries, newest first
+type WalletHistoryResponse struct {
+	Entries      []*LedgerEntryResponse `json:"entries"`
+	NextBeforeID int                    `json:"nextBeforeId,omitempty"` // Pass as beforeId for the next page; 0 on the last page
+}
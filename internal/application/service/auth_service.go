@@ -6,6 +6,12 @@ import (
 	"GameServer/internal/domain/repository"
 	"GameServer/internal/domain/service"
 	"GameServer/internal/infrastructure/cache"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,57 +19,55 @@ import (
 type AuthService struct {
 	userRepo       repository.UserRepository
 	playerRepo     repository.PlayerRepository
+	checkInRepo    repository.CheckInRepository
+	tokenRepo      repository.TokenRepository
 	authDomain     service.AuthDomainService
 	cacheService   cache.CacheService
+	tokenTTL       time.Duration
 }
 
 // NewAuthService creates a new auth service
 func NewAuthService(
 	userRepo repository.UserRepository,
 	playerRepo repository.PlayerRepository,
+	checkInRepo repository.CheckInRepository,
+	tokenRepo repository.TokenRepository,
 	authDomain service.AuthDomainService,
 	cacheService cache.CacheService,
+	tokenTTL time.Duration,
 ) *AuthService {
 	return &AuthService{
 		userRepo:     userRepo,
 		playerRepo:   playerRepo,
+		checkInRepo:  checkInRepo,
+		tokenRepo:    tokenRepo,
 		authDomain:   authDomain,
 		cacheService: cacheService,
+		tokenTTL:     tokenTTL,
 	}
 }
 
 // Login handles user login
-func (s *AuthService) Login(req *dto.LoginRequest) (*dto.LoginResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
 	// Validate input
 	if err := s.authDomain.ValidateUsername(req.Username); err != nil {
 		return nil, err
 	}
 
-	// Always check database for login to get latest online status
+	// Always check database for login
 	// Cache is only used for subsequent operations, not for login verification
 	cacheKey := "user:" + req.Username
 
-	// Get user from database
-	user, err := s.userRepo.VerifyCredentials(req.Username, req.Password)
+	user, err := s.verifyCredentials(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
-		return nil, entity.NewDomainError("invalid username or password")
-	}
-
-	// Verify password
-	if !s.verifyPassword(req.Password, user.Password) {
-		return nil, entity.NewDomainError("invalid username or password")
-	}
 
-	// Check if user is already online (prevent duplicate login)
-	if user.OnlineStatus == 1 {
-		return nil, entity.NewDomainError("user is already logged in")
-	}
+	// A user who is already online logs in anyway; the hub closes the older
+	// connection, wherever it is, so the newest login takes over
 
 	// Update online status to 1 (online)
-	if err := s.userRepo.UpdateOnlineStatus(user.ID, 1); err != nil {
+	if err := s.userRepo.UpdateOnlineStatus(ctx, user.ID, 1); err != nil {
 		// Log error but don't fail login
 		// In production, you might want to handle this differently
 	}
@@ -71,14 +75,76 @@ func (s *AuthService) Login(req *dto.LoginRequest) (*dto.LoginResponse, error) {
 	// Cache user
 	s.cacheService.SetUser(cacheKey, user)
 
-	return &dto.LoginResponse{
+	response := &dto.LoginResponse{
 		UserID:   user.ID,
 		Username: user.Username,
+	}
+
+	// Tell the client whether today's check-in reward is waiting; a failed
+	// lookup only hides the flag
+	if record, err := s.checkInRepo.GetByUserID(ctx, user.ID); err == nil {
+		response.CheckInAvailable = checkInAvailable(record, time.Now())
+	}
+
+	return response, nil
+}
+
+// IssueToken verifies credentials and returns a bearer token for the HTTP
+// API. Unlike Login it leaves the online status alone, so a user can use
+// web tools while playing. Tokens are stored in the database so every node
+// accepts them.
+func (s *AuthService) IssueToken(ctx context.Context, req *dto.LoginRequest) (*dto.TokenResponse, error) {
+	if err := s.authDomain.ValidateUsername(req.Username); err != nil {
+		return nil, err
+	}
+
+	user, err := s.verifyCredentials(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.tokenRepo.DeleteExpired(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+
+	token := uuid.New().String()
+	expiresAt := now.Add(s.tokenTTL)
+	if err := s.tokenRepo.Create(ctx, &entity.APIToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Token:     token,
+		ExpiresAt: expiresAt.Unix(),
 	}, nil
 }
 
+// ValidateToken returns the user a bearer token was issued to
+func (s *AuthService) ValidateToken(ctx context.Context, token string) (int, error) {
+	stored, err := s.tokenRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return 0, err
+	}
+	if stored == nil || !time.Now().Before(stored.ExpiresAt) {
+		return 0, entity.NewDomainError("invalid or expired token")
+	}
+	return stored.UserID, nil
+}
+
+// RevokeToken invalidates a bearer token
+func (s *AuthService) RevokeToken(ctx context.Context, token string) error {
+	return s.tokenRepo.Delete(ctx, hashToken(token))
+}
+
 // Register handles user registration
-func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.RegisterResponse, error) {
+func (s *AuthService) Register(ctx context.Context, req *dto.RegisterRequest) (*dto.RegisterResponse, error) {
 	// Validate input
 	if err := s.authDomain.ValidateUsername(req.Username); err != nil {
 		return nil, err
@@ -88,7 +154,7 @@ func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.RegisterResponse,
 	}
 
 	// Check if user already exists
-	exists, err := s.userRepo.Exists(req.Username)
+	exists, err := s.userRepo.Exists(ctx, req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +175,7 @@ func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.RegisterResponse,
 	}
 
 	// Save user
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
@@ -121,7 +187,7 @@ func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.RegisterResponse,
 		GameLevel:   1,
 		BloodEnergy: 100,
 	}
-	if err := s.playerRepo.Create(playerInfo); err != nil {
+	if err := s.playerRepo.Create(ctx, playerInfo); err != nil {
 		// Log error but don't fail registration
 		// In production, you might want to handle this with compensation
 	}
@@ -134,9 +200,9 @@ func (s *AuthService) Register(req *dto.RegisterRequest) (*dto.RegisterResponse,
 }
 
 // GetUserProfile gets user profile with player info
-func (s *AuthService) GetUserProfile(userID int) (*dto.UserProfile, error) {
+func (s *AuthService) GetUserProfile(ctx context.Context, userID int) (*dto.UserProfile, error) {
 	// Get user info
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -145,7 +211,7 @@ func (s *AuthService) GetUserProfile(userID int) (*dto.UserProfile, error) {
 	}
 
 	// Get player info
-	playerInfo, err := s.playerRepo.GetByUserID(userID)
+	playerInfo, err := s.playerRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +224,7 @@ func (s *AuthService) GetUserProfile(userID int) (*dto.UserProfile, error) {
 			GameLevel:   1,
 			BloodEnergy: 100,
 		}
-		s.playerRepo.Create(playerInfo)
+		s.playerRepo.Create(ctx, playerInfo)
 	}
 
 	return &dto.UserProfile{
@@ -172,14 +238,14 @@ func (s *AuthService) GetUserProfile(userID int) (*dto.UserProfile, error) {
 }
 
 // Logout handles user logout
-func (s *AuthService) Logout(userID int) error {
+func (s *AuthService) Logout(ctx context.Context, userID int) error {
 	// Update online status to 0 (offline)
-	if err := s.userRepo.UpdateOnlineStatus(userID, 0); err != nil {
+	if err := s.userRepo.UpdateOnlineStatus(ctx, userID, 0); err != nil {
 		// Log error but continue with logout process
 	}
 
 	// Clear cache
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err == nil && user != nil {
 		cacheKey := "user:" + user.Username
 		s.cacheService.Delete(cacheKey)
@@ -188,6 +254,31 @@ func (s *AuthService) Logout(userID int) error {
 	return nil
 }
 
+// verifyCredentials loads the user and checks the password
+func (s *AuthService) verifyCredentials(ctx context.Context, req *dto.LoginRequest) (*entity.User, error) {
+	// Get user from database
+	user, err := s.userRepo.VerifyCredentials(ctx, req.Username, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, entity.NewDomainError("invalid username or password")
+	}
+
+	// Verify password
+	if !s.verifyPassword(req.Password, user.Password) {
+		return nil, entity.NewDomainError("invalid username or password")
+	}
+
+	return user, nil
+}
+
+// hashToken returns the form a bearer token is stored in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // hashPassword hashes password using bcrypt
 func (s *AuthService) hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
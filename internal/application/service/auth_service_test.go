@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	domainService "GameServer/internal/domain/service"
+	"GameServer/internal/infrastructure/cache"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestAuthService returns an auth service with its own cache, as on a
+// separate node, sharing the given repositories
+func newTestAuthService(users *fakeUserRepo, tokens *fakeTokenRepo, ttl time.Duration) *AuthService {
+	return NewAuthService(users, nil, newFakeCheckInRepo(), tokens,
+		domainService.NewAuthDomainService(bcrypt.MinCost), cache.NewMemoryCache(), ttl)
+}
+
+func TestAPITokensAcrossNodes(t *testing.T) {
+	ctx := context.Background()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := newFakeUserRepo(&entity.User{ID: 7, Username: "alice", Password: string(hash)})
+	tokens := newFakeTokenRepo()
+
+	nodeA := newTestAuthService(users, tokens, time.Hour)
+	nodeB := newTestAuthService(users, tokens, time.Hour)
+
+	issued, err := nodeA.IssueToken(ctx, &dto.LoginRequest{Username: "alice", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	for hash := range tokens.tokens {
+		if hash == issued.Token {
+			t.Error("token stored in plain text")
+		}
+	}
+
+	tests := []struct {
+		name    string
+		node    *AuthService
+		token   string
+		wantID  int
+		wantErr bool
+	}{
+		{name: "issuing node", node: nodeA, token: issued.Token, wantID: 7},
+		{name: "other node", node: nodeB, token: issued.Token, wantID: 7},
+		{name: "unknown token", node: nodeB, token: "not-a-token", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, err := tt.node.ValidateToken(ctx, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToken error = %v, want error %v", err, tt.wantErr)
+			}
+			if userID != tt.wantID {
+				t.Errorf("ValidateToken = %d, want %d", userID, tt.wantID)
+			}
+		})
+	}
+
+	if err := nodeB.RevokeToken(ctx, issued.Token); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if _, err := nodeA.ValidateToken(ctx, issued.Token); err == nil {
+		t.Error("token revoked on another node still valid")
+	}
+}
+
+func TestAPITokenExpiry(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	users := newFakeUserRepo(&entity.User{ID: 7, Username: "alice", Password: string(hash)})
+	tokens := newFakeTokenRepo()
+	auth := newTestAuthService(users, tokens, time.Hour)
+
+	expired := "expired-token"
+	tokens.Create(ctx, &entity.APIToken{TokenHash: hashToken(expired), UserID: 7, ExpiresAt: time.Now().Add(-time.Minute)})
+
+	if _, err := auth.ValidateToken(ctx, expired); err == nil {
+		t.Error("expired token accepted")
+	}
+
+	// Issuing a new token clears the user's expired ones
+	if _, err := auth.IssueToken(ctx, &dto.LoginRequest{Username: "alice", Password: "secret123"}); err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	if token, _ := tokens.GetByHash(ctx, hashToken(expired)); token != nil {
+		t.Error("expired token kept after issuing a new one")
+	}
+}
+
+func TestLoginTakesOver(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+
+	tests := []struct {
+		name         string
+		onlineStatus int
+		password     string
+		wantErr      bool
+	}{
+		{name: "offline user", onlineStatus: 0, password: "secret123"},
+		{name: "user already online", onlineStatus: 1, password: "secret123"},
+		{name: "wrong password", onlineStatus: 1, password: "wrong", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserRepo(&entity.User{ID: 7, Username: "alice", Password: string(hash), OnlineStatus: tt.onlineStatus})
+			auth := newTestAuthService(users, newFakeTokenRepo(), time.Hour)
+
+			response, err := auth.Login(ctx, &dto.LoginRequest{Username: "alice", Password: tt.password})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Login error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if response.UserID != 7 {
+				t.Errorf("UserID = %d, want 7", response.UserID)
+			}
+			if user, _ := users.GetByID(ctx, 7); user.OnlineStatus != 1 {
+				t.Errorf("online status = %d after login, want 1", user.OnlineStatus)
+			}
+		})
+	}
+}
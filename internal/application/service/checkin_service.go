@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// Ledger reason code for check-in rewards and make-up costs
+const checkInReason = "checkin"
+
+// CheckInService handles daily check-ins. Each UTC day checked in extends
+// the streak and earns the next reward of the calendar; a missed day breaks
+// the streak unless it is made up before checking in again.
+type CheckInService struct {
+	checkInRepo repository.CheckInRepository
+	rewards     *rewardGranter
+	calendar    *entity.CheckInCalendar
+}
+
+// NewCheckInService creates a new check-in service for the given calendar
+func NewCheckInService(
+	checkInRepo repository.CheckInRepository,
+	walletService *WalletService,
+	inventoryService *InventoryService,
+	calendar *entity.CheckInCalendar,
+) *CheckInService {
+	return &CheckInService{
+		checkInRepo: checkInRepo,
+		rewards:     &rewardGranter{walletService: walletService, inventoryService: inventoryService},
+		calendar:    calendar,
+	}
+}
+
+// GetStatus returns the user's streak and the reward calendar
+func (s *CheckInService) GetStatus(ctx context.Context, userID int) (*dto.CheckInStatusResponse, error) {
+	record, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := utcDay(time.Now())
+
+	streak := record.Streak
+	makeUpDays := s.makeUpDays(record, today)
+	if missedDays(record, today) > makeUpDays {
+		streak = 0
+	}
+	checkedIn := record.LastDay == today
+
+	// Days of the current cycle already claimed; a completed cycle starts
+	// over with the next check-in
+	claimed := streak % len(s.calendar.Days)
+	if claimed == 0 && streak > 0 && checkedIn {
+		claimed = len(s.calendar.Days)
+	}
+
+	response := &dto.CheckInStatusResponse{
+		CheckedIn:  checkedIn,
+		Streak:     streak,
+		TotalDays:  record.TotalDays,
+		MakeUpDays: makeUpDays,
+		Calendar:   make([]*dto.CheckInDayResponse, 0, len(s.calendar.Days)),
+	}
+	if makeUpDays > 0 {
+		response.MakeUpCurrency = s.calendar.MakeUp.Currency
+		response.MakeUpCost = s.calendar.MakeUp.Cost
+	}
+	for i, reward := range s.calendar.Days {
+		response.Calendar = append(response.Calendar, &dto.CheckInDayResponse{
+			Day:     i + 1,
+			Reward:  rewardResponse(reward),
+			Claimed: i < claimed,
+		})
+	}
+	return response, nil
+}
+
+// Claim checks the user in for today, or with MakeUp for the oldest missed
+// day, and grants that day's reward. If the reward cannot be granted, for
+// example because an item stack is full or the make-up cost cannot be
+// paid, the check-in is undone.
+func (s *CheckInService) Claim(ctx context.Context, req *dto.CheckInClaimRequest) (*dto.CheckInClaimResponse, error) {
+	record, err := s.load(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	today := utcDay(time.Now())
+
+	next := *record
+	next.TotalDays++
+	var cost []*entity.LedgerEntry
+	if req.MakeUp {
+		if s.makeUpDays(record, today) == 0 {
+			return nil, entity.NewDomainError("no missed day can be made up")
+		}
+		next.LastDay = record.LastDay + 1
+		next.Streak = record.Streak + 1
+		if s.calendar.MakeUp.Cost > 0 {
+			cost = append(cost, &entity.LedgerEntry{
+				UserID:   req.UserID,
+				Currency: s.calendar.MakeUp.Currency,
+				Amount:   -s.calendar.MakeUp.Cost,
+				Reason:   checkInReason,
+			})
+		}
+	} else {
+		if record.LastDay == today {
+			return nil, entity.NewDomainError("already checked in today")
+		}
+		next.LastDay = today
+		next.Streak = 1
+		if missedDays(record, today) == 0 {
+			next.Streak = record.Streak + 1
+		}
+	}
+
+	saved, err := s.checkInRepo.Save(ctx, &next, record.LastDay)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, entity.NewDomainError("check-in changed concurrently, please retry")
+	}
+
+	day := (next.Streak-1)%len(s.calendar.Days) + 1
+	reward := s.calendar.Days[day-1]
+	referenceID := fmt.Sprintf("checkin:%d:%d", req.UserID, next.LastDay)
+	for _, entry := range cost {
+		entry.ReferenceID = referenceID
+	}
+	if err := s.rewards.grant(ctx, req.UserID, reward, checkInReason, referenceID, cost); err != nil {
+		s.undo(ctx, record, next.LastDay)
+		return nil, err
+	}
+
+	return &dto.CheckInClaimResponse{
+		Day:       day,
+		Streak:    next.Streak,
+		TotalDays: next.TotalDays,
+		MakeUp:    req.MakeUp,
+		Reward:    rewardResponse(reward),
+	}, nil
+}
+
+// load returns the user's check-in record, or an empty one if they never
+// checked in
+func (s *CheckInService) load(ctx context.Context, userID int) (*entity.CheckIn, error) {
+	record, err := s.checkInRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		record = &entity.CheckIn{UserID: userID}
+	}
+	return record, nil
+}
+
+// undo restores the record saved before a check-in whose reward failed.
+// It runs even if the request's deadline has passed.
+func (s *CheckInService) undo(ctx context.Context, previous *entity.CheckIn, claimedDay int) {
+	saved, err := s.checkInRepo.Save(context.WithoutCancel(ctx), previous, claimedDay)
+	if err != nil || !saved {
+		log.Printf("Failed to undo check-in of user %d for day %d: %v", previous.UserID, claimedDay, err)
+	}
+}
+
+// makeUpDays returns how many missed days the user can still make up:
+// all of them while the gap is within the make-up limit, otherwise none
+func (s *CheckInService) makeUpDays(record *entity.CheckIn, today int) int {
+	missed := missedDays(record, today)
+	if missed > s.calendar.MakeUp.MaxDays {
+		return 0
+	}
+	return missed
+}
+
+// missedDays returns how many days passed without a check-in between the
+// user's last check-in and today
+func missedDays(record *entity.CheckIn, today int) int {
+	if record.LastDay == 0 {
+		return 0
+	}
+	return max(today-record.LastDay-1, 0)
+}
+
+// checkInAvailable reports whether the user has not checked in today
+func checkInAvailable(record *entity.CheckIn, now time.Time) bool {
+	return record == nil || record.LastDay != utcDay(now)
+}
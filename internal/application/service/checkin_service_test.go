@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// testCalendar is a three-day cycle whose last day also grants an item
+// that stacks to one
+func testCalendar() *entity.CheckInCalendar {
+	return &entity.CheckInCalendar{
+		Days: []*entity.Reward{
+			{Currencies: map[string]int{"gold": 100}},
+			{Currencies: map[string]int{"gold": 200}},
+			{Currencies: map[string]int{"gems": 10}, Items: map[int]int{3001: 1}},
+		},
+		MakeUp: entity.CheckInMakeUp{MaxDays: 2, Currency: "gems", Cost: 20},
+	}
+}
+
+// newTestCheckInService returns a check-in service over in-memory repositories
+func newTestCheckInService() (*CheckInService, *fakeCheckInRepo, *fakeWalletRepo, *fakeInventoryRepo) {
+	checkIns := newFakeCheckInRepo()
+	wallet := newFakeWalletRepo()
+	inventory := newFakeInventoryRepo()
+	items := []*entity.ItemDefinition{{ID: 3001, Name: "Stage Ticket", Type: "ticket", MaxStack: 1}}
+	service := NewCheckInService(checkIns, NewWalletService(wallet, []string{"gold", "gems"}),
+		NewInventoryService(inventory, nil, items), testCalendar())
+	return service, checkIns, wallet, inventory
+}
+
+func TestMakeUpDays(t *testing.T) {
+	const today = 20000
+	service, _, _, _ := newTestCheckInService()
+
+	tests := []struct {
+		name       string
+		lastDay    int
+		wantMissed int
+		wantMakeUp int
+	}{
+		{name: "never checked in", lastDay: 0, wantMissed: 0, wantMakeUp: 0},
+		{name: "checked in today", lastDay: today, wantMissed: 0, wantMakeUp: 0},
+		{name: "checked in yesterday", lastDay: today - 1, wantMissed: 0, wantMakeUp: 0},
+		{name: "one day missed", lastDay: today - 2, wantMissed: 1, wantMakeUp: 1},
+		{name: "gap at the limit", lastDay: today - 3, wantMissed: 2, wantMakeUp: 2},
+		{name: "gap past the limit", lastDay: today - 4, wantMissed: 3, wantMakeUp: 0},
+		{name: "clock behind the record", lastDay: today + 1, wantMissed: 0, wantMakeUp: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := &entity.CheckIn{UserID: 7, Streak: 4, LastDay: tt.lastDay}
+			if missed := missedDays(record, today); missed != tt.wantMissed {
+				t.Errorf("missedDays = %d, want %d", missed, tt.wantMissed)
+			}
+			if makeUp := service.makeUpDays(record, today); makeUp != tt.wantMakeUp {
+				t.Errorf("makeUpDays = %d, want %d", makeUp, tt.wantMakeUp)
+			}
+		})
+	}
+}
+
+func TestClaimCheckIn(t *testing.T) {
+	today := utcDay(time.Now())
+
+	tests := []struct {
+		name        string
+		record      *entity.CheckIn // Relative to today; nil if never checked in
+		gems        int
+		stack       int
+		makeUp      bool
+		wantErr     bool
+		wantDay     int
+		wantStreak  int
+		wantLastDay int
+		wantGold    int
+		wantGems    int
+	}{
+		{
+			name:        "first check-in",
+			wantDay:     1,
+			wantStreak:  1,
+			wantLastDay: today,
+			wantGold:    100,
+		},
+		{
+			name:        "already checked in today",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today, TotalDays: 1},
+			wantErr:     true,
+			wantStreak:  1,
+			wantLastDay: today,
+		},
+		{
+			name:        "consecutive day",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today - 1, TotalDays: 1},
+			wantDay:     2,
+			wantStreak:  2,
+			wantLastDay: today,
+			wantGold:    200,
+		},
+		{
+			name:        "cycle starts over",
+			record:      &entity.CheckIn{Streak: 3, LastDay: today - 1, TotalDays: 3},
+			wantDay:     1,
+			wantStreak:  4,
+			wantLastDay: today,
+			wantGold:    100,
+		},
+		{
+			name:        "missed day breaks the streak",
+			record:      &entity.CheckIn{Streak: 2, LastDay: today - 2, TotalDays: 2},
+			wantDay:     1,
+			wantStreak:  1,
+			wantLastDay: today,
+			wantGold:    100,
+		},
+		{
+			name:        "make up the missed day",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today - 2, TotalDays: 1},
+			gems:        20,
+			makeUp:      true,
+			wantDay:     2,
+			wantStreak:  2,
+			wantLastDay: today - 1,
+			wantGold:    200,
+		},
+		{
+			name:        "make up the oldest of two missed days",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today - 3, TotalDays: 1},
+			gems:        50,
+			makeUp:      true,
+			wantDay:     2,
+			wantStreak:  2,
+			wantLastDay: today - 2,
+			wantGold:    200,
+			wantGems:    30,
+		},
+		{
+			name:        "make-up cost not affordable",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today - 2, TotalDays: 1},
+			gems:        10,
+			makeUp:      true,
+			wantErr:     true,
+			wantStreak:  1,
+			wantLastDay: today - 2,
+			wantGems:    10,
+		},
+		{
+			name:        "nothing to make up",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today - 1, TotalDays: 1},
+			gems:        20,
+			makeUp:      true,
+			wantErr:     true,
+			wantStreak:  1,
+			wantLastDay: today - 1,
+			wantGems:    20,
+		},
+		{
+			name:        "gap too long to make up",
+			record:      &entity.CheckIn{Streak: 1, LastDay: today - 4, TotalDays: 1},
+			gems:        100,
+			makeUp:      true,
+			wantErr:     true,
+			wantStreak:  1,
+			wantLastDay: today - 4,
+			wantGems:    100,
+		},
+		{
+			name:        "reward that does not fit is undone",
+			record:      &entity.CheckIn{Streak: 2, LastDay: today - 1, TotalDays: 2},
+			stack:       1,
+			wantErr:     true,
+			wantStreak:  2,
+			wantLastDay: today - 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service, checkIns, wallet, inventory := newTestCheckInService()
+			if tt.record != nil {
+				record := *tt.record
+				record.UserID = 7
+				checkIns.records[7] = record
+			}
+			wallet.balances["gems"] = tt.gems
+			inventory.stacks[3001] = tt.stack
+
+			response, err := service.Claim(ctx, &dto.CheckInClaimRequest{UserID: 7, MakeUp: tt.makeUp})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Claim error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (response.Day != tt.wantDay || response.Streak != tt.wantStreak) {
+				t.Errorf("claimed day %d with streak %d, want day %d with streak %d",
+					response.Day, response.Streak, tt.wantDay, tt.wantStreak)
+			}
+
+			saved := checkIns.records[7]
+			if saved.Streak != tt.wantStreak || saved.LastDay != tt.wantLastDay {
+				t.Errorf("saved streak %d on day %d, want streak %d on day %d",
+					saved.Streak, saved.LastDay, tt.wantStreak, tt.wantLastDay)
+			}
+			if wallet.balances["gold"] != tt.wantGold || wallet.balances["gems"] != tt.wantGems {
+				t.Errorf("balances %v, want gold %d and gems %d", wallet.balances, tt.wantGold, tt.wantGems)
+			}
+		})
+	}
+}
+
+func TestCheckInStatus(t *testing.T) {
+	today := utcDay(time.Now())
+
+	tests := []struct {
+		name           string
+		record         *entity.CheckIn
+		wantCheckedIn  bool
+		wantStreak     int
+		wantMakeUpDays int
+		wantClaimed    int
+	}{
+		{name: "never checked in", wantClaimed: 0},
+		{name: "checked in today", record: &entity.CheckIn{Streak: 2, LastDay: today}, wantCheckedIn: true, wantStreak: 2, wantClaimed: 2},
+		{name: "completed cycle today", record: &entity.CheckIn{Streak: 3, LastDay: today}, wantCheckedIn: true, wantStreak: 3, wantClaimed: 3},
+		{name: "completed cycle yesterday", record: &entity.CheckIn{Streak: 3, LastDay: today - 1}, wantStreak: 3, wantClaimed: 0},
+		{name: "missed day can be made up", record: &entity.CheckIn{Streak: 2, LastDay: today - 2}, wantStreak: 2, wantMakeUpDays: 1, wantClaimed: 2},
+		{name: "streak lost", record: &entity.CheckIn{Streak: 2, LastDay: today - 4}, wantStreak: 0, wantClaimed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, checkIns, _, _ := newTestCheckInService()
+			if tt.record != nil {
+				checkIns.records[7] = *tt.record
+			}
+
+			status, err := service.GetStatus(context.Background(), 7)
+			if err != nil {
+				t.Fatalf("GetStatus: %v", err)
+			}
+			if status.CheckedIn != tt.wantCheckedIn || status.Streak != tt.wantStreak || status.MakeUpDays != tt.wantMakeUpDays {
+				t.Errorf("checked in %v, streak %d, %d make-up days, want %v, %d, %d",
+					status.CheckedIn, status.Streak, status.MakeUpDays, tt.wantCheckedIn, tt.wantStreak, tt.wantMakeUpDays)
+			}
+			claimed := 0
+			for _, day := range status.Calendar {
+				if day.Claimed {
+					claimed++
+				}
+			}
+			if claimed != tt.wantClaimed {
+				t.Errorf("%d calendar days claimed, want %d", claimed, tt.wantClaimed)
+			}
+			if tt.wantMakeUpDays > 0 && (status.MakeUpCurrency != "gems" || status.MakeUpCost != 20) {
+				t.Errorf("make-up priced %d %s, want 20 gems", status.MakeUpCost, status.MakeUpCurrency)
+			}
+		})
+	}
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeUserRepo keeps users in memory. Methods the tests do not use are left
+// to the embedded interface and panic when called.
+type fakeUserRepo struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[int]*entity.User
+}
+
+func newFakeUserRepo(users ...*entity.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[int]*entity.User)}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) VerifyCredentials(ctx context.Context, username, password string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) UpdateOnlineStatus(ctx context.Context, userID int, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[userID]; ok {
+		user.OnlineStatus = status
+	}
+	return nil
+}
+
+// fakeTokenRepo keeps API tokens in memory
+type fakeTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]entity.APIToken
+}
+
+func newFakeTokenRepo() *fakeTokenRepo {
+	return &fakeTokenRepo{tokens: make(map[string]entity.APIToken)}
+}
+
+func (r *fakeTokenRepo) Create(ctx context.Context, token *entity.APIToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *fakeTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*entity.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token, ok := r.tokens[tokenHash]; ok {
+		return &token, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeTokenRepo) Delete(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tokens, tokenHash)
+	return nil
+}
+
+func (r *fakeTokenRepo) DeleteExpired(ctx context.Context, userID int, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, token := range r.tokens {
+		if token.UserID == userID && token.ExpiresAt.Before(before) {
+			delete(r.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// fakeCheckInRepo keeps check-in records in memory
+type fakeCheckInRepo struct {
+	mu      sync.Mutex
+	records map[int]entity.CheckIn
+}
+
+func newFakeCheckInRepo() *fakeCheckInRepo {
+	return &fakeCheckInRepo{records: make(map[int]entity.CheckIn)}
+}
+
+func (r *fakeCheckInRepo) GetByUserID(ctx context.Context, userID int) (*entity.CheckIn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record, ok := r.records[userID]; ok {
+		return &record, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeCheckInRepo) Save(ctx context.Context, record *entity.CheckIn, oldLastDay int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.records[record.UserID].LastDay != oldLastDay {
+		return false, nil
+	}
+	r.records[record.UserID] = *record
+	return true, nil
+}
+
+// fakePlayerRepo keeps player info in memory. Methods the tests do not use
+// are left to the embedded interface and panic when called, so a test fails
+// if a service falls back to a full-row Update.
+type fakePlayerRepo struct {
+	repository.PlayerRepository
+
+	mu      sync.Mutex
+	players map[int]*entity.PlayerInfo
+}
+
+func newFakePlayerRepo(players ...*entity.PlayerInfo) *fakePlayerRepo {
+	r := &fakePlayerRepo{players: make(map[int]*entity.PlayerInfo)}
+	for _, player := range players {
+		r.players[player.UserID] = player
+	}
+	return r
+}
+
+func (r *fakePlayerRepo) GetByUserID(ctx context.Context, userID int) (*entity.PlayerInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if player, ok := r.players[userID]; ok {
+		copied := *player
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakePlayerRepo) UpdateProgress(ctx context.Context, userID, oldLevel, oldExperience, newLevel, newExperience int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	player := r.players[userID]
+	if player == nil || player.Level != oldLevel || player.Experience != oldExperience {
+		return false, nil
+	}
+	player.Level, player.Experience = newLevel, newExperience
+	return true, nil
+}
+
+func (r *fakePlayerRepo) UpdateEnergy(ctx context.Context, player *entity.PlayerInfo, oldEnergy int, oldUpdatedAt int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.players[player.UserID]
+	if stored == nil || stored.BloodEnergy != oldEnergy || stored.EnergyUpdatedAt != oldUpdatedAt {
+		return false, nil
+	}
+	stored.BloodEnergy, stored.EnergyUpdatedAt = player.BloodEnergy, player.EnergyUpdatedAt
+	stored.EnergyPurchases, stored.EnergyPurchaseDay = player.EnergyPurchases, player.EnergyPurchaseDay
+	return true, nil
+}
+
+func (r *fakePlayerRepo) AdvanceGameLevel(ctx context.Context, userID, fromStage int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	player := r.players[userID]
+	if player == nil || player.GameLevel != fromStage {
+		return false, nil
+	}
+	player.GameLevel++
+	return true, nil
+}
+
+// fakeExperienceRepo serves a fixed experience curve
+type fakeExperienceRepo struct {
+	repository.ExperienceRepository
+	levels []*entity.Experience
+}
+
+func (r *fakeExperienceRepo) GetAllLevels(ctx context.Context) ([]*entity.Experience, error) {
+	return r.levels, nil
+}
+
+// fakeSuspicionRepo keeps the suspicion log and flags in memory
+type fakeSuspicionRepo struct {
+	mu      sync.Mutex
+	log     []entity.Suspicion
+	flagged map[int]string
+}
+
+func newFakeSuspicionRepo() *fakeSuspicionRepo {
+	return &fakeSuspicionRepo{flagged: make(map[int]string)}
+}
+
+func (r *fakeSuspicionRepo) Record(ctx context.Context, suspicion *entity.Suspicion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, *suspicion)
+	return nil
+}
+
+func (r *fakeSuspicionRepo) CountSince(ctx context.Context, userID int, since time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, suspicion := range r.log {
+		if suspicion.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeSuspicionRepo) FlagAccount(ctx context.Context, userID int, reason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flagged[userID]; ok {
+		return false, nil
+	}
+	r.flagged[userID] = reason
+	return true, nil
+}
+
+// rules returns the rules broken so far, in order
+func (r *fakeSuspicionRepo) rules() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rules := make([]string, 0, len(r.log))
+	for _, suspicion := range r.log {
+		rules = append(rules, suspicion.Rule)
+	}
+	return rules
+}
+
+// fakeXPRateRepo counts experience per user and minute in memory, like the
+// shared experience_rate table
+type fakeXPRateRepo struct {
+	mu     sync.Mutex
+	counts map[int]map[int64]int
+}
+
+func newFakeXPRateRepo() *fakeXPRateRepo {
+	return &fakeXPRateRepo{counts: make(map[int]map[int64]int)}
+}
+
+func (r *fakeXPRateRepo) Reserve(ctx context.Context, userID int, minute int64, amount, limit int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts[userID] == nil {
+		r.counts[userID] = make(map[int64]int)
+	}
+	for m := range r.counts[userID] {
+		if m < minute {
+			delete(r.counts[userID], m)
+		}
+	}
+	if r.counts[userID][minute]+amount > limit {
+		return false, nil
+	}
+	r.counts[userID][minute] += amount
+	return true, nil
+}
+
+func (r *fakeXPRateRepo) Release(ctx context.Context, userID int, minute int64, amount int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if counts := r.counts[userID]; counts != nil {
+		counts[minute] = max(counts[minute]-amount, 0)
+	}
+	return nil
+}
+
+// fakeStageRepo keeps stage records in memory
+type fakeStageRepo struct {
+	mu      sync.Mutex
+	records map[[2]int]*entity.StageRecord
+}
+
+func newFakeStageRepo() *fakeStageRepo {
+	return &fakeStageRepo{records: make(map[[2]int]*entity.StageRecord)}
+}
+
+func (r *fakeStageRepo) GetByUserID(ctx context.Context, userID int) ([]*entity.StageRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []*entity.StageRecord
+	for key, record := range r.records {
+		if key[0] == userID {
+			copied := *record
+			records = append(records, &copied)
+		}
+	}
+	return records, nil
+}
+
+func (r *fakeStageRepo) RecordClear(ctx context.Context, userID, stage, clearTime, stars int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[[2]int{userID, stage}]
+	if !ok {
+		r.records[[2]int{userID, stage}] = &entity.StageRecord{UserID: userID, Stage: stage, Stars: stars, BestTime: clearTime, ClearCount: 1}
+		return true, nil
+	}
+	record.ClearCount++
+	record.Stars = max(record.Stars, stars)
+	record.BestTime = min(record.BestTime, clearTime)
+	return false, nil
+}
+
+func (r *fakeStageRepo) GetByStage(ctx context.Context, userID, stage int) (*entity.StageRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record, ok := r.records[[2]int{userID, stage}]; ok {
+		copied := *record
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// fakeWalletRepo keeps balances and the ledger in memory
+type fakeWalletRepo struct {
+	repository.WalletRepository
+
+	mu       sync.Mutex
+	balances map[string]int // Keyed by currency; the tests use one user
+	ledger   []*entity.LedgerEntry
+}
+
+func newFakeWalletRepo() *fakeWalletRepo {
+	return &fakeWalletRepo{balances: make(map[string]int)}
+}
+
+func (r *fakeWalletRepo) Apply(ctx context.Context, entries []*entity.LedgerEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Entries apply in order, as in one transaction
+	balances := make(map[string]int, len(r.balances))
+	for currency, balance := range r.balances {
+		balances[currency] = balance
+	}
+	after := make([]int, len(entries))
+	for i, entry := range entries {
+		balances[entry.Currency] += entry.Amount
+		if balances[entry.Currency] < 0 {
+			return entity.ErrInsufficientBalance
+		}
+		after[i] = balances[entry.Currency]
+	}
+	r.balances = balances
+	for i, entry := range entries {
+		entry.ID = len(r.ledger) + 1
+		entry.BalanceAfter = after[i]
+		copied := *entry
+		r.ledger = append(r.ledger, &copied)
+	}
+	return nil
+}
+
+// fakeInventoryRepo keeps item stacks in memory
+type fakeInventoryRepo struct {
+	repository.InventoryRepository
+
+	mu     sync.Mutex
+	stacks map[int]int // Keyed by item ID; the tests use one user
+}
+
+func newFakeInventoryRepo() *fakeInventoryRepo {
+	return &fakeInventoryRepo{stacks: make(map[int]int)}
+}
+
+func (r *fakeInventoryRepo) AddItems(ctx context.Context, userID int, grants []*entity.ItemGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, grant := range grants {
+		if grant.MaxStack > 0 && r.stacks[grant.ItemID]+grant.Count > grant.MaxStack {
+			return entity.ErrStackFull
+		}
+	}
+	for _, grant := range grants {
+		r.stacks[grant.ItemID] += grant.Count
+	}
+	return nil
+}
+
+func (r *fakeInventoryRepo) ConsumeItem(ctx context.Context, userID, itemID, count int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stacks[itemID] < count {
+		return false, nil
+	}
+	r.stacks[itemID] -= count
+	return true, nil
+}
+
+// fakeMailRepo keeps mail in memory
+type fakeMailRepo struct {
+	repository.MailRepository
+
+	mu    sync.Mutex
+	mails map[int]*entity.Mail // Keyed by mail ID; the tests use one user
+	bulk  []*entity.Mail       // Mail sent with CreateBulk
+}
+
+func newFakeMailRepo() *fakeMailRepo {
+	return &fakeMailRepo{mails: make(map[int]*entity.Mail)}
+}
+
+func (r *fakeMailRepo) CreateBulk(ctx context.Context, mail *entity.Mail, recipients *entity.MailRecipients) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *mail
+	r.bulk = append(r.bulk, &copied)
+	return len(recipients.UserIDs), nil
+}
+
+func (r *fakeMailRepo) GetByID(ctx context.Context, userID, mailID int) (*entity.Mail, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if mail, ok := r.mails[mailID]; ok {
+		copied := *mail
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeMailRepo) MarkClaimed(ctx context.Context, userID, mailID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mail := r.mails[mailID]
+	if mail == nil || mail.Claimed || !mail.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	mail.Claimed = true
+	return true, nil
+}
+
+func (r *fakeMailRepo) UnmarkClaimed(ctx context.Context, userID, mailID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if mail := r.mails[mailID]; mail != nil {
+		mail.Claimed = false
+	}
+	return nil
+}
+
+// fakeFriendRepo keeps accepted friendships in memory
+type fakeFriendRepo struct {
+	repository.FriendRepository
+
+	friends [][2]int
+}
+
+func (r *fakeFriendRepo) AreFriends(ctx context.Context, userID1, userID2 int) (bool, error) {
+	for _, pair := range r.friends {
+		if pair == [2]int{userID1, userID2} || pair == [2]int{userID2, userID1} {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeFriendRepo) GetFriendsByUserID(ctx context.Context, userID int) ([]*entity.Friend, error) {
+	var friends []*entity.Friend
+	for _, pair := range r.friends {
+		if pair[0] == userID || pair[1] == userID {
+			friends = append(friends, &entity.Friend{FromUserID: pair[0], ToUserID: pair[1], Status: "accepted"})
+		}
+	}
+	return friends, nil
+}
+
+// fakePrivacyRepo keeps privacy settings in memory
+type fakePrivacyRepo struct {
+	repository.PrivacyRepository
+
+	settings map[int]*entity.PrivacySettings
+}
+
+func (r *fakePrivacyRepo) GetByUserID(ctx context.Context, userID int) (*entity.PrivacySettings, error) {
+	if settings, ok := r.settings[userID]; ok {
+		copied := *settings
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// fakeRankingRepo keeps ranking positions in memory
+type fakeRankingRepo struct {
+	repository.RankingRepository
+
+	mu        sync.Mutex
+	positions map[int]int // Keyed by user ID; every rank type shares it
+	values    map[string]int
+}
+
+func (r *fakeRankingRepo) GetUserRanking(ctx context.Context, userID int, rankType string) (*entity.Ranking, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if position, ok := r.positions[userID]; ok {
+		return &entity.Ranking{UserID: userID, RankType: rankType, RankPosition: position}, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeRankingRepo) UpdateUserRanking(ctx context.Context, userID int, rankType string, value int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = make(map[string]int)
+	}
+	r.values[rankType] = value
+	return nil
+}
+
+// fakeUserEquipRepo reports no equipped items
+type fakeUserEquipRepo struct {
+	repository.UserEquipRepository
+}
+
+func (r *fakeUserEquipRepo) GetEquippedItemDetails(ctx context.Context, userID int) ([]*entity.Equipment, error) {
+	return nil, nil
+}
+
+// fakePublisher records the topics events are published to
+type fakePublisher struct {
+	mu     sync.Mutex
+	topics []string
+}
+
+func (p *fakePublisher) Publish(topic, event string, data interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.topics = append(p.topics, topic)
+}
@@ -4,13 +4,17 @@ import (
 	"GameServer/internal/application/dto"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"GameServer/internal/domain/service"
+	"context"
 )
 
 // FriendService handles friend-related business logic
 type FriendService struct {
-	friendRepo repository.FriendRepository
-	userRepo   repository.UserRepository
-	playerRepo repository.PlayerRepository
+	friendRepo  repository.FriendRepository
+	userRepo    repository.UserRepository
+	playerRepo  repository.PlayerRepository
+	privacyRepo repository.PrivacyRepository
+	events      service.GameEventRecorder
 }
 
 // NewFriendService creates a new friend service
@@ -18,17 +22,24 @@ func NewFriendService(
 	friendRepo repository.FriendRepository,
 	userRepo repository.UserRepository,
 	playerRepo repository.PlayerRepository,
+	privacyRepo repository.PrivacyRepository,
 ) *FriendService {
 	return &FriendService{
-		friendRepo: friendRepo,
-		userRepo:   userRepo,
-		playerRepo: playerRepo,
+		friendRepo:  friendRepo,
+		userRepo:    userRepo,
+		playerRepo:  playerRepo,
+		privacyRepo: privacyRepo,
 	}
 }
 
+// SetEventRecorder sets where new friendships are recorded as gameplay events
+func (s *FriendService) SetEventRecorder(events service.GameEventRecorder) {
+	s.events = events
+}
+
 // GetFriends retrieves all friends for a user
-func (s *FriendService) GetFriends(userID int) ([]*dto.FriendResponse, error) {
-	friends, err := s.friendRepo.GetFriendsByUserID(userID)
+func (s *FriendService) GetFriends(ctx context.Context, userID int) ([]*dto.FriendResponse, error) {
+	friends, err := s.friendRepo.GetFriendsByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -41,13 +52,13 @@ func (s *FriendService) GetFriends(userID int) ([]*dto.FriendResponse, error) {
 		}
 
 		// Get friend's username
-		friendUser, err := s.userRepo.GetByID(friendUserID)
+		friendUser, err := s.userRepo.GetByID(ctx, friendUserID)
 		if err != nil {
 			continue // Skip this friend if we can't get user info
 		}
 
 		// Get friend's level
-		friendPlayer, err := s.playerRepo.GetByUserID(friendUserID)
+		friendPlayer, err := s.playerRepo.GetByUserID(ctx, friendUserID)
 		friendLevel := 1
 		if err == nil && friendPlayer != nil {
 			friendLevel = friendPlayer.Level
@@ -69,8 +80,8 @@ func (s *FriendService) GetFriends(userID int) ([]*dto.FriendResponse, error) {
 }
 
 // GetFriendRequests retrieves all pending friend requests for a user
-func (s *FriendService) GetFriendRequests(userID int) ([]*dto.FriendRequestResponse, error) {
-	requests, err := s.friendRepo.GetFriendRequestsByUserID(userID)
+func (s *FriendService) GetFriendRequests(ctx context.Context, userID int) ([]*dto.FriendRequestResponse, error) {
+	requests, err := s.friendRepo.GetFriendRequestsByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +89,7 @@ func (s *FriendService) GetFriendRequests(userID int) ([]*dto.FriendRequestRespo
 	var response []*dto.FriendRequestResponse
 	for _, request := range requests {
 		// Get requester's username
-		requester, err := s.userRepo.GetByID(request.FromUserID)
+		requester, err := s.userRepo.GetByID(ctx, request.FromUserID)
 		if err != nil {
 			continue // Skip this request if we can't get user info
 		}
@@ -99,9 +110,9 @@ func (s *FriendService) GetFriendRequests(userID int) ([]*dto.FriendRequestRespo
 }
 
 // SendFriendRequest sends a friend request
-func (s *FriendService) SendFriendRequest(fromUserID int, req *dto.AddFriendRequest) error {
+func (s *FriendService) SendFriendRequest(ctx context.Context, fromUserID int, req *dto.AddFriendRequest) error {
 	// Validate that users exist
-	fromUser, err := s.userRepo.GetByID(fromUserID)
+	fromUser, err := s.userRepo.GetByID(ctx, fromUserID)
 	if err != nil {
 		return err
 	}
@@ -109,7 +120,7 @@ func (s *FriendService) SendFriendRequest(fromUserID int, req *dto.AddFriendRequ
 		return entity.NewDomainError("sender user not found")
 	}
 
-	toUser, err := s.userRepo.GetByID(req.ToUserID)
+	toUser, err := s.userRepo.GetByID(ctx, req.ToUserID)
 	if err != nil {
 		return err
 	}
@@ -118,7 +129,7 @@ func (s *FriendService) SendFriendRequest(fromUserID int, req *dto.AddFriendRequ
 	}
 
 	// Check if users are already friends
-	areFriends, err := s.friendRepo.AreFriends(fromUserID, req.ToUserID)
+	areFriends, err := s.friendRepo.AreFriends(ctx, fromUserID, req.ToUserID)
 	if err != nil {
 		return err
 	}
@@ -127,7 +138,7 @@ func (s *FriendService) SendFriendRequest(fromUserID int, req *dto.AddFriendRequ
 	}
 
 	// Check if there's already a pending request
-	hasPending, err := s.friendRepo.HasPendingRequest(fromUserID, req.ToUserID)
+	hasPending, err := s.friendRepo.HasPendingRequest(ctx, fromUserID, req.ToUserID)
 	if err != nil {
 		return err
 	}
@@ -143,13 +154,13 @@ func (s *FriendService) SendFriendRequest(fromUserID int, req *dto.AddFriendRequ
 		Status:     "pending",
 	}
 
-	return s.friendRepo.CreateFriendRequest(friendRequest)
+	return s.friendRepo.CreateFriendRequest(ctx, friendRequest)
 }
 
 // AcceptFriendRequest accepts a friend request
-func (s *FriendService) AcceptFriendRequest(userID int, req *dto.FriendActionRequest) error {
+func (s *FriendService) AcceptFriendRequest(ctx context.Context, userID int, req *dto.FriendActionRequest) error {
 	// Verify that the request exists and belongs to the user
-	requests, err := s.friendRepo.GetFriendRequestsByUserID(userID)
+	requests, err := s.friendRepo.GetFriendRequestsByUserID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -166,13 +177,22 @@ func (s *FriendService) AcceptFriendRequest(userID int, req *dto.FriendActionReq
 		return entity.NewDomainError("friend request not found")
 	}
 
-	return s.friendRepo.AcceptFriendRequest(req.RequestID)
+	if err := s.friendRepo.AcceptFriendRequest(ctx, req.RequestID); err != nil {
+		return err
+	}
+
+	// Both players gained a friend
+	if s.events != nil {
+		s.events.RecordEvent(ctx, &entity.GameEvent{UserID: userID, Type: entity.GameEventAddFriend, Count: 1})
+		s.events.RecordEvent(ctx, &entity.GameEvent{UserID: targetRequest.FromUserID, Type: entity.GameEventAddFriend, Count: 1})
+	}
+	return nil
 }
 
 // RejectFriendRequest rejects a friend request
-func (s *FriendService) RejectFriendRequest(userID int, req *dto.FriendActionRequest) error {
+func (s *FriendService) RejectFriendRequest(ctx context.Context, userID int, req *dto.FriendActionRequest) error {
 	// Verify that the request exists and belongs to the user
-	requests, err := s.friendRepo.GetFriendRequestsByUserID(userID)
+	requests, err := s.friendRepo.GetFriendRequestsByUserID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -189,13 +209,13 @@ func (s *FriendService) RejectFriendRequest(userID int, req *dto.FriendActionReq
 		return entity.NewDomainError("friend request not found")
 	}
 
-	return s.friendRepo.RejectFriendRequest(req.RequestID)
+	return s.friendRepo.RejectFriendRequest(ctx, req.RequestID)
 }
 
 // RemoveFriend removes a friendship
-func (s *FriendService) RemoveFriend(userID int, req *dto.RemoveFriendRequest) error {
+func (s *FriendService) RemoveFriend(ctx context.Context, userID int, req *dto.RemoveFriendRequest) error {
 	// Verify that users are friends
-	areFriends, err := s.friendRepo.AreFriends(userID, req.FriendUserID)
+	areFriends, err := s.friendRepo.AreFriends(ctx, userID, req.FriendUserID)
 	if err != nil {
 		return err
 	}
@@ -203,12 +223,13 @@ func (s *FriendService) RemoveFriend(userID int, req *dto.RemoveFriendRequest) e
 		return entity.NewDomainError("users are not friends")
 	}
 
-	return s.friendRepo.RemoveFriend(userID, req.FriendUserID)
+	return s.friendRepo.RemoveFriend(ctx, userID, req.FriendUserID)
 }
 
-// GetFriendRanking retrieves ranking for user's friends
-func (s *FriendService) GetFriendRanking(userID int) ([]*dto.FriendRankResponse, error) {
-	friends, err := s.friendRepo.GetFriendsByUserID(userID)
+// GetFriendRanking retrieves ranking for user's friends. Friends who hide
+// their rankings are left out.
+func (s *FriendService) GetFriendRanking(ctx context.Context, userID int) ([]*dto.FriendRankResponse, error) {
+	friends, err := s.friendRepo.GetFriendsByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -220,14 +241,22 @@ func (s *FriendService) GetFriendRanking(userID int) ([]*dto.FriendRankResponse,
 			friendUserID = friend.FromUserID
 		}
 
+		settings, err := s.privacyRepo.GetByUserID(ctx, friendUserID)
+		if err != nil {
+			return nil, err
+		}
+		if settings != nil && settings.HideRankings {
+			continue
+		}
+
 		// Get friend's user info
-		friendUser, err := s.userRepo.GetByID(friendUserID)
+		friendUser, err := s.userRepo.GetByID(ctx, friendUserID)
 		if err != nil {
 			continue
 		}
 
 		// Get friend's player info
-		friendPlayer, err := s.playerRepo.GetByUserID(friendUserID)
+		friendPlayer, err := s.playerRepo.GetByUserID(ctx, friendUserID)
 		if err != nil {
 			continue
 		}
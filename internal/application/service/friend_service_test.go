@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"GameServer/internal/domain/entity"
+)
+
+func TestGetFriendRanking(t *testing.T) {
+	ctx := context.Background()
+	var users []*entity.User
+	var players []*entity.PlayerInfo
+	for id := 1; id <= 4; id++ {
+		users = append(users, &entity.User{ID: id, Username: "player"})
+		players = append(players, &entity.PlayerInfo{UserID: id, Level: id})
+	}
+
+	tests := []struct {
+		name     string
+		settings map[int]*entity.PrivacySettings
+		want     []int
+	}{
+		{name: "every friend listed", want: []int{2, 3, 4}},
+		{
+			name: "friend hiding rankings left out",
+			settings: map[int]*entity.PrivacySettings{
+				3: {UserID: 3, HideRankings: true},
+				4: {UserID: 4, HideEquipment: true, FriendsOnly: true},
+			},
+			want: []int{2, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			friends := &fakeFriendRepo{friends: [][2]int{{1, 2}, {3, 1}, {1, 4}}}
+			friendService := NewFriendService(friends, newFakeUserRepo(users...), newFakePlayerRepo(players...),
+				&fakePrivacyRepo{settings: tt.settings})
+
+			ranking, err := friendService.GetFriendRanking(ctx, 1)
+			if err != nil {
+				t.Fatalf("GetFriendRanking: %v", err)
+			}
+			var got []int
+			for _, friend := range ranking {
+				got = append(got, friend.UserID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("friends listed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// maxItemsPerUse bounds how many items a single use request may consume
+const maxItemsPerUse = 99
+
+// InventoryService handles stackable items such as potions, tickets and
+// materials. Item definitions are static and loaded at startup.
+type InventoryService struct {
+	inventoryRepo repository.InventoryRepository
+	playerService *PlayerService
+	items         map[int]*entity.ItemDefinition
+}
+
+// NewInventoryService creates a new inventory service for the given item definitions
+func NewInventoryService(inventoryRepo repository.InventoryRepository, playerService *PlayerService, items []*entity.ItemDefinition) *InventoryService {
+	byID := make(map[int]*entity.ItemDefinition, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	return &InventoryService{
+		inventoryRepo: inventoryRepo,
+		playerService: playerService,
+		items:         byID,
+	}
+}
+
+// Item returns the definition of an item
+func (s *InventoryService) Item(itemID int) (*entity.ItemDefinition, bool) {
+	item, ok := s.items[itemID]
+	return item, ok
+}
+
+// ListItems returns the stacks the user holds
+func (s *InventoryService) ListItems(ctx context.Context, userID int) ([]*dto.InventoryItemResponse, error) {
+	stacks, err := s.inventoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*dto.InventoryItemResponse, 0, len(stacks))
+	for _, stack := range stacks {
+		item, ok := s.items[stack.ItemID]
+		if !ok {
+			// The item was removed from the definitions; keep the stack but hide it
+			continue
+		}
+		response = append(response, &dto.InventoryItemResponse{
+			ItemID:   item.ID,
+			Name:     item.Name,
+			Type:     item.Type,
+			Count:    stack.Count,
+			MaxStack: item.MaxStack,
+			Usable:   item.Effect != nil,
+		})
+	}
+	return response, nil
+}
+
+// GrantItems adds items to the user's inventory, mapping item IDs to
+// counts. Either every item is added or, when a stack would overflow,
+// none is.
+func (s *InventoryService) GrantItems(ctx context.Context, userID int, counts map[int]int) error {
+	grants := make([]*entity.ItemGrant, 0, len(counts))
+	for itemID, count := range counts {
+		item, ok := s.items[itemID]
+		if !ok {
+			return entity.NewDomainError(fmt.Sprintf("unknown item %d", itemID))
+		}
+		if count <= 0 {
+			return entity.NewDomainError("item count must be positive")
+		}
+		grants = append(grants, &entity.ItemGrant{ItemID: itemID, Count: count, MaxStack: item.MaxStack})
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	return s.inventoryRepo.AddItems(ctx, userID, grants)
+}
+
+// UseItem consumes items from a stack and applies their effect. If the
+// effect cannot be applied, for example because blood energy is already
+// full, the items are given back.
+func (s *InventoryService) UseItem(ctx context.Context, req *dto.UseItemRequest) (*dto.UseItemResponse, error) {
+	item, ok := s.items[req.ItemID]
+	if !ok {
+		return nil, entity.NewDomainError("unknown item")
+	}
+	if item.Effect == nil {
+		return nil, entity.NewDomainError("item cannot be used")
+	}
+	count := req.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxItemsPerUse {
+		return nil, entity.NewDomainError(fmt.Sprintf("item count must be between 1 and %d", maxItemsPerUse))
+	}
+
+	consumed, err := s.inventoryRepo.ConsumeItem(ctx, req.UserID, item.ID, count)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, entity.NewDomainError("not enough items")
+	}
+
+	response := &dto.UseItemResponse{
+		ItemID: item.ID,
+		Count:  count,
+		Effect: item.Effect.Type,
+		Amount: item.Effect.Amount * count,
+	}
+
+	switch item.Effect.Type {
+	case entity.ItemEffectRestoreEnergy:
+		response.Energy, err = s.playerService.RestoreEnergy(ctx, req.UserID, response.Amount)
+	case entity.ItemEffectGrantExperience:
+		// Not counted against the per-minute cap: the experience is bounded
+		// by the items the player holds, so using many at once is legitimate
+		response.Progress, err = s.playerService.awardExperience(ctx, req.UserID, response.Amount)
+	}
+	if err != nil {
+		s.refund(ctx, req.UserID, item.ID, count)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// refund gives back items whose effect failed. The stack limit is not
+// applied because the items were held a moment ago, and the refund runs
+// even if the request's deadline has passed.
+func (s *InventoryService) refund(ctx context.Context, userID, itemID, count int) {
+	grant := []*entity.ItemGrant{{ItemID: itemID, Count: count}}
+	if err := s.inventoryRepo.AddItems(context.WithoutCancel(ctx), userID, grant); err != nil {
+		log.Printf("Failed to refund %d of item %d to user %d: %v", count, itemID, userID, err)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// testItems are the item definitions the inventory tests run with
+func testItems() []*entity.ItemDefinition {
+	return []*entity.ItemDefinition{
+		{ID: 1001, Name: "Small Potion", Type: "potion", MaxStack: 10, Effect: &entity.ItemEffect{Type: entity.ItemEffectRestoreEnergy, Amount: 20}},
+		{ID: 1101, Name: "Experience Potion", Type: "potion", MaxStack: 10, Effect: &entity.ItemEffect{Type: entity.ItemEffectGrantExperience, Amount: 300}},
+		{ID: 2001, Name: "Stage Ticket", Type: "ticket", MaxStack: 5},
+	}
+}
+
+func TestGrantItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		counts     map[int]int
+		wantErr    bool
+		wantStacks map[int]int
+	}{
+		{name: "nothing to grant", wantStacks: map[int]int{1001: 0, 2001: 4}},
+		{name: "several items", counts: map[int]int{1001: 3, 2001: 1}, wantStacks: map[int]int{1001: 3, 2001: 5}},
+		{name: "fills a stack", counts: map[int]int{1001: 10}, wantStacks: map[int]int{1001: 10}},
+		{name: "overflowing stack grants nothing", counts: map[int]int{1001: 3, 2001: 2}, wantErr: true, wantStacks: map[int]int{1001: 0, 2001: 4}},
+		{name: "unknown item", counts: map[int]int{1001: 1, 9999: 1}, wantErr: true, wantStacks: map[int]int{1001: 0}},
+		{name: "zero count", counts: map[int]int{1001: 0}, wantErr: true, wantStacks: map[int]int{1001: 0}},
+		{name: "negative count", counts: map[int]int{2001: -4}, wantErr: true, wantStacks: map[int]int{2001: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := newFakeInventoryRepo()
+			inventory.stacks[2001] = 4
+			service := NewInventoryService(inventory, nil, testItems())
+
+			err := service.GrantItems(context.Background(), 7, tt.counts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GrantItems error = %v, want error %v", err, tt.wantErr)
+			}
+			for itemID, want := range tt.wantStacks {
+				if got := inventory.stacks[itemID]; got != want {
+					t.Errorf("item %d stack = %d, want %d", itemID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUseItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		energy     int
+		req        dto.UseItemRequest
+		wantErr    bool
+		wantAmount int
+		wantStack  int // Of the item used
+		wantEnergy int
+		wantLevel  int
+	}{
+		{name: "restore energy", energy: 40, req: dto.UseItemRequest{ItemID: 1001}, wantAmount: 20, wantStack: 4, wantEnergy: 60, wantLevel: 1},
+		{name: "several at once", energy: 40, req: dto.UseItemRequest{ItemID: 1001, Count: 2}, wantAmount: 40, wantStack: 3, wantEnergy: 80, wantLevel: 1},
+		{name: "restore capped at the maximum", energy: 90, req: dto.UseItemRequest{ItemID: 1001}, wantAmount: 20, wantStack: 4, wantEnergy: 100, wantLevel: 1},
+		{name: "energy already full refunds", energy: 100, req: dto.UseItemRequest{ItemID: 1001}, wantErr: true, wantStack: 5, wantEnergy: 100, wantLevel: 1},
+		{name: "not enough items", energy: 40, req: dto.UseItemRequest{ItemID: 1001, Count: 6}, wantErr: true, wantStack: 5, wantEnergy: 40, wantLevel: 1},
+		{name: "count out of range", energy: 40, req: dto.UseItemRequest{ItemID: 1001, Count: maxItemsPerUse + 1}, wantErr: true, wantStack: 5, wantEnergy: 40, wantLevel: 1},
+		{name: "negative count", energy: 40, req: dto.UseItemRequest{ItemID: 1001, Count: -1}, wantErr: true, wantStack: 5, wantEnergy: 40, wantLevel: 1},
+		{name: "item without an effect", energy: 40, req: dto.UseItemRequest{ItemID: 2001}, wantErr: true, wantStack: 5, wantEnergy: 40, wantLevel: 1},
+		{name: "unknown item", energy: 40, req: dto.UseItemRequest{ItemID: 9999}, wantErr: true, wantEnergy: 40, wantLevel: 1},
+		{name: "grant experience", energy: 40, req: dto.UseItemRequest{ItemID: 1101}, wantAmount: 300, wantStack: 4, wantEnergy: 40, wantLevel: 3},
+		{name: "experience beyond the per-minute cap", energy: 40, req: dto.UseItemRequest{ItemID: 1101, Count: 4}, wantAmount: 1200, wantStack: 1, wantEnergy: 40, wantLevel: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			players := newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, Level: 1, BloodEnergy: tt.energy})
+			node := newPlayerNode(players, newFakeXPRateRepo(), testRules())
+			inventory := newFakeInventoryRepo()
+			for _, item := range testItems() {
+				inventory.stacks[item.ID] = 5
+			}
+			service := NewInventoryService(inventory, node.PlayerService, testItems())
+
+			req := tt.req
+			req.UserID = 7
+			response, err := service.UseItem(ctx, &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UseItem error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && response.Amount != tt.wantAmount {
+				t.Errorf("effect amount = %d, want %d", response.Amount, tt.wantAmount)
+			}
+			if got := inventory.stacks[tt.req.ItemID]; got != tt.wantStack {
+				t.Errorf("stack = %d, want %d", got, tt.wantStack)
+			}
+			player := players.players[7]
+			if player.BloodEnergy != tt.wantEnergy || player.Level != tt.wantLevel {
+				t.Errorf("energy %d at level %d, want %d at level %d",
+					player.BloodEnergy, player.Level, tt.wantEnergy, tt.wantLevel)
+			}
+			if rules := node.suspicion.rules(); len(rules) != 0 {
+				t.Errorf("item use logged violations %v", rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+	"GameServer/internal/domain/service"
+	"GameServer/internal/domain/valueobject"
+)
+
+// Mail limits
+const (
+	maxMailTitleLength    = 64
+	maxMailBodyLength     = 2000
+	maxMailEquipment      = 10
+	mailboxListLimit      = 100
+	defaultMailSenderName = "system"
+)
+
+// Ledger reason code for mail attachments
+const mailReason = "mail"
+
+// MailService handles mailboxes. Players send text mail to their friends;
+// system mail is sent in bulk through the admin API and may carry
+// attachments that are claimed once.
+type MailService struct {
+	mailRepo      repository.MailRepository
+	userRepo      repository.UserRepository
+	friendRepo    repository.FriendRepository
+	playerService *PlayerService
+	rewards       *rewardGranter
+	ttl           time.Duration
+	notifier      service.UserNotifier
+}
+
+// NewMailService creates a new mail service; mail expires after ttl unless
+// sent with an explicit expiry
+func NewMailService(
+	mailRepo repository.MailRepository,
+	userRepo repository.UserRepository,
+	friendRepo repository.FriendRepository,
+	playerService *PlayerService,
+	walletService *WalletService,
+	inventoryService *InventoryService,
+	ttl time.Duration,
+) *MailService {
+	return &MailService{
+		mailRepo:      mailRepo,
+		userRepo:      userRepo,
+		friendRepo:    friendRepo,
+		playerService: playerService,
+		rewards:       &rewardGranter{walletService: walletService, inventoryService: inventoryService},
+		ttl:           ttl,
+	}
+}
+
+// SetNotifier sets where new player mail is pushed to its recipient
+func (s *MailService) SetNotifier(notifier service.UserNotifier) {
+	s.notifier = notifier
+}
+
+// ListMail returns the user's unexpired mail, newest first, without bodies
+func (s *MailService) ListMail(ctx context.Context, userID int) ([]*dto.MailResponse, error) {
+	mails, err := s.mailRepo.GetByUserID(ctx, userID, mailboxListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*dto.MailResponse, 0, len(mails))
+	for _, mail := range mails {
+		item := mailResponse(mail)
+		item.Body = ""
+		response = append(response, item)
+	}
+	return response, nil
+}
+
+// ReadMail returns a mail with its body and marks it read
+func (s *MailService) ReadMail(ctx context.Context, req *dto.MailActionRequest) (*dto.MailResponse, error) {
+	mail, err := s.getMail(ctx, req.UserID, req.MailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mail.Read {
+		if err := s.mailRepo.MarkRead(ctx, req.UserID, mail.ID); err != nil {
+			return nil, err
+		}
+		mail.Read = true
+	}
+	return mailResponse(mail), nil
+}
+
+// ClaimMail grants a mail's attachments. The claim is recorded first so it
+// can succeed only once; if the attachments cannot be granted, for example
+// because an item stack is full, everything granted is taken back and the
+// mail can be claimed again.
+func (s *MailService) ClaimMail(ctx context.Context, req *dto.MailActionRequest) (*dto.MailClaimResponse, error) {
+	mail, err := s.getMail(ctx, req.UserID, req.MailID)
+	if err != nil {
+		return nil, err
+	}
+	if mail.Attachments.IsEmpty() {
+		return nil, entity.NewDomainError("mail has no attachments")
+	}
+
+	claimed, err := s.mailRepo.MarkClaimed(ctx, req.UserID, mail.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, entity.NewDomainError("mail attachments already claimed")
+	}
+
+	equipment, err := s.playerService.grantEquipment(ctx, req.UserID, mail.Attachments.Equipment)
+	if err != nil {
+		s.releaseClaim(ctx, req.UserID, mail.ID)
+		return nil, err
+	}
+
+	reward := mail.Attachments.Reward()
+	referenceID := fmt.Sprintf("mail:%d", mail.ID)
+	if err := s.rewards.grant(ctx, req.UserID, reward, mailReason, referenceID, nil); err != nil {
+		s.playerService.removeEquipment(ctx, req.UserID, equipment)
+		s.releaseClaim(ctx, req.UserID, mail.ID)
+		return nil, err
+	}
+
+	return &dto.MailClaimResponse{
+		MailID:    mail.ID,
+		Reward:    rewardResponse(reward),
+		Equipment: s.playerService.convertEquipmentToDTO(equipment),
+	}, nil
+}
+
+// DeleteMail removes a mail. Mail with unclaimed attachments can only be
+// deleted once it has expired.
+func (s *MailService) DeleteMail(ctx context.Context, req *dto.MailActionRequest) error {
+	deleted, err := s.mailRepo.Delete(ctx, req.UserID, req.MailID)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return nil
+	}
+
+	mail, err := s.mailRepo.GetByID(ctx, req.UserID, req.MailID)
+	if err != nil {
+		return err
+	}
+	if mail == nil {
+		return entity.NewDomainError("mail not found")
+	}
+	return entity.NewDomainError("claim the attachments before deleting the mail")
+}
+
+// SendMail sends a text mail from one player to a friend
+func (s *MailService) SendMail(ctx context.Context, req *dto.SendMailRequest) (*dto.SendMailResponse, error) {
+	if req.ToUserID == req.UserID {
+		return nil, entity.NewDomainError("cannot send mail to yourself")
+	}
+	if err := validateMailText(req.Title, req.Body); err != nil {
+		return nil, err
+	}
+
+	sender, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if sender == nil {
+		return nil, entity.NewDomainError("sender user not found")
+	}
+	areFriends, err := s.friendRepo.AreFriends(ctx, req.UserID, req.ToUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !areFriends {
+		return nil, entity.NewDomainError("mail can only be sent to friends")
+	}
+
+	mail := &entity.Mail{
+		UserID:     req.ToUserID,
+		SenderID:   req.UserID,
+		SenderName: sender.Username,
+		Kind:       entity.MailKindPlayer,
+		Title:      req.Title,
+		Body:       req.Body,
+		ExpiresAt:  time.Now().Add(s.ttl),
+	}
+	if err := s.mailRepo.Create(ctx, mail); err != nil {
+		return nil, err
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyUser(req.ToUserID, valueobject.EventMailReceived, map[string]interface{}{
+			"mailId":     mail.ID,
+			"senderName": mail.SenderName,
+			"title":      mail.Title,
+		})
+	}
+
+	return &dto.SendMailResponse{MailID: mail.ID}, nil
+}
+
+// SendBulk sends a system mail to every selected user and returns how many
+// received it
+func (s *MailService) SendBulk(ctx context.Context, req *dto.BulkMailRequest) (*dto.BulkMailResponse, error) {
+	if err := validateMailText(req.Title, req.Body); err != nil {
+		return nil, err
+	}
+	if req.MinLevel < 0 || req.MaxLevel < 0 || (req.MaxLevel > 0 && req.MinLevel > req.MaxLevel) {
+		return nil, entity.NewDomainError("invalid level range")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	if req.ExpiresAt != 0 {
+		expiresAt = time.Unix(req.ExpiresAt, 0)
+		if !expiresAt.After(now) {
+			return nil, entity.NewDomainError("expiry must be in the future")
+		}
+	}
+
+	senderName := req.SenderName
+	if senderName == "" {
+		senderName = defaultMailSenderName
+	}
+	if utf8.RuneCountInString(senderName) > maxMailTitleLength {
+		return nil, entity.NewDomainError("sender name is too long")
+	}
+
+	attachments, err := s.attachmentsFromData(req.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	mail := &entity.Mail{
+		SenderName:  senderName,
+		Kind:        entity.MailKindSystem,
+		Title:       req.Title,
+		Body:        req.Body,
+		Attachments: attachments,
+		ExpiresAt:   expiresAt,
+	}
+	recipients := &entity.MailRecipients{
+		UserIDs:  req.UserIDs,
+		MinLevel: req.MinLevel,
+		MaxLevel: req.MaxLevel,
+	}
+	sent, err := s.mailRepo.CreateBulk(ctx, mail, recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Sent system mail %q to %d users", req.Title, sent)
+	return &dto.BulkMailResponse{Sent: sent}, nil
+}
+
+// getMail returns one of the user's unexpired mails
+func (s *MailService) getMail(ctx context.Context, userID, mailID int) (*entity.Mail, error) {
+	mail, err := s.mailRepo.GetByID(ctx, userID, mailID)
+	if err != nil {
+		return nil, err
+	}
+	if mail == nil || !mail.ExpiresAt.After(time.Now()) {
+		return nil, entity.NewDomainError("mail not found")
+	}
+	return mail, nil
+}
+
+// releaseClaim makes a mail claimable again after its grant was undone. It
+// runs even if the request's deadline has passed.
+func (s *MailService) releaseClaim(ctx context.Context, userID, mailID int) {
+	if err := s.mailRepo.UnmarkClaimed(context.WithoutCancel(ctx), userID, mailID); err != nil {
+		log.Printf("Failed to release claim of mail %d for user %d: %v", mailID, userID, err)
+	}
+}
+
+// attachmentsFromData validates attachments sent through the admin API
+func (s *MailService) attachmentsFromData(data *dto.MailAttachmentsData) (*entity.MailAttachments, error) {
+	if data == nil {
+		return nil, nil
+	}
+	for currency, amount := range data.Currencies {
+		if !s.rewards.walletService.IsCurrency(currency) {
+			return nil, entity.NewDomainError(fmt.Sprintf("unknown currency %s", currency))
+		}
+		if amount <= 0 {
+			return nil, entity.NewDomainError("attachment amounts must be positive")
+		}
+	}
+	for itemID, count := range data.Items {
+		if _, ok := s.rewards.inventoryService.Item(itemID); !ok {
+			return nil, entity.NewDomainError(fmt.Sprintf("unknown item %d", itemID))
+		}
+		if count <= 0 {
+			return nil, entity.NewDomainError("attachment amounts must be positive")
+		}
+	}
+	if len(data.Equipment) > maxMailEquipment {
+		return nil, entity.NewDomainError(fmt.Sprintf("a mail may carry at most %d pieces of equipment", maxMailEquipment))
+	}
+
+	attachments := &entity.MailAttachments{
+		Currencies: data.Currencies,
+		Items:      data.Items,
+	}
+	for _, equip := range data.Equipment {
+		if equip == nil || equip.Type <= 0 || equip.Quality <= 0 {
+			return nil, entity.NewDomainError("equipment type and quality must be positive integers")
+		}
+		attachments.Equipment = append(attachments.Equipment, &entity.Equipment{
+			Quality:       equip.Quality,
+			Damage:        equip.Damage,
+			Crit:          equip.Crit,
+			CritDamage:    equip.CritDamage,
+			DamageSpeed:   equip.DamageSpeed,
+			BloodSuck:     equip.BloodSuck,
+			HP:            equip.HP,
+			MoveSpeed:     equip.MoveSpeed,
+			SuitID:        equip.SuitID,
+			SuitName:      equip.SuitName,
+			EquipTypeID:   equip.EquipTypeID,
+			EquipTypeName: equip.EquipTypeName,
+			Defense:       equip.Defense,
+			GoodFortune:   equip.GoodFortune,
+			Type:          equip.Type,
+		})
+	}
+	if attachments.IsEmpty() {
+		return nil, nil
+	}
+	return attachments, nil
+}
+
+// validateMailText checks a mail's title and body
+func validateMailText(title, body string) error {
+	if title == "" {
+		return entity.NewDomainError("mail title is required")
+	}
+	if utf8.RuneCountInString(title) > maxMailTitleLength {
+		return entity.NewDomainError(fmt.Sprintf("mail title must be at most %d characters", maxMailTitleLength))
+	}
+	if utf8.RuneCountInString(body) > maxMailBodyLength {
+		return entity.NewDomainError(fmt.Sprintf("mail body must be at most %d characters", maxMailBodyLength))
+	}
+	return nil
+}
+
+// mailResponse describes a mail
+func mailResponse(mail *entity.Mail) *dto.MailResponse {
+	response := &dto.MailResponse{
+		MailID:     mail.ID,
+		Kind:       mail.Kind,
+		SenderID:   mail.SenderID,
+		SenderName: mail.SenderName,
+		Title:      mail.Title,
+		Body:       mail.Body,
+		Read:       mail.Read,
+		Claimed:    mail.Claimed,
+		ExpiresAt:  mail.ExpiresAt.Unix(),
+		CreatedAt:  mail.CreatedAt.Unix(),
+	}
+	if !mail.Attachments.IsEmpty() {
+		response.Attachments = &dto.MailAttachmentsData{
+			Currencies: mail.Attachments.Currencies,
+			Items:      mail.Attachments.Items,
+		}
+		for _, equip := range mail.Attachments.Equipment {
+			response.Attachments.Equipment = append(response.Attachments.Equipment, &dto.EquipmentData{
+				Quality:       equip.Quality,
+				Damage:        equip.Damage,
+				Crit:          equip.Crit,
+				CritDamage:    equip.CritDamage,
+				DamageSpeed:   equip.DamageSpeed,
+				BloodSuck:     equip.BloodSuck,
+				HP:            equip.HP,
+				MoveSpeed:     equip.MoveSpeed,
+				SuitID:        equip.SuitID,
+				SuitName:      equip.SuitName,
+				EquipTypeID:   equip.EquipTypeID,
+				EquipTypeName: equip.EquipTypeName,
+				Defense:       equip.Defense,
+				GoodFortune:   equip.GoodFortune,
+				Type:          equip.Type,
+			})
+		}
+	}
+	return response
+}
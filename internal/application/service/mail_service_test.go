@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// newTestMailService returns a mail service over in-memory repositories
+func newTestMailService() (*MailService, *fakeMailRepo, *fakeWalletRepo, *fakeInventoryRepo) {
+	mails := newFakeMailRepo()
+	wallet := newFakeWalletRepo()
+	inventory := newFakeInventoryRepo()
+	players := newPlayerNode(newFakePlayerRepo(), newFakeXPRateRepo(), testRules())
+	service := NewMailService(mails, nil, nil, players.PlayerService,
+		NewWalletService(wallet, []string{"gold", "gems"}),
+		NewInventoryService(inventory, players.PlayerService, testItems()), 24*time.Hour)
+	return service, mails, wallet, inventory
+}
+
+func TestSendBulkMail(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		req         dto.BulkMailRequest
+		wantErr     bool
+		wantSender  string
+		wantExpires time.Time // Zero for the default expiry
+	}{
+		{name: "text only", req: dto.BulkMailRequest{Title: "Maintenance", UserIDs: []int{1, 2}}, wantSender: "system"},
+		{
+			name: "with attachments and expiry",
+			req: dto.BulkMailRequest{
+				Title:       "Compensation",
+				SenderName:  "GM",
+				ExpiresAt:   now.Add(time.Hour).Unix(),
+				Attachments: &dto.MailAttachmentsData{Currencies: map[string]int{"gold": 500}, Items: map[int]int{1001: 2}},
+			},
+			wantSender:  "GM",
+			wantExpires: time.Unix(now.Add(time.Hour).Unix(), 0),
+		},
+		{name: "missing title", req: dto.BulkMailRequest{Body: "Hello"}, wantErr: true},
+		{name: "title too long", req: dto.BulkMailRequest{Title: strings.Repeat("標", maxMailTitleLength+1)}, wantErr: true},
+		{name: "body too long", req: dto.BulkMailRequest{Title: "News", Body: strings.Repeat("a", maxMailBodyLength+1)}, wantErr: true},
+		{name: "sender name too long", req: dto.BulkMailRequest{Title: "News", SenderName: strings.Repeat("a", maxMailTitleLength+1)}, wantErr: true},
+		{name: "inverted level range", req: dto.BulkMailRequest{Title: "News", MinLevel: 10, MaxLevel: 5}, wantErr: true},
+		{name: "negative level", req: dto.BulkMailRequest{Title: "News", MinLevel: -1}, wantErr: true},
+		{name: "expiry in the past", req: dto.BulkMailRequest{Title: "News", ExpiresAt: now.Add(-time.Minute).Unix()}, wantErr: true},
+		{
+			name:    "unknown currency",
+			req:     dto.BulkMailRequest{Title: "Gift", Attachments: &dto.MailAttachmentsData{Currencies: map[string]int{"tokens": 5}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown item",
+			req:     dto.BulkMailRequest{Title: "Gift", Attachments: &dto.MailAttachmentsData{Items: map[int]int{9999: 1}}},
+			wantErr: true,
+		},
+		{
+			name:    "non-positive amount",
+			req:     dto.BulkMailRequest{Title: "Gift", Attachments: &dto.MailAttachmentsData{Currencies: map[string]int{"gold": 0}}},
+			wantErr: true,
+		},
+		{
+			name:    "equipment without a type",
+			req:     dto.BulkMailRequest{Title: "Gift", Attachments: &dto.MailAttachmentsData{Equipment: []*dto.EquipmentData{{Quality: 3}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mails, _, _ := newTestMailService()
+
+			req := tt.req
+			_, err := service.SendBulk(context.Background(), &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendBulk error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(mails.bulk) != 0 {
+					t.Error("rejected mail was stored")
+				}
+				return
+			}
+
+			mail := mails.bulk[0]
+			if mail.Kind != entity.MailKindSystem || mail.SenderName != tt.wantSender {
+				t.Errorf("stored %s mail from %q, want system mail from %q", mail.Kind, mail.SenderName, tt.wantSender)
+			}
+			wantExpires := tt.wantExpires
+			if wantExpires.IsZero() {
+				wantExpires = now.Add(24 * time.Hour)
+			}
+			if diff := mail.ExpiresAt.Sub(wantExpires); diff < -time.Minute || diff > time.Minute {
+				t.Errorf("expires at %s, want %s", mail.ExpiresAt, wantExpires)
+			}
+		})
+	}
+}
+
+func TestClaimMail(t *testing.T) {
+	attachments := &entity.MailAttachments{Currencies: map[string]int{"gold": 500}, Items: map[int]int{2001: 2}}
+
+	tests := []struct {
+		name        string
+		mail        *entity.Mail // Stored as mail 1; nil if there is none
+		stack       int          // Tickets already held
+		wantErr     bool
+		wantClaimed bool
+		wantGold    int
+		wantStack   int
+	}{
+		{
+			name:        "claims attachments",
+			mail:        &entity.Mail{ID: 1, Attachments: attachments, ExpiresAt: time.Now().Add(time.Hour)},
+			wantClaimed: true,
+			wantGold:    500,
+			wantStack:   2,
+		},
+		{
+			name:        "already claimed",
+			mail:        &entity.Mail{ID: 1, Attachments: attachments, Claimed: true, ExpiresAt: time.Now().Add(time.Hour)},
+			wantErr:     true,
+			wantClaimed: true,
+		},
+		{
+			name:    "expired",
+			mail:    &entity.Mail{ID: 1, Attachments: attachments, ExpiresAt: time.Now().Add(-time.Minute)},
+			wantErr: true,
+		},
+		{
+			name:    "no attachments",
+			mail:    &entity.Mail{ID: 1, ExpiresAt: time.Now().Add(time.Hour)},
+			wantErr: true,
+		},
+		{name: "no such mail", wantErr: true},
+		{
+			name:      "full stack takes everything back",
+			mail:      &entity.Mail{ID: 1, Attachments: attachments, ExpiresAt: time.Now().Add(time.Hour)},
+			stack:     4,
+			wantErr:   true,
+			wantStack: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mails, wallet, inventory := newTestMailService()
+			if tt.mail != nil {
+				mails.mails[1] = tt.mail
+			}
+			inventory.stacks[2001] = tt.stack
+
+			response, err := service.ClaimMail(context.Background(), &dto.MailActionRequest{UserID: 7, MailID: 1})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClaimMail error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && response.Reward.Currencies["gold"] != 500 {
+				t.Errorf("claimed reward %v, want 500 gold", response.Reward.Currencies)
+			}
+			if tt.mail != nil && mails.mails[1].Claimed != tt.wantClaimed {
+				t.Errorf("claimed = %v, want %v", mails.mails[1].Claimed, tt.wantClaimed)
+			}
+			if wallet.balances["gold"] != tt.wantGold || inventory.stacks[2001] != tt.wantStack {
+				t.Errorf("holds %d gold and %d tickets, want %d and %d",
+					wallet.balances["gold"], inventory.stacks[2001], tt.wantGold, tt.wantStack)
+			}
+		})
+	}
+}
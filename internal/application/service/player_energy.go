@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// ConsumeEnergy spends blood energy after adding what regenerated since the
+// last change
+func (s *PlayerService) ConsumeEnergy(ctx context.Context, req *dto.ConsumeEnergyRequest) (*dto.EnergyResponse, error) {
+	if req.Amount <= 0 {
+		return nil, entity.NewDomainError("energy amount must be positive")
+	}
+
+	return s.changeEnergy(ctx, req.UserID, func(player *entity.PlayerInfo, now time.Time) error {
+		if player.BloodEnergy < req.Amount {
+			return entity.NewDomainError("not enough blood energy")
+		}
+		player.BloodEnergy -= req.Amount
+		return nil
+	})
+}
+
+// BuyEnergy adds one purchase of blood energy, filling up to the cap. The
+// number of purchases per UTC day is limited.
+func (s *PlayerService) BuyEnergy(ctx context.Context, userID int) (*dto.EnergyResponse, error) {
+	return s.changeEnergy(ctx, userID, func(player *entity.PlayerInfo, now time.Time) error {
+		if player.BloodEnergy >= s.rules.MaxBloodEnergy {
+			return entity.NewDomainError("blood energy is full")
+		}
+		if s.purchasesLeft(player, now) <= 0 {
+			return entity.NewDomainError("daily energy purchase limit reached")
+		}
+
+		if day := utcDay(now); player.EnergyPurchaseDay != day {
+			player.EnergyPurchaseDay = day
+			player.EnergyPurchases = 0
+		}
+		player.EnergyPurchases++
+		player.BloodEnergy = min(player.BloodEnergy+s.rules.EnergyPurchaseAmount, s.rules.MaxBloodEnergy)
+		return nil
+	})
+}
+
+// RestoreEnergy adds blood energy from an item or reward, filling up to the
+// cap. It fails when the energy is already full so the source is not wasted.
+func (s *PlayerService) RestoreEnergy(ctx context.Context, userID, amount int) (*dto.EnergyResponse, error) {
+	if amount <= 0 {
+		return nil, entity.NewDomainError("energy amount must be positive")
+	}
+
+	return s.changeEnergy(ctx, userID, func(player *entity.PlayerInfo, now time.Time) error {
+		if player.BloodEnergy >= s.rules.MaxBloodEnergy {
+			return entity.NewDomainError("blood energy is full")
+		}
+		player.BloodEnergy = min(player.BloodEnergy+amount, s.rules.MaxBloodEnergy)
+		return nil
+	})
+}
+
+// changeEnergy regenerates the player's blood energy, applies change and
+// stores the result, retrying when another request changed the energy
+// between reading and writing it
+func (s *PlayerService) changeEnergy(ctx context.Context, userID int, change func(player *entity.PlayerInfo, now time.Time) error) (*dto.EnergyResponse, error) {
+	for attempt := 0; attempt < maxProgressRetries; attempt++ {
+		player, err := s.playerRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if player == nil {
+			return nil, entity.NewDomainError("player info not found")
+		}
+
+		oldEnergy, oldUpdatedAt := player.BloodEnergy, player.EnergyUpdatedAt
+		now := time.Now()
+		player.RegenerateEnergy(now, s.rules.MaxBloodEnergy, s.rules.EnergyRegenPerMinute)
+		if err := change(player, now); err != nil {
+			return nil, err
+		}
+
+		updated, err := s.playerRepo.UpdateEnergy(ctx, player, oldEnergy, oldUpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			continue
+		}
+
+		s.cacheService.Delete(fmt.Sprintf("player_info:%d", userID))
+		return s.energyResponse(player, now), nil
+	}
+
+	return nil, entity.NewDomainError("blood energy changed concurrently, please retry")
+}
+
+// energyResponse describes a player's regenerated blood energy
+func (s *PlayerService) energyResponse(player *entity.PlayerInfo, now time.Time) *dto.EnergyResponse {
+	response := &dto.EnergyResponse{
+		BloodEnergy:    player.BloodEnergy,
+		MaxBloodEnergy: s.rules.MaxBloodEnergy,
+		PurchasesLeft:  s.purchasesLeft(player, now),
+	}
+	if player.BloodEnergy < s.rules.MaxBloodEnergy && s.rules.EnergyRegenPerMinute > 0 {
+		response.NextRegenIn = int(player.EnergyUpdatedAt + 60 - now.Unix())
+	}
+	return response
+}
+
+// purchasesLeft returns how many energy purchases the player has left today
+func (s *PlayerService) purchasesLeft(player *entity.PlayerInfo, now time.Time) int {
+	if player.EnergyPurchaseDay != utcDay(now) {
+		return s.rules.EnergyPurchasesPerDay
+	}
+	return max(s.rules.EnergyPurchasesPerDay-player.EnergyPurchases, 0)
+}
+
+// utcDay numbers UTC days since the Unix epoch
+func utcDay(now time.Time) int {
+	return int(now.Unix() / 86400)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// PlayerRules holds the limits PlayerService enforces on player progress
+type PlayerRules struct {
+	// ExperienceSources maps each allowed source to its per-grant limit
+	ExperienceSources map[string]int
+	// MaxExperiencePerMinute caps experience gained per minute across sources
+	MaxExperiencePerMinute int
+	// MaxBloodEnergy is the most blood energy a player may hold; below it
+	// energy regenerates by EnergyRegenPerMinute
+	MaxBloodEnergy       int
+	EnergyRegenPerMinute int
+	// EnergyPurchaseAmount is added by each of the EnergyPurchasesPerDay
+	// purchases a player may make per UTC day
+	EnergyPurchaseAmount  int
+	EnergyPurchasesPerDay int
+	// StageCount stages make up the stage map; clears award
+	// StageFirstClearExperience the first time and StageRepeatExperience
+	// after that, and clears faster than StageMinClearTime are rejected
+	StageCount                int
+	StageFirstClearExperience int
+	StageRepeatExperience     int
+	StageMinClearTime         time.Duration
+	// SuspicionThreshold violations within SuspicionWindow flag the account
+	SuspicionThreshold int
+	SuspicionWindow    time.Duration
+}
+
+// Anti-cheat rule names, as recorded in the suspicion log
+const (
+	RuleLevelWrite       = "level_write"
+	RuleExperienceWrite  = "experience_write"
+	RuleGameLevelWrite   = "game_level_write"
+	RuleBloodEnergyWrite = "blood_energy_write"
+	RuleExperienceRate   = "experience_rate"
+	RuleStageLocked      = "stage_locked"
+	RuleStageClearTime   = "stage_clear_time"
+)
+
+// protectedField is a player field only the server may change. A client
+// setting it through UpdatePlayer is rejected and logged.
+type protectedField struct {
+	rule    string
+	name    string
+	value   func(req *dto.UpdatePlayerRequest) *int
+	current func(player *entity.PlayerInfo) int
+	message string // Returned to the client
+}
+
+// protectedFields are checked on every UpdatePlayer request, in order
+var protectedFields = []protectedField{
+	{
+		rule:    RuleLevelWrite,
+		name:    "level",
+		value:   func(req *dto.UpdatePlayerRequest) *int { return req.Level },
+		current: func(player *entity.PlayerInfo) int { return player.Level },
+		message: "level and experience can only change through gainExperience",
+	},
+	{
+		rule:    RuleExperienceWrite,
+		name:    "experience",
+		value:   func(req *dto.UpdatePlayerRequest) *int { return req.Experience },
+		current: func(player *entity.PlayerInfo) int { return player.Experience },
+		message: "level and experience can only change through gainExperience",
+	},
+	{
+		rule:    RuleGameLevelWrite,
+		name:    "game level",
+		value:   func(req *dto.UpdatePlayerRequest) *int { return req.GameLevel },
+		current: func(player *entity.PlayerInfo) int { return player.GameLevel },
+		message: "game level can only change through completeStage",
+	},
+	{
+		rule:    RuleBloodEnergyWrite,
+		name:    "blood energy",
+		value:   func(req *dto.UpdatePlayerRequest) *int { return req.BloodEnergy },
+		current: func(player *entity.PlayerInfo) int { return player.BloodEnergy },
+		message: "blood energy can only change through consumeEnergy and buyEnergy",
+	},
+}
+
+// stageRule checks a reported stage clear against the player's state and
+// returns a description of the violation, or "" when the clear is allowed
+type stageRule struct {
+	name  string
+	check func(rules PlayerRules, current *entity.PlayerInfo, req *dto.CompleteStageRequest) string
+}
+
+// stageRules are applied to every CompleteStage request, in order
+var stageRules = []stageRule{
+	{
+		name: RuleStageLocked,
+		check: func(rules PlayerRules, current *entity.PlayerInfo, req *dto.CompleteStageRequest) string {
+			if req.Stage > current.GameLevel {
+				return fmt.Sprintf("stage %d cleared while stage %d is the highest unlocked", req.Stage, current.GameLevel)
+			}
+			return ""
+		},
+	},
+	{
+		name: RuleStageClearTime,
+		check: func(rules PlayerRules, current *entity.PlayerInfo, req *dto.CompleteStageRequest) string {
+			if minimum := int(rules.StageMinClearTime.Milliseconds()); req.ClearTime < minimum {
+				return fmt.Sprintf("stage %d cleared in %dms, minimum is %dms", req.Stage, req.ClearTime, minimum)
+			}
+			return ""
+		},
+	},
+}
+
+// checkStageClear runs the stage rules and reports the first violation
+func (s *PlayerService) checkStageClear(ctx context.Context, current *entity.PlayerInfo, req *dto.CompleteStageRequest) error {
+	for _, rule := range stageRules {
+		if detail := rule.check(s.rules, current, req); detail != "" {
+			s.reportViolation(ctx, req.UserID, rule.name, detail, req.Payload)
+			return entity.NewDomainError("stage clear rejected: " + detail)
+		}
+	}
+	return nil
+}
+
+// checkUpdate reports every protected field the request sets as a
+// violation and rejects the request with the first one's message
+func (s *PlayerService) checkUpdate(ctx context.Context, current *entity.PlayerInfo, req *dto.UpdatePlayerRequest) error {
+	var rejected error
+	for _, field := range protectedFields {
+		value := field.value(req)
+		if value == nil {
+			continue
+		}
+		detail := fmt.Sprintf("%s set from %d to %d by the client", field.name, field.current(current), *value)
+		s.reportViolation(ctx, req.UserID, field.rule, detail, req.Payload)
+		if rejected == nil {
+			rejected = entity.NewDomainError(field.message)
+		}
+	}
+	return rejected
+}
+
+// reserveExperience counts amount against the player's experience for the
+// current minute and reports a violation when the per-minute cap is exceeded.
+// The count is kept in the database so it is shared by every node; rejected
+// grants are not counted. It returns the minute the amount was counted in.
+func (s *PlayerService) reserveExperience(ctx context.Context, userID, amount int, payload []byte) (int64, error) {
+	minute := time.Now().Unix() / 60
+	reserved, err := s.xpRateRepo.Reserve(ctx, userID, minute, amount, s.rules.MaxExperiencePerMinute)
+	if err != nil {
+		return 0, err
+	}
+	if !reserved {
+		detail := fmt.Sprintf("%d more experience this minute exceeds %d", amount, s.rules.MaxExperiencePerMinute)
+		s.reportViolation(ctx, userID, RuleExperienceRate, detail, payload)
+		return 0, entity.NewDomainError("experience rate limit exceeded")
+	}
+	return minute, nil
+}
+
+// releaseExperience takes back experience reserved in minute but not
+// granted. Failures are logged: the count only runs high until the minute
+// is over.
+func (s *PlayerService) releaseExperience(ctx context.Context, userID int, minute int64, amount int) {
+	if err := s.xpRateRepo.Release(ctx, userID, minute, amount); err != nil {
+		log.Printf("Failed to release %d experience of user %d: %v", amount, userID, err)
+	}
+}
+
+// reportViolation records a violation in the suspicion log and flags the
+// account once it has broken the rules SuspicionThreshold times within
+// SuspicionWindow. Failures are logged; the request is rejected regardless.
+func (s *PlayerService) reportViolation(ctx context.Context, userID int, rule, detail string, payload []byte) {
+	suspicion := &entity.Suspicion{
+		UserID:  userID,
+		Rule:    rule,
+		Detail:  detail,
+		Payload: string(payload),
+	}
+	if err := s.suspicionRepo.Record(ctx, suspicion); err != nil {
+		log.Printf("Failed to record %s violation for user %d: %v", rule, userID, err)
+		return
+	}
+	log.Printf("Anti-cheat: user %d broke %s: %s", userID, rule, detail)
+
+	count, err := s.suspicionRepo.CountSince(ctx, userID, time.Now().Add(-s.rules.SuspicionWindow))
+	if err != nil {
+		log.Printf("Failed to count violations for user %d: %v", userID, err)
+		return
+	}
+	if count < s.rules.SuspicionThreshold {
+		return
+	}
+
+	reason := fmt.Sprintf("%d violations within %s, last: %s", count, s.rules.SuspicionWindow, rule)
+	flagged, err := s.suspicionRepo.FlagAccount(ctx, userID, reason)
+	if err != nil {
+		log.Printf("Failed to flag user %d: %v", userID, err)
+		return
+	}
+	if flagged {
+		log.Printf("Anti-cheat: flagged user %d for review (%s)", userID, reason)
+	}
+}
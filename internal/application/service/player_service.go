@@ -4,16 +4,43 @@ import (
 	"GameServer/internal/application/dto"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"GameServer/internal/domain/service"
+	"GameServer/internal/domain/valueobject"
 	"GameServer/internal/infrastructure/cache"
+	"context"
 	"fmt"
+	"log"
+	"sync"
+	"time"
 )
 
+// maxProgressRetries bounds how often an experience grant is retried when the
+// player's progress changes between reading and writing it
+const maxProgressRetries = 3
+
 // PlayerService handles player-related business logic
 type PlayerService struct {
 	playerRepo     repository.PlayerRepository
 	equipmentRepo  repository.EquipmentRepository
 	sourceStoneRepo repository.SourceStoneRepository
+	experienceRepo repository.ExperienceRepository
+	suspicionRepo  repository.SuspicionRepository
+	xpRateRepo     repository.ExperienceRateRepository
+	stageRepo      repository.StageRepository
 	cacheService   cache.CacheService
+	notifier       service.UserNotifier
+	events         service.GameEventRecorder
+
+	// Currencies and items paid for stage clears, see SetStageRewards
+	stageRewards *entity.StageRewards
+	rewards      *rewardGranter
+
+	// rules are the limits checked by the anti-cheat rule engine, see player_rules.go
+	rules PlayerRules
+
+	// The experience curve is loaded once and kept, see experienceCurve
+	curveMu sync.Mutex
+	curve   map[int]int
 }
 
 // NewPlayerService creates a new player service
@@ -21,21 +48,49 @@ func NewPlayerService(
 	playerRepo repository.PlayerRepository,
 	equipmentRepo repository.EquipmentRepository,
 	sourceStoneRepo repository.SourceStoneRepository,
+	experienceRepo repository.ExperienceRepository,
+	suspicionRepo repository.SuspicionRepository,
+	xpRateRepo repository.ExperienceRateRepository,
+	stageRepo repository.StageRepository,
 	cacheService cache.CacheService,
+	rules PlayerRules,
 ) *PlayerService {
 	return &PlayerService{
 		playerRepo:      playerRepo,
 		equipmentRepo:   equipmentRepo,
 		sourceStoneRepo: sourceStoneRepo,
+		experienceRepo:  experienceRepo,
+		suspicionRepo:   suspicionRepo,
+		xpRateRepo:      xpRateRepo,
+		stageRepo:       stageRepo,
 		cacheService:    cacheService,
+		rules:           rules,
 	}
 }
 
+// SetNotifier sets where player events such as level-ups are pushed
+func (s *PlayerService) SetNotifier(notifier service.UserNotifier) {
+	s.notifier = notifier
+}
+
+// SetEventRecorder sets where gameplay events such as stage clears are recorded
+func (s *PlayerService) SetEventRecorder(events service.GameEventRecorder) {
+	s.events = events
+}
+
+// SetStageRewards sets the currencies and items paid for stage clears, on
+// top of their experience, and the wallet and inventory they go into
+func (s *PlayerService) SetStageRewards(stageRewards *entity.StageRewards, walletService *WalletService, inventoryService *InventoryService) {
+	s.stageRewards = stageRewards
+	s.rewards = &rewardGranter{walletService: walletService, inventoryService: inventoryService}
+}
+
 // GetPlayerInfo retrieves player information
-func (s *PlayerService) GetPlayerInfo(userID int) (*dto.PlayerInfoResponse, error) {
+func (s *PlayerService) GetPlayerInfo(ctx context.Context, userID int) (*dto.PlayerInfoResponse, error) {
 	// Check cache first
 	cacheKey := fmt.Sprintf("player_info:%d", userID)
 	if cachedPlayer, err := s.cacheService.GetPlayerInfo(cacheKey); err == nil && cachedPlayer != nil {
+		cachedPlayer.RegenerateEnergy(time.Now(), s.rules.MaxBloodEnergy, s.rules.EnergyRegenPerMinute)
 		return &dto.PlayerInfoResponse{
 			UserID:      cachedPlayer.UserID,
 			Level:       cachedPlayer.Level,
@@ -46,7 +101,7 @@ func (s *PlayerService) GetPlayerInfo(userID int) (*dto.PlayerInfoResponse, erro
 	}
 
 	// Get from database
-	playerInfo, err := s.playerRepo.GetByUserID(userID)
+	playerInfo, err := s.playerRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -54,8 +109,9 @@ func (s *PlayerService) GetPlayerInfo(userID int) (*dto.PlayerInfoResponse, erro
 		return nil, entity.NewDomainError("player info not found")
 	}
 
-	// Cache the result
+	// Cache the stored state; regeneration is added on every read
 	s.cacheService.SetPlayerInfo(cacheKey, playerInfo)
+	playerInfo.RegenerateEnergy(time.Now(), s.rules.MaxBloodEnergy, s.rules.EnergyRegenPerMinute)
 
 	return &dto.PlayerInfoResponse{
 		UserID:      playerInfo.UserID,
@@ -66,10 +122,11 @@ func (s *PlayerService) GetPlayerInfo(userID int) (*dto.PlayerInfoResponse, erro
 	}, nil
 }
 
-// UpdatePlayer updates player information
-func (s *PlayerService) UpdatePlayer(req *dto.UpdatePlayerRequest) error {
+// UpdatePlayer rejects every request. It is kept so that old clients get
+// an answer; setting a protected field is logged as suspicious.
+func (s *PlayerService) UpdatePlayer(ctx context.Context, req *dto.UpdatePlayerRequest) error {
 	// Get current player info
-	playerInfo, err := s.playerRepo.GetByUserID(req.UserID)
+	playerInfo, err := s.playerRepo.GetByUserID(ctx, req.UserID)
 	if err != nil {
 		return err
 	}
@@ -77,34 +134,133 @@ func (s *PlayerService) UpdatePlayer(req *dto.UpdatePlayerRequest) error {
 		return entity.NewDomainError("player info not found")
 	}
 
-	// Update fields if provided
-	if req.Level != nil {
-		playerInfo.Level = *req.Level
+	// Every field is server-authoritative: level and experience change
+	// through GainExperience, the game level through CompleteStage and blood
+	// energy through the energy actions. A client writing one is cheating.
+	if err := s.checkUpdate(ctx, playerInfo, req); err != nil {
+		return err
 	}
-	if req.Experience != nil {
-		playerInfo.Experience = *req.Experience
+	return entity.NewDomainError("no player field can be updated")
+}
+
+// GainExperience awards experience from an allowed source. Grants beyond the
+// per-minute cap are rejected and logged as suspicious.
+func (s *PlayerService) GainExperience(ctx context.Context, req *dto.GainExperienceRequest) (*dto.GainExperienceResponse, error) {
+	limit, ok := s.rules.ExperienceSources[req.Source]
+	if !ok {
+		return nil, entity.NewDomainError("unknown experience source")
 	}
-	if req.GameLevel != nil {
-		playerInfo.GameLevel = *req.GameLevel
+	if req.Amount <= 0 {
+		return nil, entity.NewDomainError("experience amount must be positive")
 	}
-	if req.BloodEnergy != nil {
-		playerInfo.BloodEnergy = *req.BloodEnergy
+	if req.Amount > limit {
+		return nil, entity.NewDomainError("experience amount exceeds the limit for this source")
 	}
 
-	// Update in database
-	if err := s.playerRepo.Update(playerInfo); err != nil {
-		return err
+	return s.grantExperience(ctx, req.UserID, req.Amount, req.Payload)
+}
+
+// grantExperience counts amount against the per-minute cap and awards it.
+// Every source of experience goes through here; payload is the raw request
+// recorded if the cap is exceeded.
+func (s *PlayerService) grantExperience(ctx context.Context, userID, amount int, payload []byte) (*dto.GainExperienceResponse, error) {
+	if _, err := s.reserveExperience(ctx, userID, amount, payload); err != nil {
+		return nil, err
 	}
+	return s.awardExperience(ctx, userID, amount)
+}
 
-	// Invalidate cache
-	cacheKey := fmt.Sprintf("player_info:%d", req.UserID)
-	s.cacheService.Delete(cacheKey)
+// awardExperience adds experience that has already been validated and
+// levels the player up along the experience curve, pushing a level-up
+// event when at least one level was gained
+func (s *PlayerService) awardExperience(ctx context.Context, userID, amount int) (*dto.GainExperienceResponse, error) {
+	curve, err := s.experienceCurve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load experience curve: %w", err)
+	}
 
-	return nil
+	for attempt := 0; attempt < maxProgressRetries; attempt++ {
+		playerInfo, err := s.playerRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if playerInfo == nil {
+			return nil, entity.NewDomainError("player info not found")
+		}
+
+		previousLevel, previousExperience := playerInfo.Level, playerInfo.Experience
+		gained := playerInfo.GainExperience(amount, curve)
+
+		updated, err := s.playerRepo.UpdateProgress(ctx, userID,
+			previousLevel, previousExperience, playerInfo.Level, playerInfo.Experience)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
+			continue
+		}
+
+		s.cacheService.Delete(fmt.Sprintf("player_info:%d", userID))
+
+		nextLevelExp, hasNext := curve[playerInfo.Level]
+		response := &dto.GainExperienceResponse{
+			Level:        playerInfo.Level,
+			Experience:   playerInfo.Experience,
+			NextLevelExp: nextLevelExp,
+			LevelsGained: gained,
+			MaxLevel:     !hasNext,
+		}
+
+		if gained > 0 && s.notifier != nil {
+			s.notifier.NotifyUser(userID, valueobject.EventLevelUp, map[string]interface{}{
+				"previousLevel": previousLevel,
+				"level":         playerInfo.Level,
+				"experience":    playerInfo.Experience,
+				"nextLevelExp":  nextLevelExp,
+			})
+		}
+		if gained > 0 && s.events != nil {
+			s.events.RecordEvent(ctx, &entity.GameEvent{
+				UserID: userID,
+				Type:   entity.GameEventLevelUp,
+				Count:  gained,
+				Value:  playerInfo.Level,
+			})
+		}
+
+		return response, nil
+	}
+
+	return nil, entity.NewDomainError("player progress changed concurrently, please retry")
+}
+
+// experienceCurve returns the experience needed to advance from each level.
+// It is read from the experience table on first use and kept for the
+// lifetime of the service; a failed load is retried on the next call.
+func (s *PlayerService) experienceCurve(ctx context.Context) (map[int]int, error) {
+	s.curveMu.Lock()
+	defer s.curveMu.Unlock()
+
+	if s.curve != nil {
+		return s.curve, nil
+	}
+
+	levels, err := s.experienceRepo.GetAllLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	curve := make(map[int]int, len(levels))
+	for _, level := range levels {
+		if level.Value > 0 {
+			curve[level.Level] = level.Value
+		}
+	}
+	s.curve = curve
+	return curve, nil
 }
 
 // GetUserEquipment retrieves all equipment for a user
-func (s *PlayerService) GetUserEquipment(userID int) ([]*dto.EquipmentResponse, error) {
+func (s *PlayerService) GetUserEquipment(ctx context.Context, userID int) ([]*dto.EquipmentResponse, error) {
 	// Check cache first
 	cacheKey := fmt.Sprintf("equipment:%d", userID)
 	if cachedEquipment, err := s.cacheService.GetEquipment(cacheKey); err == nil && cachedEquipment != nil {
@@ -112,7 +268,7 @@ func (s *PlayerService) GetUserEquipment(userID int) ([]*dto.EquipmentResponse,
 	}
 
 	// Get from database
-	equipment, err := s.equipmentRepo.GetByUserID(userID)
+	equipment, err := s.equipmentRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +280,7 @@ func (s *PlayerService) GetUserEquipment(userID int) ([]*dto.EquipmentResponse,
 }
 
 // SaveEquipment saves or updates equipment
-func (s *PlayerService) SaveEquipment(req *dto.SaveEquipmentRequest) (*dto.EquipmentResponse, error) {
+func (s *PlayerService) SaveEquipment(ctx context.Context, req *dto.SaveEquipmentRequest) (*dto.EquipmentResponse, error) {
 	// Get effective equipment data (supports both nested and direct formats)
 	equipData := req.GetEffectiveEquipmentData()
 	
@@ -156,12 +312,12 @@ func (s *PlayerService) SaveEquipment(req *dto.SaveEquipmentRequest) (*dto.Equip
 
 	if equipData.EquipID == 0 {
 		// Create new equipment (ID will be auto-generated by database)
-		if err := s.equipmentRepo.Create(equipment); err != nil {
+		if err := s.equipmentRepo.Create(ctx, equipment); err != nil {
 			return nil, fmt.Errorf("failed to create equipment: %w", err)
 		}
 	} else {
 		// Check if equipment exists for update
-		existing, err := s.equipmentRepo.GetByEquipID(equipData.EquipID)
+		existing, err := s.equipmentRepo.GetByEquipID(ctx, equipData.EquipID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check existing equipment: %w", err)
 		}
@@ -176,7 +332,7 @@ func (s *PlayerService) SaveEquipment(req *dto.SaveEquipmentRequest) (*dto.Equip
 		}
 		
 		// Update existing equipment
-		if err := s.equipmentRepo.Update(equipment); err != nil {
+		if err := s.equipmentRepo.Update(ctx, equipment); err != nil {
 			return nil, fmt.Errorf("failed to update equipment: %w", err)
 		}
 	}
@@ -185,6 +341,10 @@ func (s *PlayerService) SaveEquipment(req *dto.SaveEquipmentRequest) (*dto.Equip
 	cacheKey := fmt.Sprintf("equipment:%d", req.UserID)
 	s.cacheService.Delete(cacheKey)
 
+	if s.events != nil {
+		s.events.RecordEvent(ctx, &entity.GameEvent{UserID: req.UserID, Type: entity.GameEventSaveEquipment, Count: 1})
+	}
+
 	// Return the equipment response with the final equipID
 	return &dto.EquipmentResponse{
 		EquipID:       equipment.EquipID,
@@ -209,9 +369,9 @@ func (s *PlayerService) SaveEquipment(req *dto.SaveEquipmentRequest) (*dto.Equip
 
 
 // DeleteEquipment deletes equipment
-func (s *PlayerService) DeleteEquipment(equipID, userID int) error {
+func (s *PlayerService) DeleteEquipment(ctx context.Context, equipID, userID int) error {
 	// Delete from database
-	if err := s.equipmentRepo.Delete(equipID); err != nil {
+	if err := s.equipmentRepo.Delete(ctx, equipID); err != nil {
 		return err
 	}
 
@@ -222,9 +382,42 @@ func (s *PlayerService) DeleteEquipment(equipID, userID int) error {
 	return nil
 }
 
+// grantEquipment creates a copy of each template for the user, for example
+// from mail attachments. If one cannot be created, the copies already
+// created are removed again.
+func (s *PlayerService) grantEquipment(ctx context.Context, userID int, templates []*entity.Equipment) ([]*entity.Equipment, error) {
+	created := make([]*entity.Equipment, 0, len(templates))
+	for _, template := range templates {
+		equipment := *template
+		equipment.EquipID = 0
+		equipment.UserID = userID
+		if err := s.equipmentRepo.Create(ctx, &equipment); err != nil {
+			s.removeEquipment(ctx, userID, created)
+			return nil, fmt.Errorf("failed to create equipment: %w", err)
+		}
+		created = append(created, &equipment)
+	}
+
+	s.cacheService.Delete(fmt.Sprintf("equipment:%d", userID))
+	return created, nil
+}
+
+// removeEquipment deletes equipment created by grantEquipment whose grant
+// was undone. It runs even if the request's deadline has passed.
+func (s *PlayerService) removeEquipment(ctx context.Context, userID int, equipment []*entity.Equipment) {
+	for _, equip := range equipment {
+		if err := s.equipmentRepo.Delete(context.WithoutCancel(ctx), equip.EquipID); err != nil {
+			log.Printf("Failed to remove equipment %d of user %d: %v", equip.EquipID, userID, err)
+		}
+	}
+	if len(equipment) > 0 {
+		s.cacheService.Delete(fmt.Sprintf("equipment:%d", userID))
+	}
+}
+
 // GetUserSourceStones retrieves all source stones for a user
-func (s *PlayerService) GetUserSourceStones(userID int) ([]*dto.SourceStoneResponse, error) {
-	sourceStones, err := s.sourceStoneRepo.GetByUserID(userID)
+func (s *PlayerService) GetUserSourceStones(ctx context.Context, userID int) ([]*dto.SourceStoneResponse, error) {
+	sourceStones, err := s.sourceStoneRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
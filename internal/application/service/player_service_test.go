@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/infrastructure/cache"
+)
+
+// testRules are the player rules the service tests run with
+func testRules() PlayerRules {
+	return PlayerRules{
+		ExperienceSources:         map[string]int{"battle": 1000},
+		MaxExperiencePerMinute:    1000,
+		MaxBloodEnergy:            100,
+		EnergyRegenPerMinute:      1,
+		EnergyPurchaseAmount:      50,
+		EnergyPurchasesPerDay:     3,
+		StageCount:                10,
+		StageFirstClearExperience: 500,
+		StageRepeatExperience:     100,
+		StageMinClearTime:         10 * time.Second,
+		SuspicionThreshold:        3,
+		SuspicionWindow:           time.Hour,
+	}
+}
+
+// playerNode is a player service with its own cache, as on a separate node,
+// over repositories that may be shared with other nodes
+type playerNode struct {
+	*PlayerService
+	players   *fakePlayerRepo
+	suspicion *fakeSuspicionRepo
+	stages    *fakeStageRepo
+}
+
+func newPlayerNode(players *fakePlayerRepo, xpRate *fakeXPRateRepo, rules PlayerRules) *playerNode {
+	node := &playerNode{players: players, suspicion: newFakeSuspicionRepo(), stages: newFakeStageRepo()}
+	curve := &fakeExperienceRepo{levels: []*entity.Experience{{Level: 1, Value: 100}, {Level: 2, Value: 200}, {Level: 3, Value: 300}, {Level: 4, Value: 1000}}}
+	node.PlayerService = NewPlayerService(players, nil, nil, curve, node.suspicion, xpRate, node.stages, cache.NewMemoryCache(), rules)
+	return node
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestUpdatePlayer(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         dto.UpdatePlayerRequest
+		wantRules   []string
+		wantFlagged bool
+	}{
+		{name: "nothing to change", wantRules: []string{}},
+		{name: "same game level", req: dto.UpdatePlayerRequest{GameLevel: intPtr(5)}, wantRules: []string{RuleGameLevelWrite}},
+		{name: "skipping to the last game level", req: dto.UpdatePlayerRequest{GameLevel: intPtr(9999)}, wantRules: []string{RuleGameLevelWrite}},
+		{name: "setting the level", req: dto.UpdatePlayerRequest{Level: intPtr(99)}, wantRules: []string{RuleLevelWrite}},
+		{name: "setting experience", req: dto.UpdatePlayerRequest{Experience: intPtr(99)}, wantRules: []string{RuleExperienceWrite}},
+		{name: "setting blood energy", req: dto.UpdatePlayerRequest{BloodEnergy: intPtr(100)}, wantRules: []string{RuleBloodEnergyWrite}},
+		{
+			name:        "setting every field",
+			req:         dto.UpdatePlayerRequest{Level: intPtr(99), Experience: intPtr(0), GameLevel: intPtr(100), BloodEnergy: intPtr(100)},
+			wantRules:   []string{RuleLevelWrite, RuleExperienceWrite, RuleGameLevelWrite, RuleBloodEnergyWrite},
+			wantFlagged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			players := newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, Level: 3, Experience: 10, GameLevel: 5, BloodEnergy: 40})
+			node := newPlayerNode(players, newFakeXPRateRepo(), testRules())
+
+			req := tt.req
+			req.UserID = 7
+			req.Payload = []byte(`{"gamelevel":9999}`)
+			if err := node.UpdatePlayer(ctx, &req); err == nil {
+				t.Fatal("UpdatePlayer accepted the request")
+			}
+
+			player, _ := players.GetByUserID(ctx, 7)
+			if player.Level != 3 || player.Experience != 10 || player.GameLevel != 5 || player.BloodEnergy != 40 {
+				t.Errorf("player changed: %+v", player)
+			}
+			if rules := node.suspicion.rules(); !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("violations %v, want %v", rules, tt.wantRules)
+			}
+			for _, suspicion := range node.suspicion.log {
+				if suspicion.Payload != string(req.Payload) {
+					t.Errorf("logged payload %q, want %q", suspicion.Payload, req.Payload)
+				}
+			}
+			if _, flagged := node.suspicion.flagged[7]; flagged != tt.wantFlagged {
+				t.Errorf("flagged = %v, want %v", flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestExperienceRateLimit(t *testing.T) {
+	type grant struct {
+		node    int // Index of the node handling the grant
+		stage   int // Clear this stage instead of gaining Amount directly
+		amount  int
+		wantErr bool
+	}
+
+	tests := []struct {
+		name   string
+		grants []grant
+	}{
+		{
+			name:   "within the cap",
+			grants: []grant{{amount: 400}, {amount: 600}},
+		},
+		{
+			name:   "over the cap on one node",
+			grants: []grant{{amount: 600}, {amount: 500, wantErr: true}, {amount: 400}},
+		},
+		{
+			name:   "over the cap across nodes",
+			grants: []grant{{node: 0, amount: 600}, {node: 1, amount: 500, wantErr: true}, {node: 1, amount: 400}},
+		},
+		{
+			name:   "stage experience counts",
+			grants: []grant{{amount: 600}, {stage: 1, wantErr: true}, {amount: 400}},
+		},
+		{
+			name:   "stage experience uses up the cap",
+			grants: []grant{{stage: 1}, {node: 1, amount: 600, wantErr: true}, {node: 1, amount: 500}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			players := newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, Level: 1, GameLevel: 1})
+			xpRate := newFakeXPRateRepo()
+			nodes := []*playerNode{
+				newPlayerNode(players, xpRate, testRules()),
+				newPlayerNode(players, xpRate, testRules()),
+			}
+
+			total := 0
+			for i, g := range tt.grants {
+				node := nodes[g.node]
+				var err error
+				if g.stage > 0 {
+					_, err = node.CompleteStage(ctx, &dto.CompleteStageRequest{UserID: 7, Stage: g.stage, ClearTime: 20000, Stars: 3})
+					if err == nil {
+						total += testRules().StageFirstClearExperience
+					} else if record, _ := node.stages.GetByStage(ctx, 7, g.stage); record != nil {
+						t.Errorf("grant %d: rejected clear was recorded", i)
+					}
+				} else {
+					_, err = node.GainExperience(ctx, &dto.GainExperienceRequest{UserID: 7, Source: "battle", Amount: g.amount})
+					if err == nil {
+						total += g.amount
+					}
+				}
+				if (err != nil) != g.wantErr {
+					t.Fatalf("grant %d: error = %v, want error %v", i, err, g.wantErr)
+				}
+				if g.wantErr && !reflect.DeepEqual(node.suspicion.rules(), []string{RuleExperienceRate}) {
+					t.Errorf("grant %d: violations = %v, want the rate rule", i, node.suspicion.rules())
+				}
+			}
+
+			// 1000 experience over a 100/200/300/1000 curve is level 4 with 400 left
+			player, _ := players.GetByUserID(ctx, 7)
+			if total != 1000 || player.Level != 4 || player.Experience != 400 {
+				t.Errorf("granted %d, player at level %d with %d experience", total, player.Level, player.Experience)
+			}
+		})
+	}
+}
+
+func TestRepeatedViolationsFlagAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		violations  int
+		wantFlagged bool
+	}{
+		{name: "below the threshold", violations: 2},
+		{name: "at the threshold", violations: 3, wantFlagged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			node := newPlayerNode(newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, GameLevel: 1}), newFakeXPRateRepo(), testRules())
+
+			for i := 0; i < tt.violations; i++ {
+				node.CompleteStage(ctx, &dto.CompleteStageRequest{UserID: 7, Stage: 5, ClearTime: 20000, Stars: 3})
+			}
+
+			if _, flagged := node.suspicion.flagged[7]; flagged != tt.wantFlagged {
+				t.Errorf("flagged = %v, want %v", flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestCompleteStage(t *testing.T) {
+	rewards := &entity.StageRewards{
+		FirstClear: &entity.Reward{Currencies: map[string]int{"gold": 300}, Items: map[int]int{3001: 2}},
+		Repeat:     &entity.Reward{Currencies: map[string]int{"gold": 50}},
+	}
+	items := []*entity.ItemDefinition{{ID: 3001, MaxStack: 10}}
+
+	tests := []struct {
+		name           string
+		gameLevel      int
+		cleared        bool // Whether the stage has been cleared before
+		stack          int  // Item 3001 already held
+		req            dto.CompleteStageRequest
+		wantErr        bool
+		wantRules      []string
+		wantGameLevel  int
+		wantExperience int
+		wantGold       int
+		wantItems      int // Item 3001 held afterwards
+		wantReference  string
+	}{
+		{
+			name:           "first clear of the highest stage",
+			gameLevel:      3,
+			req:            dto.CompleteStageRequest{Stage: 3, ClearTime: 20000, Stars: 2},
+			wantGameLevel:  4,
+			wantExperience: 500,
+			wantGold:       300,
+			wantItems:      2,
+			wantReference:  "stage:7:3:1",
+		},
+		{
+			name:           "first clear of a lower stage",
+			gameLevel:      3,
+			req:            dto.CompleteStageRequest{Stage: 2, ClearTime: 20000, Stars: 2},
+			wantGameLevel:  3,
+			wantExperience: 500,
+			wantGold:       300,
+			wantItems:      2,
+			wantReference:  "stage:7:2:1",
+		},
+		{
+			name:           "repeat clear",
+			gameLevel:      4,
+			cleared:        true,
+			req:            dto.CompleteStageRequest{Stage: 3, ClearTime: 20000, Stars: 3},
+			wantGameLevel:  4,
+			wantExperience: 100,
+			wantGold:       50,
+			wantReference:  "stage:7:3:2",
+		},
+		{
+			name:          "items do not fit",
+			gameLevel:     3,
+			stack:         9,
+			req:           dto.CompleteStageRequest{Stage: 3, ClearTime: 20000, Stars: 2},
+			wantErr:       true,
+			wantGameLevel: 4,
+			// The experience is granted with the clear; the gold is reversed
+			wantExperience: 500,
+			wantItems:      9,
+		},
+		{
+			name:          "locked stage",
+			gameLevel:     3,
+			req:           dto.CompleteStageRequest{Stage: 4, ClearTime: 20000, Stars: 2},
+			wantErr:       true,
+			wantRules:     []string{RuleStageLocked},
+			wantGameLevel: 3,
+		},
+		{
+			name:          "too fast",
+			gameLevel:     3,
+			req:           dto.CompleteStageRequest{Stage: 3, ClearTime: 5000, Stars: 2},
+			wantErr:       true,
+			wantRules:     []string{RuleStageClearTime},
+			wantGameLevel: 3,
+		},
+		{
+			name:          "stage does not exist",
+			gameLevel:     3,
+			req:           dto.CompleteStageRequest{Stage: 11, ClearTime: 20000, Stars: 2},
+			wantErr:       true,
+			wantGameLevel: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			players := newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, Level: 1, GameLevel: tt.gameLevel})
+			xpRate := newFakeXPRateRepo()
+			node := newPlayerNode(players, xpRate, testRules())
+			wallet := newFakeWalletRepo()
+			inventory := newFakeInventoryRepo()
+			inventory.stacks[3001] = tt.stack
+			node.SetStageRewards(rewards, NewWalletService(wallet, []string{"gold", "gems"}),
+				NewInventoryService(inventory, node.PlayerService, items))
+			if tt.cleared {
+				node.stages.RecordClear(ctx, 7, tt.req.Stage, 30000, 1)
+			}
+
+			req := tt.req
+			req.UserID = 7
+			response, err := node.CompleteStage(ctx, &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompleteStage error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && response.RewardExperience != tt.wantExperience {
+				t.Errorf("reward experience = %d, want %d", response.RewardExperience, tt.wantExperience)
+			}
+
+			player, _ := players.GetByUserID(ctx, 7)
+			// Level 1 needs 100 experience, level 2 another 200 and level 3 another 300
+			gained := 0
+			for level := 1; level < player.Level; level++ {
+				gained += level * 100
+			}
+			if gained+player.Experience != tt.wantExperience {
+				t.Errorf("player gained %d experience, want %d", gained+player.Experience, tt.wantExperience)
+			}
+			// Only the experience awarded stays counted against the cap
+			counted := 0
+			for _, amount := range xpRate.counts[7] {
+				counted += amount
+			}
+			if counted != tt.wantExperience {
+				t.Errorf("%d experience counted against the cap, want %d", counted, tt.wantExperience)
+			}
+			if player.GameLevel != tt.wantGameLevel {
+				t.Errorf("game level = %d, want %d", player.GameLevel, tt.wantGameLevel)
+			}
+			if gold := wallet.balances["gold"]; gold != tt.wantGold {
+				t.Errorf("gold = %d, want %d", gold, tt.wantGold)
+			}
+			if held := inventory.stacks[3001]; held != tt.wantItems {
+				t.Errorf("holding %d of item 3001, want %d", held, tt.wantItems)
+			}
+			if tt.wantReference != "" && (len(wallet.ledger) == 0 || wallet.ledger[0].Reason != stageReason || wallet.ledger[0].ReferenceID != tt.wantReference) {
+				t.Errorf("ledger = %+v, want a %s entry for %s", wallet.ledger, stageReason, tt.wantReference)
+			}
+			if got := node.suspicion.rules(); len(got) != len(tt.wantRules) || (len(got) > 0 && !reflect.DeepEqual(got, tt.wantRules)) {
+				t.Errorf("violations = %v, want %v", got, tt.wantRules)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// Ledger reason code for stage clear rewards
+const stageReason = "stage_clear"
+
+// GetStageMap returns every stage of the stage map with the player's records
+func (s *PlayerService) GetStageMap(ctx context.Context, userID int) (*dto.StageMapResponse, error) {
+	player, err := s.playerRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if player == nil {
+		return nil, entity.NewDomainError("player info not found")
+	}
+
+	records, err := s.stageRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byStage := make(map[int]*entity.StageRecord, len(records))
+	for _, record := range records {
+		byStage[record.Stage] = record
+	}
+
+	stages := make([]*dto.StageResponse, 0, s.rules.StageCount)
+	for stage := 1; stage <= s.rules.StageCount; stage++ {
+		response := &dto.StageResponse{
+			Stage:    stage,
+			Unlocked: stage <= player.GameLevel,
+		}
+		if record, ok := byStage[stage]; ok {
+			response.Stars = record.Stars
+			response.BestTime = record.BestTime
+			response.ClearCount = record.ClearCount
+		}
+		stages = append(stages, response)
+	}
+
+	return &dto.StageMapResponse{
+		GameLevel:  player.GameLevel,
+		StageCount: s.rules.StageCount,
+		Stages:     stages,
+	}, nil
+}
+
+// CompleteStage records a clear of an unlocked stage, awards the first-clear
+// or repeat experience and rewards, and unlocks the next stage on the first
+// clear of the highest unlocked one. The experience counts against the
+// per-minute cap; a clear is rejected before anything is recorded unless the
+// larger of the first-clear and repeat experience still fits under it. If the currencies and items cannot be granted, for example
+// because an item stack is full, the clear stays recorded and the error is
+// returned.
+func (s *PlayerService) CompleteStage(ctx context.Context, req *dto.CompleteStageRequest) (*dto.CompleteStageResponse, error) {
+	if req.Stage < 1 || req.Stage > s.rules.StageCount {
+		return nil, entity.NewDomainError("stage does not exist")
+	}
+	if req.Stars < 1 || req.Stars > 3 {
+		return nil, entity.NewDomainError("stars must be between 1 and 3")
+	}
+
+	player, err := s.playerRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if player == nil {
+		return nil, entity.NewDomainError("player info not found")
+	}
+
+	// Reject and log clears no legitimate client would report
+	if err := s.checkStageClear(ctx, player, req); err != nil {
+		return nil, err
+	}
+
+	// Whether this is the first clear is only known once it is recorded, so
+	// the larger of the two awards is reserved first and the part a repeat
+	// clear does not use is released afterwards
+	reserved := max(s.rules.StageFirstClearExperience, s.rules.StageRepeatExperience)
+	var minute int64
+	if reserved > 0 {
+		if minute, err = s.reserveExperience(ctx, req.UserID, reserved, req.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	firstClear, err := s.stageRepo.RecordClear(ctx, req.UserID, req.Stage, req.ClearTime, req.Stars)
+	if err != nil {
+		if reserved > 0 {
+			s.releaseExperience(ctx, req.UserID, minute, reserved)
+		}
+		return nil, err
+	}
+	experience := s.rules.StageRepeatExperience
+	if firstClear {
+		experience = s.rules.StageFirstClearExperience
+	}
+	if unused := reserved - experience; unused > 0 {
+		s.releaseExperience(ctx, req.UserID, minute, unused)
+	}
+	if s.events != nil {
+		s.events.RecordEvent(ctx, &entity.GameEvent{
+			UserID: req.UserID,
+			Type:   entity.GameEventCompleteStage,
+			Count:  1,
+			Value:  req.Stage,
+		})
+	}
+
+	gameLevel := player.GameLevel
+	if firstClear && req.Stage == player.GameLevel && req.Stage < s.rules.StageCount {
+		advanced, err := s.playerRepo.AdvanceGameLevel(ctx, req.UserID, req.Stage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unlock the next stage: %w", err)
+		}
+		if advanced {
+			gameLevel = req.Stage + 1
+		}
+		s.cacheService.Delete(fmt.Sprintf("player_info:%d", req.UserID))
+	}
+
+	var progress *dto.GainExperienceResponse
+	if experience > 0 {
+		if progress, err = s.awardExperience(ctx, req.UserID, experience); err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := s.stageRepo.GetByStage(ctx, req.UserID, req.Stage)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("stage record %d missing after clear", req.Stage)
+	}
+
+	response := &dto.CompleteStageResponse{
+		Stage:            record.Stage,
+		Stars:            record.Stars,
+		BestTime:         record.BestTime,
+		ClearCount:       record.ClearCount,
+		FirstClear:       firstClear,
+		RewardExperience: experience,
+		GameLevel:        gameLevel,
+		Progress:         progress,
+	}
+
+	if reward := s.stageReward(firstClear); reward != nil {
+		referenceID := fmt.Sprintf("stage:%d:%d:%d", req.UserID, req.Stage, record.ClearCount)
+		if err := s.rewards.grant(ctx, req.UserID, reward, stageReason, referenceID, nil); err != nil {
+			return nil, err
+		}
+		response.Reward = rewardResponse(reward)
+	}
+
+	return response, nil
+}
+
+// stageReward returns the currencies and items paid for a first or repeat
+// clear, or nil when the clear pays experience only
+func (s *PlayerService) stageReward(firstClear bool) *entity.Reward {
+	if s.stageRewards == nil || s.rewards == nil {
+		return nil
+	}
+	reward := s.stageRewards.Repeat
+	if firstClear {
+		reward = s.stageRewards.FirstClear
+	}
+	if reward == nil || reward.IsEmpty() {
+		return nil
+	}
+	return reward
+}
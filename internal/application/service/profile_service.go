@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// errProfileUnavailable is returned both for users that do not exist and for
+// friends-only profiles the viewer may not see, so lookups cannot tell them
+// apart
+var errProfileUnavailable = entity.NewDomainError("profile not found")
+
+// ProfileService shows players' public profiles to other players, subject
+// to the privacy settings of the player viewed
+type ProfileService struct {
+	userRepo      repository.UserRepository
+	friendRepo    repository.FriendRepository
+	userEquipRepo repository.UserEquipRepository
+	rankingRepo   repository.RankingRepository
+	privacyRepo   repository.PrivacyRepository
+	playerService *PlayerService
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(
+	userRepo repository.UserRepository,
+	friendRepo repository.FriendRepository,
+	userEquipRepo repository.UserEquipRepository,
+	rankingRepo repository.RankingRepository,
+	privacyRepo repository.PrivacyRepository,
+	playerService *PlayerService,
+) *ProfileService {
+	return &ProfileService{
+		userRepo:      userRepo,
+		friendRepo:    friendRepo,
+		userEquipRepo: userEquipRepo,
+		rankingRepo:   rankingRepo,
+		privacyRepo:   privacyRepo,
+		playerService: playerService,
+	}
+}
+
+// GetProfile returns a player's profile as the viewer may see it. Players
+// always see their own profile in full.
+func (s *ProfileService) GetProfile(ctx context.Context, req *dto.GetProfileRequest) (*dto.ProfileResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errProfileUnavailable
+	}
+
+	settings, err := s.getSettings(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ViewerID == req.UserID {
+		settings = &entity.PrivacySettings{UserID: req.UserID}
+	}
+
+	if settings.FriendsOnly {
+		areFriends, err := s.friendRepo.AreFriends(ctx, req.ViewerID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !areFriends {
+			return nil, errProfileUnavailable
+		}
+	}
+
+	playerInfo, err := s.playerService.GetPlayerInfo(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.ProfileResponse{
+		UserID:          user.ID,
+		Username:        user.Username,
+		Level:           playerInfo.Level,
+		GameLevel:       playerInfo.GameLevel,
+		EquipmentHidden: settings.HideEquipment,
+		RankingsHidden:  settings.HideRankings,
+	}
+
+	if !settings.HideEquipment {
+		equipment, err := s.userEquipRepo.GetEquippedItemDetails(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		response.Equipment = s.playerService.convertEquipmentToDTO(equipment)
+		response.EquipmentStats = equipmentStats(equipment)
+	}
+
+	if !settings.HideRankings {
+		response.Rankings = make(map[string]int)
+		for _, rankType := range rankTypes {
+			ranking, err := s.rankingRepo.GetUserRanking(ctx, req.UserID, rankType)
+			if err != nil {
+				return nil, err
+			}
+			if ranking != nil && ranking.RankPosition > 0 {
+				response.Rankings[rankType] = ranking.RankPosition
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// GetPrivacySettings returns the user's privacy settings
+func (s *ProfileService) GetPrivacySettings(ctx context.Context, userID int) (*dto.PrivacySettingsResponse, error) {
+	settings, err := s.getSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return privacySettingsResponse(settings), nil
+}
+
+// UpdatePrivacySettings changes the settings given in the request
+func (s *ProfileService) UpdatePrivacySettings(ctx context.Context, req *dto.UpdatePrivacyRequest) (*dto.PrivacySettingsResponse, error) {
+	settings, err := s.getSettings(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FriendsOnly != nil {
+		settings.FriendsOnly = *req.FriendsOnly
+	}
+	if req.HideEquipment != nil {
+		settings.HideEquipment = *req.HideEquipment
+	}
+	if req.HideRankings != nil {
+		settings.HideRankings = *req.HideRankings
+	}
+
+	if err := s.privacyRepo.Save(ctx, settings); err != nil {
+		return nil, err
+	}
+	return privacySettingsResponse(settings), nil
+}
+
+// getSettings returns the user's privacy settings, or the defaults if they
+// never changed them
+func (s *ProfileService) getSettings(ctx context.Context, userID int) (*entity.PrivacySettings, error) {
+	settings, err := s.privacyRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &entity.PrivacySettings{UserID: userID}
+	}
+	return settings, nil
+}
+
+// privacySettingsResponse describes privacy settings
+func privacySettingsResponse(settings *entity.PrivacySettings) *dto.PrivacySettingsResponse {
+	return &dto.PrivacySettingsResponse{
+		FriendsOnly:   settings.FriendsOnly,
+		HideEquipment: settings.HideEquipment,
+		HideRankings:  settings.HideRankings,
+	}
+}
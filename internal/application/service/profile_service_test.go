@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+func TestGetProfile(t *testing.T) {
+	ctx := context.Background()
+	var users []*entity.User
+	var players []*entity.PlayerInfo
+	for id := 1; id <= 5; id++ {
+		users = append(users, &entity.User{ID: id, Username: "player"})
+		players = append(players, &entity.PlayerInfo{UserID: id, Level: id, GameLevel: 1})
+	}
+	node := newPlayerNode(newFakePlayerRepo(players...), newFakeXPRateRepo(), testRules())
+	privacy := &fakePrivacyRepo{settings: map[int]*entity.PrivacySettings{
+		3: {UserID: 3, FriendsOnly: true},
+		4: {UserID: 4, FriendsOnly: true},
+		5: {UserID: 5, HideRankings: true, HideEquipment: true},
+	}}
+	rankings := &fakeRankingRepo{positions: map[int]int{1: 10, 2: 20, 3: 30, 4: 40, 5: 50}}
+	profiles := NewProfileService(newFakeUserRepo(users...), &fakeFriendRepo{friends: [][2]int{{4, 1}}},
+		&fakeUserEquipRepo{}, rankings, privacy, node.PlayerService)
+
+	tests := []struct {
+		name         string
+		viewer       int
+		userID       int
+		wantErr      error
+		wantRankings bool
+	}{
+		{name: "public profile", viewer: 1, userID: 2, wantRankings: true},
+		{name: "friends-only profile of a friend", viewer: 1, userID: 4, wantRankings: true},
+		{name: "friends-only profile of a stranger", viewer: 1, userID: 3, wantErr: errProfileUnavailable},
+		{name: "user that does not exist", viewer: 1, userID: 99, wantErr: errProfileUnavailable},
+		{name: "hidden rankings", viewer: 1, userID: 5},
+		{name: "own friends-only profile", viewer: 3, userID: 3, wantRankings: true},
+		{name: "own hidden rankings", viewer: 5, userID: 5, wantRankings: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := profiles.GetProfile(ctx, &dto.GetProfileRequest{ViewerID: tt.viewer, UserID: tt.userID})
+			if err != tt.wantErr {
+				t.Fatalf("GetProfile error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if hasRankings := len(profile.Rankings) > 0; hasRankings != tt.wantRankings || profile.RankingsHidden == tt.wantRankings {
+				t.Errorf("rankings = %v (hidden %v), want shown %v", profile.Rankings, profile.RankingsHidden, tt.wantRankings)
+			}
+		})
+	}
+}
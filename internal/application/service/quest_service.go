@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// Ledger reason code for quest rewards
+const questReason = "quest"
+
+// QuestService tracks quest and achievement progress from gameplay events
+// and grants their rewards. It is the GameEventRecorder of the services
+// that report events.
+type QuestService struct {
+	questRepo repository.QuestRepository
+	rewards   *rewardGranter
+	quests    []*entity.QuestDefinition
+	byID      map[int]*entity.QuestDefinition
+	byEvent   map[string][]*entity.QuestDefinition
+}
+
+// NewQuestService creates a new quest service for the given definitions
+func NewQuestService(
+	questRepo repository.QuestRepository,
+	walletService *WalletService,
+	inventoryService *InventoryService,
+	quests []*entity.QuestDefinition,
+) *QuestService {
+	s := &QuestService{
+		questRepo: questRepo,
+		rewards:   &rewardGranter{walletService: walletService, inventoryService: inventoryService},
+		quests:    quests,
+		byID:      make(map[int]*entity.QuestDefinition, len(quests)),
+		byEvent:   make(map[string][]*entity.QuestDefinition),
+	}
+	for _, quest := range quests {
+		s.byID[quest.ID] = quest
+		s.byEvent[quest.Event] = append(s.byEvent[quest.Event], quest)
+	}
+	return s
+}
+
+// RecordEvent advances every quest counting the event. Failures are logged
+// rather than returned because the action that caused the event has
+// already succeeded.
+func (s *QuestService) RecordEvent(ctx context.Context, event *entity.GameEvent) {
+	now := time.Now()
+	for _, quest := range s.byEvent[event.Type] {
+		period := questPeriod(quest, now)
+
+		var err error
+		if quest.Measure == entity.QuestMeasureValue {
+			if event.Value <= 0 {
+				continue
+			}
+			err = s.questRepo.RaiseProgress(ctx, event.UserID, quest.ID, period, event.Value, quest.Target)
+		} else {
+			if event.Count <= 0 {
+				continue
+			}
+			err = s.questRepo.AddProgress(ctx, event.UserID, quest.ID, period, event.Count, quest.Target)
+		}
+		if err != nil {
+			log.Printf("Failed to record %s for quest %d of user %d: %v", event.Type, quest.ID, event.UserID, err)
+		}
+	}
+}
+
+// ListQuests returns every quest with the user's progress in its current period
+func (s *QuestService) ListQuests(ctx context.Context, userID int) ([]*dto.QuestResponse, error) {
+	stored, err := s.questRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	progress := make(map[int]*entity.QuestProgress, len(stored))
+	for _, p := range stored {
+		progress[p.QuestID] = p
+	}
+
+	now := time.Now()
+	response := make([]*dto.QuestResponse, 0, len(s.quests))
+	for _, quest := range s.quests {
+		item := &dto.QuestResponse{
+			QuestID:  quest.ID,
+			Name:     quest.Name,
+			Kind:     quest.Kind,
+			Event:    quest.Event,
+			Target:   quest.Target,
+			Reward:   rewardResponse(quest.Reward),
+			ResetsAt: questResetsAt(quest, now),
+		}
+		// Progress from an earlier period has been reset
+		if p, ok := progress[quest.ID]; ok && p.Period == questPeriod(quest, now) {
+			item.Progress = p.Progress
+			item.Claimed = p.Claimed
+		}
+		item.Completed = item.Progress >= quest.Target
+		response = append(response, item)
+	}
+	return response, nil
+}
+
+// Claim grants the reward of a completed quest once per period. If the
+// reward cannot be granted, for example because an item stack is full,
+// the quest can be claimed again.
+func (s *QuestService) Claim(ctx context.Context, req *dto.QuestClaimRequest) (*dto.QuestClaimResponse, error) {
+	quest, ok := s.byID[req.QuestID]
+	if !ok {
+		return nil, entity.NewDomainError("unknown quest")
+	}
+	period := questPeriod(quest, time.Now())
+
+	claimed, err := s.questRepo.MarkClaimed(ctx, req.UserID, quest.ID, period, quest.Target)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, s.claimError(ctx, req.UserID, quest, period)
+	}
+
+	referenceID := fmt.Sprintf("quest:%d:%d:%d", req.UserID, quest.ID, period)
+	if err := s.rewards.grant(ctx, req.UserID, quest.Reward, questReason, referenceID, nil); err != nil {
+		if err := s.questRepo.UnmarkClaimed(context.WithoutCancel(ctx), req.UserID, quest.ID, period); err != nil {
+			log.Printf("Failed to release claim of quest %d for user %d: %v", quest.ID, req.UserID, err)
+		}
+		return nil, err
+	}
+
+	return &dto.QuestClaimResponse{
+		QuestID: quest.ID,
+		Reward:  rewardResponse(quest.Reward),
+	}, nil
+}
+
+// claimError explains why a quest could not be claimed
+func (s *QuestService) claimError(ctx context.Context, userID int, quest *entity.QuestDefinition, period int) error {
+	stored, err := s.questRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, p := range stored {
+		if p.QuestID == quest.ID && p.Period == period && p.Claimed {
+			return entity.NewDomainError("quest reward already claimed")
+		}
+	}
+	return entity.NewDomainError("quest is not complete")
+}
+
+// questPeriod returns the UTC day or week a quest's progress counts for,
+// or 0 for achievements
+func questPeriod(quest *entity.QuestDefinition, now time.Time) int {
+	switch quest.Kind {
+	case entity.QuestKindDaily:
+		return utcDay(now)
+	case entity.QuestKindWeekly:
+		// The Unix epoch was a Thursday; shift so weeks start on Monday
+		return (utcDay(now) + 3) / 7
+	default:
+		return 0
+	}
+}
+
+// questResetsAt returns the Unix time a quest's progress next resets, or 0
+// if it never does
+func questResetsAt(quest *entity.QuestDefinition, now time.Time) int64 {
+	switch quest.Kind {
+	case entity.QuestKindDaily:
+		return int64(questPeriod(quest, now)+1) * 86400
+	case entity.QuestKindWeekly:
+		return (int64(questPeriod(quest, now)+1)*7 - 3) * 86400
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"GameServer/internal/domain/entity"
+)
+
+func TestQuestPeriods(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatalf("parse %s: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name         string
+		kind         string
+		now          time.Time
+		wantResetsAt time.Time
+	}{
+		{name: "daily at midnight", kind: entity.QuestKindDaily, now: at("2024-01-03T00:00:00Z"), wantResetsAt: at("2024-01-04T00:00:00Z")},
+		{name: "daily before midnight", kind: entity.QuestKindDaily, now: at("2024-01-03T23:59:59Z"), wantResetsAt: at("2024-01-04T00:00:00Z")},
+		{name: "daily in another time zone", kind: entity.QuestKindDaily, now: at("2024-01-04T07:00:00+08:00"), wantResetsAt: at("2024-01-04T00:00:00Z")},
+		{name: "weekly on Monday midnight", kind: entity.QuestKindWeekly, now: at("2024-01-01T00:00:00Z"), wantResetsAt: at("2024-01-08T00:00:00Z")},
+		{name: "weekly midweek", kind: entity.QuestKindWeekly, now: at("2024-01-04T12:00:00Z"), wantResetsAt: at("2024-01-08T00:00:00Z")},
+		{name: "weekly on Sunday night", kind: entity.QuestKindWeekly, now: at("2024-01-07T23:59:59Z"), wantResetsAt: at("2024-01-08T00:00:00Z")},
+		{name: "weekly across a year end", kind: entity.QuestKindWeekly, now: at("2023-12-31T23:59:59Z"), wantResetsAt: at("2024-01-01T00:00:00Z")},
+		{name: "weekly in the epoch week", kind: entity.QuestKindWeekly, now: at("1970-01-01T00:00:00Z"), wantResetsAt: at("1970-01-05T00:00:00Z")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quest := &entity.QuestDefinition{Kind: tt.kind}
+			resetsAt := questResetsAt(quest, tt.now)
+			if resetsAt != tt.wantResetsAt.Unix() {
+				t.Fatalf("resets at %s, want %s", time.Unix(resetsAt, 0).UTC(), tt.wantResetsAt)
+			}
+
+			// The period lasts until the reset and the next one starts with it
+			period := questPeriod(quest, tt.now)
+			if last := questPeriod(quest, time.Unix(resetsAt-1, 0)); last != period {
+				t.Errorf("period a second before the reset = %d, want %d", last, period)
+			}
+			if next := questPeriod(quest, time.Unix(resetsAt, 0)); next != period+1 {
+				t.Errorf("period at the reset = %d, want %d", next, period+1)
+			}
+		})
+	}
+
+	achievement := &entity.QuestDefinition{Kind: entity.QuestKindAchievement}
+	now := at("2024-01-04T12:00:00Z")
+	if period, resetsAt := questPeriod(achievement, now), questResetsAt(achievement, now); period != 0 || resetsAt != 0 {
+		t.Errorf("achievement period %d resetting at %d, want 0 and 0", period, resetsAt)
+	}
+}
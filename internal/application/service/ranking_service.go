@@ -4,13 +4,22 @@ import (
 	"GameServer/internal/application/dto"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"GameServer/internal/domain/service"
+	"GameServer/internal/domain/valueobject"
+	"context"
+	"log"
 )
 
+// rankTypes lists the ranking types
+var rankTypes = []string{"level", "experience", "equipment_power"}
+
 // RankingService handles ranking-related business logic
 type RankingService struct {
 	rankingRepo repository.RankingRepository
 	userRepo    repository.UserRepository
 	playerRepo  repository.PlayerRepository
+	privacyRepo repository.PrivacyRepository
+	publisher   service.TopicPublisher
 }
 
 // NewRankingService creates a new ranking service
@@ -18,16 +27,33 @@ func NewRankingService(
 	rankingRepo repository.RankingRepository,
 	userRepo repository.UserRepository,
 	playerRepo repository.PlayerRepository,
+	privacyRepo repository.PrivacyRepository,
 ) *RankingService {
 	return &RankingService{
 		rankingRepo: rankingRepo,
 		userRepo:    userRepo,
 		playerRepo:  playerRepo,
+		privacyRepo: privacyRepo,
 	}
 }
 
+// SetPublisher sets where ranking changes are announced. Each ranking type is
+// published on the topic "rank:<type>".
+func (s *RankingService) SetPublisher(publisher service.TopicPublisher) {
+	s.publisher = publisher
+}
+
+// publishRanking announces a ranking change to the subscribers of its topic
+func (s *RankingService) publishRanking(rankType string, data map[string]interface{}) {
+	if s.publisher == nil {
+		return
+	}
+	data["rankType"] = rankType
+	s.publisher.Publish("rank:"+rankType, valueobject.EventRankUpdated, data)
+}
+
 // GetRanking retrieves ranking by type
-func (s *RankingService) GetRanking(req *dto.GetRankingRequest) ([]*dto.RankingResponse, error) {
+func (s *RankingService) GetRanking(ctx context.Context, req *dto.GetRankingRequest) ([]*dto.RankingResponse, error) {
 	// Set default limit if not specified
 	limit := req.Limit
 	if limit <= 0 || limit > 100 {
@@ -35,9 +61,8 @@ func (s *RankingService) GetRanking(req *dto.GetRankingRequest) ([]*dto.RankingR
 	}
 
 	// Validate rank type
-	validTypes := []string{"level", "experience", "equipment_power"}
 	isValid := false
-	for _, validType := range validTypes {
+	for _, validType := range rankTypes {
 		if req.RankType == validType {
 			isValid = true
 			break
@@ -48,7 +73,7 @@ func (s *RankingService) GetRanking(req *dto.GetRankingRequest) ([]*dto.RankingR
 	}
 
 	// Get rankings from repository
-	rankings, err := s.rankingRepo.GetRankingByType(req.RankType, limit)
+	rankings, err := s.rankingRepo.GetRankingByType(ctx, req.RankType, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +82,7 @@ func (s *RankingService) GetRanking(req *dto.GetRankingRequest) ([]*dto.RankingR
 	var response []*dto.RankingResponse
 	for _, ranking := range rankings {
 		// Get username
-		user, err := s.userRepo.GetByID(ranking.UserID)
+		user, err := s.userRepo.GetByID(ctx, ranking.UserID)
 		if err != nil {
 			continue // Skip if user not found
 		}
@@ -77,11 +102,10 @@ func (s *RankingService) GetRanking(req *dto.GetRankingRequest) ([]*dto.RankingR
 }
 
 // GetUserRanking retrieves specific user's ranking
-func (s *RankingService) GetUserRanking(userID int, rankType string) (*dto.UserRankingResponse, error) {
+func (s *RankingService) GetUserRanking(ctx context.Context, userID int, rankType string) (*dto.UserRankingResponse, error) {
 	// Validate rank type
-	validTypes := []string{"level", "experience", "equipment_power"}
 	isValid := false
-	for _, validType := range validTypes {
+	for _, validType := range rankTypes {
 		if rankType == validType {
 			isValid = true
 			break
@@ -92,16 +116,17 @@ func (s *RankingService) GetUserRanking(userID int, rankType string) (*dto.UserR
 	}
 
 	// Get user ranking
-	ranking, err := s.rankingRepo.GetUserRanking(userID, rankType)
+	ranking, err := s.rankingRepo.GetUserRanking(ctx, userID, rankType)
 	if err != nil {
 		return nil, err
 	}
-	if ranking == nil {
+	// Position 0 means unranked: not refreshed yet, flagged or hidden
+	if ranking == nil || ranking.RankPosition == 0 {
 		return nil, entity.NewDomainError("user ranking not found")
 	}
 
 	// Get username
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,9 +142,9 @@ func (s *RankingService) GetUserRanking(userID int, rankType string) (*dto.UserR
 }
 
 // UpdateUserRankings updates all rankings for a user based on current player info
-func (s *RankingService) UpdateUserRankings(userID int) error {
+func (s *RankingService) UpdateUserRankings(ctx context.Context, userID int) error {
 	// Get player info
-	playerInfo, err := s.playerRepo.GetByUserID(userID)
+	playerInfo, err := s.playerRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -128,33 +153,56 @@ func (s *RankingService) UpdateUserRankings(userID int) error {
 	}
 
 	// Update level ranking
-	if err := s.rankingRepo.UpdateUserRanking(userID, "level", playerInfo.Level); err != nil {
+	if err := s.rankingRepo.UpdateUserRanking(ctx, userID, "level", playerInfo.Level); err != nil {
 		return err
 	}
 
 	// Update experience ranking
-	if err := s.rankingRepo.UpdateUserRanking(userID, "experience", playerInfo.Experience); err != nil {
+	if err := s.rankingRepo.UpdateUserRanking(ctx, userID, "experience", playerInfo.Experience); err != nil {
 		return err
 	}
 
 	// TODO: Calculate equipment power and update equipment_power ranking
 	// For now, we'll use a placeholder value
 	equipmentPower := s.calculateEquipmentPower(userID)
-	if err := s.rankingRepo.UpdateUserRanking(userID, "equipment_power", equipmentPower); err != nil {
+	if err := s.rankingRepo.UpdateUserRanking(ctx, userID, "equipment_power", equipmentPower); err != nil {
 		return err
 	}
 
+	// Players who hide their rankings are not announced to subscribers
+	settings, err := s.privacyRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if settings != nil && settings.HideRankings {
+		return nil
+	}
+
+	s.publishRanking("level", map[string]interface{}{"userId": userID, "value": playerInfo.Level})
+	s.publishRanking("experience", map[string]interface{}{"userId": userID, "value": playerInfo.Experience})
+	s.publishRanking("equipment_power", map[string]interface{}{"userId": userID, "value": equipmentPower})
+
 	return nil
 }
 
+// RecordEvent updates the rankings of a player who levelled up, so the
+// player's ranks follow their progress between refreshes
+func (s *RankingService) RecordEvent(ctx context.Context, event *entity.GameEvent) {
+	if event.Type != entity.GameEventLevelUp {
+		return
+	}
+	if err := s.UpdateUserRankings(ctx, event.UserID); err != nil {
+		log.Printf("Failed to update rankings of user %d: %v", event.UserID, err)
+	}
+}
+
 // RefreshAllRankings recalculates all ranking positions
-func (s *RankingService) RefreshAllRankings() error {
-	rankTypes := []string{"level", "experience", "equipment_power"}
-	
+func (s *RankingService) RefreshAllRankings(ctx context.Context) error {
 	for _, rankType := range rankTypes {
-		if err := s.rankingRepo.RefreshRankings(rankType); err != nil {
+		if err := s.rankingRepo.RefreshRankings(ctx, rankType); err != nil {
 			return err
 		}
+		s.publishRanking(rankType, map[string]interface{}{"refreshed": true})
 	}
 	
 	return nil
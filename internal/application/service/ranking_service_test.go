@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"GameServer/internal/domain/entity"
+)
+
+func TestUpdateUserRankingsAnnouncements(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[int]*entity.PrivacySettings
+		wantEvents int
+	}{
+		{name: "public rankings announced", wantEvents: len(rankTypes)},
+		{name: "hidden rankings not announced", settings: map[int]*entity.PrivacySettings{7: {UserID: 7, HideRankings: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rankings := &fakeRankingRepo{}
+			rankingService := NewRankingService(rankings, newFakeUserRepo(),
+				newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, Level: 3, Experience: 40}),
+				&fakePrivacyRepo{settings: tt.settings})
+			publisher := &fakePublisher{}
+			rankingService.SetPublisher(publisher)
+
+			if err := rankingService.UpdateUserRankings(context.Background(), 7); err != nil {
+				t.Fatalf("UpdateUserRankings: %v", err)
+			}
+			if rankings.values["level"] != 3 {
+				t.Errorf("level ranking = %d, want 3", rankings.values["level"])
+			}
+			if len(publisher.topics) != tt.wantEvents {
+				t.Errorf("published to %v, want %d events", publisher.topics, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestRankingsFollowLevelUps(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		wantLevel int // Level ranking afterwards; 0 if it was not updated
+	}{
+		{name: "level up", eventType: entity.GameEventLevelUp, wantLevel: 3},
+		{name: "other event", eventType: entity.GameEventCompleteStage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rankings := &fakeRankingRepo{}
+			rankingService := NewRankingService(rankings, newFakeUserRepo(),
+				newFakePlayerRepo(&entity.PlayerInfo{UserID: 7, Level: 3, Experience: 40}),
+				&fakePrivacyRepo{})
+
+			rankingService.RecordEvent(context.Background(), &entity.GameEvent{UserID: 7, Type: tt.eventType, Count: 1, Value: 3})
+			if rankings.values["level"] != tt.wantLevel {
+				t.Errorf("level ranking = %d, want %d", rankings.values["level"], tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestGetUserRanking(t *testing.T) {
+	tests := []struct {
+		name         string
+		positions    map[int]int
+		rankType     string
+		wantErr      bool
+		wantPosition int
+	}{
+		{name: "ranked", positions: map[int]int{7: 3}, rankType: "level", wantPosition: 3},
+		{name: "no ranking row", rankType: "level", wantErr: true},
+		// Flagged and hidden players are left at 0 by the refresh
+		{name: "unranked", positions: map[int]int{7: 0}, rankType: "level", wantErr: true},
+		{name: "unknown rank type", positions: map[int]int{7: 3}, rankType: "gold", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rankingService := NewRankingService(&fakeRankingRepo{positions: tt.positions},
+				newFakeUserRepo(&entity.User{ID: 7, Username: "alice"}), newFakePlayerRepo(), &fakePrivacyRepo{})
+
+			response, err := rankingService.GetUserRanking(context.Background(), 7, tt.rankType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetUserRanking error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && response.RankPosition != tt.wantPosition {
+				t.Errorf("position = %d, want %d", response.RankPosition, tt.wantPosition)
+			}
+		})
+	}
+}
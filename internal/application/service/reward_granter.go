@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+)
+
+// rewardGranter grants reward bundles into the wallet and the inventory
+type rewardGranter struct {
+	walletService    *WalletService
+	inventoryService *InventoryService
+}
+
+// grant credits the reward's currencies, together with any extra ledger
+// entries such as a cost, in one wallet transaction and then adds its
+// items. If the items cannot be added the wallet changes are reversed, so
+// the player gets either the whole reward or none of it.
+func (g *rewardGranter) grant(ctx context.Context, userID int, reward *entity.Reward, reason, referenceID string, extra []*entity.LedgerEntry) error {
+	currencies := make([]string, 0, len(reward.Currencies))
+	for currency := range reward.Currencies {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	entries := append([]*entity.LedgerEntry{}, extra...)
+	for _, currency := range currencies {
+		entries = append(entries, &entity.LedgerEntry{
+			UserID:      userID,
+			Currency:    currency,
+			Amount:      reward.Currencies[currency],
+			Reason:      reason,
+			ReferenceID: referenceID,
+		})
+	}
+	if err := g.walletService.Apply(ctx, entries); err != nil {
+		return err
+	}
+
+	if err := g.inventoryService.GrantItems(ctx, userID, reward.Items); err != nil {
+		g.reverse(ctx, entries)
+		return err
+	}
+	return nil
+}
+
+// reverse appends ledger entries that undo entries already applied. It
+// runs even if the request's deadline has passed.
+func (g *rewardGranter) reverse(ctx context.Context, entries []*entity.LedgerEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	reversals := make([]*entity.LedgerEntry, 0, len(entries))
+	for _, entry := range entries {
+		reversals = append(reversals, &entity.LedgerEntry{
+			UserID:      entry.UserID,
+			Currency:    entry.Currency,
+			Amount:      -entry.Amount,
+			Reason:      entry.Reason + "_reversed",
+			ReferenceID: entry.ReferenceID,
+		})
+	}
+	if err := g.walletService.Apply(context.WithoutCancel(ctx), reversals); err != nil {
+		log.Printf("Failed to reverse %s ledger entries %s: %v", entries[0].Reason, entries[0].ReferenceID, err)
+	}
+}
+
+// rewardResponse describes a reward
+func rewardResponse(reward *entity.Reward) *dto.RewardResponse {
+	return &dto.RewardResponse{
+		Currencies: reward.Currencies,
+		Items:      reward.Items,
+	}
+}
@@ -1,10 +1,12 @@
 package service
 
 import (
+	"context"
 	"fmt"
 
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"GameServer/internal/domain/service"
 )
 
 // UserEquipService handles user equipment business logic
@@ -12,6 +14,7 @@ type UserEquipService struct {
 	userEquipRepo repository.UserEquipRepository
 	equipmentRepo repository.EquipmentRepository
 	userRepo      repository.UserRepository
+	events        service.GameEventRecorder
 }
 
 // NewUserEquipService creates a new user equipment service
@@ -23,10 +26,15 @@ func NewUserEquipService(userEquipRepo repository.UserEquipRepository, equipment
 	}
 }
 
+// SetEventRecorder sets where equipping an item is recorded as a gameplay event
+func (s *UserEquipService) SetEventRecorder(events service.GameEventRecorder) {
+	s.events = events
+}
+
 // GetUserEquippedItems retrieves all equipped items for a user with detailed information
-func (s *UserEquipService) GetUserEquippedItems(userID int) (map[string]interface{}, error) {
+func (s *UserEquipService) GetUserEquippedItems(ctx context.Context, userID int) (map[string]interface{}, error) {
 	// Verify user exists
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify user: %w", err)
 	}
@@ -35,19 +43,19 @@ func (s *UserEquipService) GetUserEquippedItems(userID int) (map[string]interfac
 	}
 
 	// Get user equipped items
-	userEquips, err := s.userEquipRepo.GetUserEquippedItems(userID)
+	userEquips, err := s.userEquipRepo.GetUserEquippedItems(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user equipped items: %w", err)
 	}
 
 	// If no equipment slots exist, initialize them
 	if len(userEquips) == 0 {
-		err = s.userEquipRepo.InitializeUserEquipSlots(userID)
+		err = s.userEquipRepo.InitializeUserEquipSlots(ctx, userID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to initialize equipment slots: %w", err)
 		}
 		// Get the newly initialized slots
-		userEquips, err = s.userEquipRepo.GetUserEquippedItems(userID)
+		userEquips, err = s.userEquipRepo.GetUserEquippedItems(ctx, userID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get user equipped items after initialization: %w", err)
 		}
@@ -63,7 +71,7 @@ func (s *UserEquipService) GetUserEquippedItems(userID int) (map[string]interfac
 	for _, userEquip := range userEquips {
 		if userEquip.EquipID != nil {
 			// Get equipment details
-			equipment, err := s.equipmentRepo.GetByEquipID(*userEquip.EquipID)
+			equipment, err := s.equipmentRepo.GetByEquipID(ctx, *userEquip.EquipID)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get equipment details for ID %d: %w", *userEquip.EquipID, err)
 			}
@@ -77,7 +85,7 @@ func (s *UserEquipService) GetUserEquippedItems(userID int) (map[string]interfac
 }
 
 // EquipItem equips an item to a specific slot
-func (s *UserEquipService) EquipItem(userID int, slot string, equipID int) error {
+func (s *UserEquipService) EquipItem(ctx context.Context, userID int, slot string, equipID int) error {
 	// Validate slot type
 	isValidSlot := false
 	for _, validSlot := range entity.ValidEquipSlots {
@@ -91,7 +99,7 @@ func (s *UserEquipService) EquipItem(userID int, slot string, equipID int) error
 	}
 
 	// Verify user exists
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to verify user: %w", err)
 	}
@@ -100,7 +108,7 @@ func (s *UserEquipService) EquipItem(userID int, slot string, equipID int) error
 	}
 
 	// Verify equipment exists and belongs to user
-	equipment, err := s.equipmentRepo.GetByEquipID(equipID)
+	equipment, err := s.equipmentRepo.GetByEquipID(ctx, equipID)
 	if err != nil {
 		return fmt.Errorf("failed to get equipment: %w", err)
 	}
@@ -112,7 +120,7 @@ func (s *UserEquipService) EquipItem(userID int, slot string, equipID int) error
 	}
 
 	// Check if equipment is already equipped in another slot
-	userEquips, err := s.userEquipRepo.GetUserEquippedItems(userID)
+	userEquips, err := s.userEquipRepo.GetUserEquippedItems(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user equipped items: %w", err)
 	}
@@ -135,16 +143,20 @@ func (s *UserEquipService) EquipItem(userID int, slot string, equipID int) error
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	err = s.userEquipRepo.UpdateUserEquip(userEquip)
+	err = s.userEquipRepo.UpdateUserEquip(ctx, userEquip)
 	if err != nil {
 		return fmt.Errorf("failed to equip item: %w", err)
 	}
 
+	if s.events != nil {
+		s.events.RecordEvent(ctx, &entity.GameEvent{UserID: userID, Type: entity.GameEventEquipItem, Count: 1})
+	}
+
 	return nil
 }
 
 // UnequipItem removes equipment from a specific slot
-func (s *UserEquipService) UnequipItem(userID int, slot string) error {
+func (s *UserEquipService) UnequipItem(ctx context.Context, userID int, slot string) error {
 	// Validate slot type
 	isValidSlot := false
 	for _, validSlot := range entity.ValidEquipSlots {
@@ -158,7 +170,7 @@ func (s *UserEquipService) UnequipItem(userID int, slot string) error {
 	}
 
 	// Verify user exists
-	user, err := s.userRepo.GetByID(userID)
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to verify user: %w", err)
 	}
@@ -167,7 +179,7 @@ func (s *UserEquipService) UnequipItem(userID int, slot string) error {
 	}
 
 	// Unequip item
-	err = s.userEquipRepo.UnequipItem(userID, slot)
+	err = s.userEquipRepo.UnequipItem(ctx, userID, slot)
 	if err != nil {
 		return fmt.Errorf("failed to unequip item: %w", err)
 	}
@@ -176,7 +188,7 @@ func (s *UserEquipService) UnequipItem(userID int, slot string) error {
 }
 
 // GetEquippedItemsBySlot retrieves equipment for a specific slot
-func (s *UserEquipService) GetEquippedItemsBySlot(userID int, slot string) (interface{}, error) {
+func (s *UserEquipService) GetEquippedItemsBySlot(ctx context.Context, userID int, slot string) (interface{}, error) {
 	// Validate slot type
 	isValidSlot := false
 	for _, validSlot := range entity.ValidEquipSlots {
@@ -190,7 +202,7 @@ func (s *UserEquipService) GetEquippedItemsBySlot(userID int, slot string) (inte
 	}
 
 	// Get user equipment for the slot
-	userEquip, err := s.userEquipRepo.GetUserEquipBySlot(userID, slot)
+	userEquip, err := s.userEquipRepo.GetUserEquipBySlot(ctx, userID, slot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user equipment: %w", err)
 	}
@@ -200,7 +212,7 @@ func (s *UserEquipService) GetEquippedItemsBySlot(userID int, slot string) (inte
 	}
 
 	// Get equipment details
-	equipment, err := s.equipmentRepo.GetByEquipID(*userEquip.EquipID)
+	equipment, err := s.equipmentRepo.GetByEquipID(ctx, *userEquip.EquipID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get equipment details: %w", err)
 	}
@@ -209,17 +221,31 @@ func (s *UserEquipService) GetEquippedItemsBySlot(userID int, slot string) (inte
 }
 
 // InitializeUserEquipSlots initializes equipment slots for a new user
-func (s *UserEquipService) InitializeUserEquipSlots(userID int) error {
-	return s.userEquipRepo.InitializeUserEquipSlots(userID)
+func (s *UserEquipService) InitializeUserEquipSlots(ctx context.Context, userID int) error {
+	return s.userEquipRepo.InitializeUserEquipSlots(ctx, userID)
 }
 
 // GetEquipmentStats calculates total stats from all equipped items
-func (s *UserEquipService) GetEquipmentStats(userID int) (map[string]int, error) {
-	equippedItems, err := s.GetUserEquippedItems(userID)
+func (s *UserEquipService) GetEquipmentStats(ctx context.Context, userID int) (map[string]int, error) {
+	equippedItems, err := s.GetUserEquippedItems(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get equipped items: %w", err)
 	}
 
+	var equipment []*entity.Equipment
+	for _, equipmentInterface := range equippedItems {
+		if equipmentInterface != nil {
+			if equip, ok := equipmentInterface.(*entity.Equipment); ok {
+				equipment = append(equipment, equip)
+			}
+		}
+	}
+
+	return equipmentStats(equipment), nil
+}
+
+// equipmentStats totals the stats of the given equipment
+func equipmentStats(equipment []*entity.Equipment) map[string]int {
 	stats := map[string]int{
 		"damage":      0,
 		"crit":        0,
@@ -232,22 +258,17 @@ func (s *UserEquipService) GetEquipmentStats(userID int) (map[string]int, error)
 		"goodfortune": 0,
 	}
 
-	for _, equipmentInterface := range equippedItems {
-		if equipmentInterface != nil {
-			equipment, ok := equipmentInterface.(*entity.Equipment)
-			if ok {
-				stats["damage"] += equipment.Damage
-				stats["crit"] += equipment.Crit
-				stats["critdamage"] += equipment.CritDamage
-				stats["damagespeed"] += equipment.DamageSpeed
-				stats["bloodsuck"] += equipment.BloodSuck
-				stats["hp"] += equipment.HP
-				stats["movespeed"] += equipment.MoveSpeed
-				stats["defense"] += equipment.Defense
-				stats["goodfortune"] += equipment.GoodFortune
-			}
-		}
+	for _, equip := range equipment {
+		stats["damage"] += equip.Damage
+		stats["crit"] += equip.Crit
+		stats["critdamage"] += equip.CritDamage
+		stats["damagespeed"] += equip.DamageSpeed
+		stats["bloodsuck"] += equip.BloodSuck
+		stats["hp"] += equip.HP
+		stats["movespeed"] += equip.MoveSpeed
+		stats["defense"] += equip.Defense
+		stats["goodfortune"] += equip.GoodFortune
 	}
 
-	return stats, nil
+	return stats
 }
\ No newline at end of file
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// Ledger history page sizes
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// WalletService handles currency balances. Every change goes through the
+// append-only ledger; other services credit and debit through Apply.
+type WalletService struct {
+	walletRepo repository.WalletRepository
+	currencies []string
+}
+
+// NewWalletService creates a new wallet service for the given currencies
+func NewWalletService(walletRepo repository.WalletRepository, currencies []string) *WalletService {
+	return &WalletService{
+		walletRepo: walletRepo,
+		currencies: currencies,
+	}
+}
+
+// IsCurrency reports whether currency is configured
+func (s *WalletService) IsCurrency(currency string) bool {
+	for _, c := range s.currencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBalance returns the user's balance of every configured currency
+func (s *WalletService) GetBalance(ctx context.Context, userID int) (*dto.WalletBalanceResponse, error) {
+	balances, err := s.walletRepo.GetBalances(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.WalletBalanceResponse{Balances: make(map[string]int, len(s.currencies))}
+	for _, currency := range s.currencies {
+		response.Balances[currency] = 0
+	}
+	for _, balance := range balances {
+		if s.IsCurrency(balance.Currency) {
+			response.Balances[balance.Currency] = balance.Balance
+		}
+	}
+	return response, nil
+}
+
+// GetHistory returns a page of the user's ledger entries, newest first
+func (s *WalletService) GetHistory(ctx context.Context, req *dto.WalletHistoryRequest) (*dto.WalletHistoryResponse, error) {
+	if req.Currency != "" && !s.IsCurrency(req.Currency) {
+		return nil, entity.NewDomainError("unknown currency")
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	entries, err := s.walletRepo.GetHistory(ctx, req.UserID, req.Currency, req.BeforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dto.WalletHistoryResponse{Entries: make([]*dto.LedgerEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, &dto.LedgerEntryResponse{
+			ID:           entry.ID,
+			Currency:     entry.Currency,
+			Amount:       entry.Amount,
+			BalanceAfter: entry.BalanceAfter,
+			Reason:       entry.Reason,
+			ReferenceID:  entry.ReferenceID,
+			CreatedAt:    entry.CreatedAt,
+		})
+	}
+	if len(entries) == limit {
+		response.NextBeforeID = entries[len(entries)-1].ID
+	}
+	return response, nil
+}
+
+// Credit adds amount of currency to the user's balance
+func (s *WalletService) Credit(ctx context.Context, userID int, currency string, amount int, reason, referenceID string) (int, error) {
+	if amount <= 0 {
+		return 0, entity.NewDomainError("credit amount must be positive")
+	}
+	return s.applyOne(ctx, userID, currency, amount, reason, referenceID)
+}
+
+// Debit removes amount of currency from the user's balance, failing with
+// entity.ErrInsufficientBalance rather than going negative
+func (s *WalletService) Debit(ctx context.Context, userID int, currency string, amount int, reason, referenceID string) (int, error) {
+	if amount <= 0 {
+		return 0, entity.NewDomainError("debit amount must be positive")
+	}
+	return s.applyOne(ctx, userID, currency, -amount, reason, referenceID)
+}
+
+// applyOne applies a single ledger entry and returns the new balance
+func (s *WalletService) applyOne(ctx context.Context, userID int, currency string, amount int, reason, referenceID string) (int, error) {
+	entry := &entity.LedgerEntry{
+		UserID:      userID,
+		Currency:    currency,
+		Amount:      amount,
+		Reason:      reason,
+		ReferenceID: referenceID,
+	}
+	if err := s.Apply(ctx, []*entity.LedgerEntry{entry}); err != nil {
+		return 0, err
+	}
+	return entry.BalanceAfter, nil
+}
+
+// Apply validates the entries and applies them in one transaction: either
+// every balance changes or none does
+func (s *WalletService) Apply(ctx context.Context, entries []*entity.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		if !s.IsCurrency(entry.Currency) {
+			return entity.NewDomainError("unknown currency")
+		}
+		if entry.Amount == 0 {
+			return entity.NewDomainError("ledger entry amount cannot be zero")
+		}
+		if entry.Reason == "" {
+			return entity.NewDomainError("ledger entry needs a reason code")
+		}
+	}
+	return s.walletRepo.Apply(ctx, entries)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"GameServer/internal/domain/entity"
+)
+
+func TestWalletApply(t *testing.T) {
+	entry := func(currency string, amount int, reason string) *entity.LedgerEntry {
+		return &entity.LedgerEntry{UserID: 7, Currency: currency, Amount: amount, Reason: reason}
+	}
+
+	tests := []struct {
+		name         string
+		entries      []*entity.LedgerEntry
+		wantErr      error
+		wantDomain   bool // Rejected with a domain error before reaching the repository
+		wantBalances map[string]int
+	}{
+		{name: "no entries", wantBalances: map[string]int{"gold": 100}},
+		{
+			name:         "credit and debit together",
+			entries:      []*entity.LedgerEntry{entry("gold", -100, "shop"), entry("gems", 5, "shop")},
+			wantBalances: map[string]int{"gold": 0, "gems": 5},
+		},
+		{
+			name:         "credit covers a later debit",
+			entries:      []*entity.LedgerEntry{entry("gems", 30, "quest"), entry("gems", -20, "checkin")},
+			wantBalances: map[string]int{"gold": 100, "gems": 10},
+		},
+		{
+			name:         "insufficient balance changes nothing",
+			entries:      []*entity.LedgerEntry{entry("gems", 5, "shop"), entry("gold", -101, "shop")},
+			wantErr:      entity.ErrInsufficientBalance,
+			wantBalances: map[string]int{"gold": 100},
+		},
+		{
+			name:         "unknown currency",
+			entries:      []*entity.LedgerEntry{entry("gold", 10, "quest"), entry("tokens", 10, "quest")},
+			wantDomain:   true,
+			wantBalances: map[string]int{"gold": 100},
+		},
+		{
+			name:         "zero amount",
+			entries:      []*entity.LedgerEntry{entry("gold", 0, "quest")},
+			wantDomain:   true,
+			wantBalances: map[string]int{"gold": 100},
+		},
+		{
+			name:         "missing reason",
+			entries:      []*entity.LedgerEntry{entry("gold", 10, "")},
+			wantDomain:   true,
+			wantBalances: map[string]int{"gold": 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wallet := newFakeWalletRepo()
+			wallet.balances["gold"] = 100
+			service := NewWalletService(wallet, []string{"gold", "gems"})
+
+			err := service.Apply(context.Background(), tt.entries)
+			var domainErr *entity.DomainError
+			switch {
+			case tt.wantDomain:
+				if !errors.As(err, &domainErr) {
+					t.Fatalf("Apply error = %v, want a domain error", err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("Apply error = %v, want %v", err, tt.wantErr)
+			}
+
+			for currency, want := range tt.wantBalances {
+				if got := wallet.balances[currency]; got != want {
+					t.Errorf("%s balance = %d, want %d", currency, got, want)
+				}
+			}
+			if err != nil && len(wallet.ledger) != 0 {
+				t.Errorf("%d ledger entries appended by a failed apply", len(wallet.ledger))
+			}
+		})
+	}
+}
+
+func TestWalletCreditDebit(t *testing.T) {
+	tests := []struct {
+		name        string
+		debit       bool
+		amount      int
+		wantErr     bool
+		wantBalance int
+	}{
+		{name: "credit", amount: 50, wantBalance: 150},
+		{name: "credit zero", amount: 0, wantErr: true},
+		{name: "credit negative", amount: -50, wantErr: true},
+		{name: "debit", debit: true, amount: 40, wantBalance: 60},
+		{name: "debit whole balance", debit: true, amount: 100, wantBalance: 0},
+		{name: "debit more than the balance", debit: true, amount: 101, wantErr: true},
+		{name: "debit negative", debit: true, amount: -40, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			wallet := newFakeWalletRepo()
+			wallet.balances["gold"] = 100
+			service := NewWalletService(wallet, []string{"gold", "gems"})
+
+			var balance int
+			var err error
+			if tt.debit {
+				balance, err = service.Debit(ctx, 7, "gold", tt.amount, "shop", "order:1")
+			} else {
+				balance, err = service.Credit(ctx, 7, "gold", tt.amount, "quest", "quest:1")
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if wallet.balances["gold"] != 100 {
+					t.Errorf("balance changed to %d by a failed call", wallet.balances["gold"])
+				}
+				return
+			}
+			if balance != tt.wantBalance || wallet.balances["gold"] != tt.wantBalance {
+				t.Errorf("returned balance %d, stored %d, want %d", balance, wallet.balances["gold"], tt.wantBalance)
+			}
+		})
+	}
+}
@@ -43,4 +43,139 @@ CREATE TABLE IF NOT EXISTS ranking (
 CREATE INDEX idx_friend_fromuserid ON friend(fromuserid);
 CREATE INDEX idx_friend_touserid ON friend(touserid);
 CREATE INDEX idx_friend_request_touserid ON friend_request(touserid);
-CREATE INDEX idx_ranking_type_value ON ranking(rank_type, rank_value DESC);
\ No newline at end of file
+CREATE INDEX idx_ranking_type_value ON ranking(rank_type, rank_value DESC);
+-- 反作弊可疑记录表（违规的用户、规则和原始请求数据）
+CREATE TABLE IF NOT EXISTS suspicion_log (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    userid INT NOT NULL,
+    rule VARCHAR(64) NOT NULL,
+    detail VARCHAR(255) NOT NULL DEFAULT '',
+    payload TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_suspicion_user_time (userid, created_at)
+);
+
+-- 账号标记表（多次违规的账号，待人工审核）
+CREATE TABLE IF NOT EXISTS account_flag (
+    userid INT PRIMARY KEY,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    flagged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家信息表（血能恢复字段为恢复计时的Unix时间、当天购买次数和购买日期；
+-- 已有的表用 migrate_energy_regen.sql 添加这些字段）
+CREATE TABLE IF NOT EXISTS playerinfo (
+    userid INT PRIMARY KEY,
+    level INT DEFAULT 1,
+    experience INT DEFAULT 0,
+    gamelevel INT DEFAULT 1,
+    bloodenergy INT DEFAULT 100,
+    energy_updated_at BIGINT NOT NULL DEFAULT 0,
+    energy_purchases INT NOT NULL DEFAULT 0,
+    energy_purchase_day INT NOT NULL DEFAULT 0
+);
+
+-- 关卡记录表（每个玩家每个关卡的最佳时间、星级和通关次数）
+CREATE TABLE IF NOT EXISTS stage_record (
+    userid INT NOT NULL,
+    stage INT NOT NULL,
+    stars TINYINT NOT NULL DEFAULT 0,
+    best_time INT NOT NULL DEFAULT 0,
+    clear_count INT NOT NULL DEFAULT 0,
+    first_cleared_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (userid, stage)
+);
+
+-- 钱包余额表（每个用户每种货币一行）
+CREATE TABLE IF NOT EXISTS wallet_balance (
+    userid INT NOT NULL,
+    currency VARCHAR(32) NOT NULL,
+    balance BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (userid, currency)
+);
+
+-- 钱包流水表（只追加，每次余额变化一行，记录原因和关联ID）
+CREATE TABLE IF NOT EXISTS wallet_ledger (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    userid INT NOT NULL,
+    currency VARCHAR(32) NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    reason VARCHAR(64) NOT NULL,
+    reference_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_wallet_ledger_user (userid, id),
+    INDEX idx_wallet_ledger_reference (reference_id)
+);
+
+-- 道具背包表（可堆叠道具，每个用户每种道具一行；道具定义见 configs/items.json）
+CREATE TABLE IF NOT EXISTS inventory (
+    userid INT NOT NULL,
+    item_id INT NOT NULL,
+    count INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (userid, item_id)
+);
+
+-- 每日签到表（连续签到天数、最后签到日（UTC，自1970-01-01起的天数）、累计签到天数）
+CREATE TABLE IF NOT EXISTS checkin (
+    userid INT PRIMARY KEY,
+    streak INT NOT NULL DEFAULT 0,
+    last_day INT NOT NULL DEFAULT 0,
+    total_days INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- 任务进度表（每个用户每个任务一行；period 为进度所属的 UTC 日或周，成就为 0，周期变化时进度清零；任务定义见 configs/quests.json）
+CREATE TABLE IF NOT EXISTS quest_progress (
+    userid INT NOT NULL,
+    quest_id INT NOT NULL,
+    period INT NOT NULL DEFAULT 0,
+    progress INT NOT NULL DEFAULT 0,
+    claimed TINYINT(1) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (userid, quest_id)
+);
+
+-- 邮件表（系统邮件和玩家邮件；attachments 为附件 JSON（货币、道具、装备），只能领取一次；过期后不再显示）
+CREATE TABLE IF NOT EXISTS mail (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    userid INT NOT NULL,
+    sender_id INT NOT NULL DEFAULT 0,
+    sender_name VARCHAR(64) NOT NULL DEFAULT '',
+    kind VARCHAR(16) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    attachments TEXT,
+    is_read TINYINT(1) NOT NULL DEFAULT 0,
+    claimed TINYINT(1) NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_mail_user (userid, expires_at)
+);
+
+-- 隐私设置表（没有记录的用户使用默认设置：资料对所有人可见）
+CREATE TABLE IF NOT EXISTS privacy_setting (
+    userid INT PRIMARY KEY,
+    friends_only TINYINT(1) NOT NULL DEFAULT 0,
+    hide_equipment TINYINT(1) NOT NULL DEFAULT 0,
+    hide_rankings TINYINT(1) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- HTTP API 令牌表（只保存令牌的 SHA-256 哈希，所有节点共享）
+CREATE TABLE IF NOT EXISTS api_token (
+    token_hash CHAR(64) PRIMARY KEY,
+    userid INT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_api_token_user (userid, expires_at)
+);
+
+-- 经验获取速率表（每个用户每分钟获得的经验，所有节点共享，用于每分钟经验上限）
+CREATE TABLE IF NOT EXISTS experience_rate (
+    userid INT NOT NULL,
+    minute_index BIGINT NOT NULL,
+    amount INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (userid, minute_index)
+);
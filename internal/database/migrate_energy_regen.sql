@@ -0,0 +1,16 @@
+-- Migration script to add the blood energy regeneration and purchase fields
+-- Run this script to update existing database schema
+
+USE Vampire;
+
+-- Add the columns to the playerinfo table: when energy last regenerated
+-- (Unix time), purchases made today and the UTC day they were made on
+ALTER TABLE playerinfo ADD COLUMN energy_updated_at BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE playerinfo ADD COLUMN energy_purchases INT NOT NULL DEFAULT 0;
+ALTER TABLE playerinfo ADD COLUMN energy_purchase_day INT NOT NULL DEFAULT 0;
+
+-- Existing players' energy regenerates from now on
+UPDATE playerinfo SET energy_updated_at = UNIX_TIMESTAMP() WHERE energy_updated_at = 0;
+
+-- Verify the change
+DESCRIBE playerinfo;
@@ -28,6 +28,62 @@ type PlayerInfo struct {
 	Experience  int `json:"experience"`
 	GameLevel   int `json:"gamelevel"`
 	BloodEnergy int `json:"bloodenergy"`
+
+	// EnergyUpdatedAt is the Unix time BloodEnergy was last regenerated
+	// up to; 0 means regeneration has not started yet
+	EnergyUpdatedAt int64 `json:"energy_updated_at"`
+	// EnergyPurchases counts the energy purchases made on EnergyPurchaseDay,
+	// a day number since the Unix epoch in UTC
+	EnergyPurchases   int `json:"energy_purchases"`
+	EnergyPurchaseDay int `json:"energy_purchase_day"`
+}
+
+// RegenerateEnergy adds the blood energy regenerated since EnergyUpdatedAt,
+// perMinute for every full minute, up to maxEnergy. Partial minutes carry
+// over to the next call. Energy does not regenerate while at or above the
+// cap, so the clock restarts from now once the player spends below it.
+func (p *PlayerInfo) RegenerateEnergy(now time.Time, maxEnergy, perMinute int) {
+	nowUnix := now.Unix()
+	if p.EnergyUpdatedAt == 0 || p.BloodEnergy >= maxEnergy || perMinute <= 0 {
+		p.EnergyUpdatedAt = nowUnix
+		return
+	}
+
+	minutes := (nowUnix - p.EnergyUpdatedAt) / 60
+	if minutes <= 0 {
+		return
+	}
+
+	p.BloodEnergy += int(minutes) * perMinute
+	if p.BloodEnergy >= maxEnergy {
+		p.BloodEnergy = maxEnergy
+		p.EnergyUpdatedAt = nowUnix
+		return
+	}
+	p.EnergyUpdatedAt += minutes * 60
+}
+
+// GainExperience adds experience and levels the player up for as long as the
+// experience covers what the curve requires at the current level. curve maps
+// a level to the experience needed to advance from it; a level missing from
+// the curve is the maximum level, where experience no longer accumulates.
+// It returns the number of levels gained.
+func (p *PlayerInfo) GainExperience(amount int, curve map[int]int) int {
+	gained := 0
+	p.Experience += amount
+	for {
+		required, ok := curve[p.Level]
+		if !ok {
+			p.Experience = 0
+			return gained
+		}
+		if p.Experience < required {
+			return gained
+		}
+		p.Experience -= required
+		p.Level++
+		gained++
+	}
 }
 
 // Friend represents a friend relationship entity
@@ -134,6 +190,250 @@ func (ue *UserEquip) Validate() error {
 	return nil
 }
 
+// StageRecord holds a player's results on one stage
+type StageRecord struct {
+	UserID         int       `json:"userid"`
+	Stage          int       `json:"stage"`
+	Stars          int       `json:"stars"`     // Best star rating, 1-3
+	BestTime       int       `json:"best_time"` // Fastest clear in milliseconds
+	ClearCount     int       `json:"clear_count"`
+	FirstClearedAt time.Time `json:"first_cleared_at"`
+}
+
+// Built-in currencies; more can be configured
+const (
+	CurrencyGold = "gold"
+	CurrencyGems = "gems"
+)
+
+// CurrencyBalance holds a user's balance of one currency
+type CurrencyBalance struct {
+	UserID   int    `json:"userid"`
+	Currency string `json:"currency"`
+	Balance  int    `json:"balance"`
+}
+
+// LedgerEntry records one change to a user's balance. Entries are only
+// ever appended; the balance is the sum of a user's entries per currency.
+type LedgerEntry struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"userid"`
+	Currency     string    `json:"currency"`
+	Amount       int       `json:"amount"` // Positive for credits, negative for debits
+	BalanceAfter int       `json:"balance_after"`
+	Reason       string    `json:"reason"`       // Reason code, e.g. "stage_clear"
+	ReferenceID  string    `json:"reference_id"` // ID of what caused the change, e.g. a mail or order ID
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ErrInsufficientBalance is returned when a debit would make a balance negative
+var ErrInsufficientBalance = NewDomainError("insufficient balance")
+
+// Item effect types
+const (
+	ItemEffectRestoreEnergy   = "restore_energy"
+	ItemEffectGrantExperience = "grant_experience"
+)
+
+// ItemDefinition describes a stackable item such as a potion or material
+type ItemDefinition struct {
+	ID       int         `json:"id"`
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`      // e.g. "potion", "ticket", "material"
+	MaxStack int         `json:"max_stack"` // Most of the item one user may hold
+	Effect   *ItemEffect `json:"effect,omitempty"`
+}
+
+// ItemEffect is what using one item does; items without an effect cannot be used
+type ItemEffect struct {
+	Type   string `json:"type"`
+	Amount int    `json:"amount"`
+}
+
+// InventoryItem is a user's stack of one item
+type InventoryItem struct {
+	UserID int `json:"userid"`
+	ItemID int `json:"item_id"`
+	Count  int `json:"count"`
+}
+
+// ItemGrant adds Count of an item to a stack that may hold at most MaxStack
+type ItemGrant struct {
+	ItemID   int
+	Count    int
+	MaxStack int
+}
+
+// ErrStackFull is returned when a grant would exceed an item's stack limit
+var ErrStackFull = NewDomainError("item stack is full")
+
+// Reward is a bundle of currencies and items granted to a player
+type Reward struct {
+	Currencies map[string]int `json:"currencies,omitempty"` // Currency to amount
+	Items      map[int]int    `json:"items,omitempty"`      // Item ID to count
+}
+
+// IsEmpty reports whether the reward grants nothing
+func (r *Reward) IsEmpty() bool {
+	return len(r.Currencies) == 0 && len(r.Items) == 0
+}
+
+// StageRewards are the currencies and items paid for stage clears, on top of
+// the configured experience. Either reward may be left out to pay nothing.
+type StageRewards struct {
+	FirstClear *Reward `json:"first_clear"` // Paid the first time a stage is cleared
+	Repeat     *Reward `json:"repeat"`      // Paid for every later clear
+}
+
+// CheckInCalendar is the cycle of daily check-in rewards. Day N of a streak
+// earns Days[(N-1) % len(Days)], so the calendar repeats once completed.
+type CheckInCalendar struct {
+	Days   []*Reward     `json:"days"`
+	MakeUp CheckInMakeUp `json:"make_up"`
+}
+
+// CheckInMakeUp prices checking in for a missed day to keep a streak
+type CheckInMakeUp struct {
+	MaxDays  int    `json:"max_days"` // Longest gap that can still be made up; 0 disables make-ups
+	Currency string `json:"currency"`
+	Cost     int    `json:"cost"` // Charged per day made up
+}
+
+// CheckIn tracks a user's daily check-in streak
+type CheckIn struct {
+	UserID    int `json:"userid"`
+	Streak    int `json:"streak"`
+	LastDay   int `json:"last_day"` // Latest UTC day checked in, counted from the Unix epoch
+	TotalDays int `json:"total_days"`
+}
+
+// Gameplay events that drive quest progress
+const (
+	GameEventEquipItem     = "equip_item"
+	GameEventSaveEquipment = "save_equipment"
+	GameEventAddFriend     = "add_friend"
+	GameEventLevelUp       = "level_up"
+	GameEventCompleteStage = "complete_stage"
+)
+
+// GameEvent is something a player did that quests may count
+type GameEvent struct {
+	UserID int
+	Type   string
+	Count  int // How many times it happened, e.g. levels gained
+	Value  int // What it reached, e.g. the new level or the stage cleared
+}
+
+// Quest kinds. Daily and weekly quests reset at the start of each UTC day
+// and week (weeks start on Monday); achievements never reset.
+const (
+	QuestKindDaily       = "daily"
+	QuestKindWeekly      = "weekly"
+	QuestKindAchievement = "achievement"
+)
+
+// Quest progress measures
+const (
+	QuestMeasureCount = "count" // Sum of the event counts
+	QuestMeasureValue = "value" // Highest event value
+)
+
+// QuestDefinition describes a quest or achievement and its reward
+type QuestDefinition struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Kind    string  `json:"kind"`
+	Event   string  `json:"event"`
+	Measure string  `json:"measure,omitempty"` // Defaults to QuestMeasureCount
+	Target  int     `json:"target"`
+	Reward  *Reward `json:"reward"`
+}
+
+// QuestProgress is a user's progress on a quest in one period
+type QuestProgress struct {
+	UserID   int  `json:"userid"`
+	QuestID  int  `json:"quest_id"`
+	Period   int  `json:"period"` // UTC day or week the progress counts for; 0 for achievements
+	Progress int  `json:"progress"`
+	Claimed  bool `json:"claimed"`
+}
+
+// Mail kinds
+const (
+	MailKindSystem = "system"
+	MailKindPlayer = "player"
+)
+
+// Mail is a message in a user's mailbox. System mail may carry attachments,
+// which the recipient can claim once before the mail expires.
+type Mail struct {
+	ID          int              `json:"id"`
+	UserID      int              `json:"userid"`      // Recipient
+	SenderID    int              `json:"sender_id"`   // 0 for system mail
+	SenderName  string           `json:"sender_name"` // Shown to the recipient
+	Kind        string           `json:"kind"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	Attachments *MailAttachments `json:"attachments,omitempty"`
+	Read        bool             `json:"read"`
+	Claimed     bool             `json:"claimed"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// MailAttachments are the currencies, items and equipment a mail grants
+type MailAttachments struct {
+	Currencies map[string]int `json:"currencies,omitempty"` // Currency to amount
+	Items      map[int]int    `json:"items,omitempty"`      // Item ID to count
+	Equipment  []*Equipment   `json:"equipment,omitempty"`  // Created for the recipient on claim
+}
+
+// IsEmpty reports whether the attachments grant nothing
+func (a *MailAttachments) IsEmpty() bool {
+	return a == nil || (len(a.Currencies) == 0 && len(a.Items) == 0 && len(a.Equipment) == 0)
+}
+
+// Reward returns the currencies and items of the attachments
+func (a *MailAttachments) Reward() *Reward {
+	return &Reward{Currencies: a.Currencies, Items: a.Items}
+}
+
+// MailRecipients selects the users a bulk mail is sent to: the listed
+// users, or everyone when none are listed, narrowed to players within the
+// level range when a bound is set (0 means unbounded)
+type MailRecipients struct {
+	UserIDs  []int
+	MinLevel int
+	MaxLevel int
+}
+
+// PrivacySettings controls what other players see of a user's profile.
+// The zero value, used for users who never changed it, shows everything.
+type PrivacySettings struct {
+	UserID        int  `json:"userid"`
+	FriendsOnly   bool `json:"friends_only"`   // Only friends may view the profile
+	HideEquipment bool `json:"hide_equipment"` // Hide equipped items and their stats
+	HideRankings  bool `json:"hide_rankings"`  // Hide ranking positions
+}
+
+// Suspicion records a player update that broke an anti-cheat rule
+type Suspicion struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userid"`
+	Rule      string    `json:"rule"`
+	Detail    string    `json:"detail"`
+	Payload   string    `json:"payload"` // Raw request data as the client sent it
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIToken is a bearer token for the HTTP API. Only a hash of the token is
+// stored, so a leaked table does not leak usable tokens.
+type APIToken struct {
+	TokenHash string    `json:"-"`
+	UserID    int       `json:"userid"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 // DomainError represents domain-specific errors
 type DomainError struct {
 	Message string
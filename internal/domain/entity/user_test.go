@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegenerateEnergy(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name        string
+		energy      int
+		updatedAt   int64
+		perMinute   int
+		wantEnergy  int
+		wantUpdated int64
+	}{
+		{name: "clock not started", energy: 10, updatedAt: 0, perMinute: 1, wantEnergy: 10, wantUpdated: now.Unix()},
+		{name: "under a minute", energy: 10, updatedAt: now.Unix() - 59, perMinute: 1, wantEnergy: 10, wantUpdated: now.Unix() - 59},
+		{name: "one minute", energy: 10, updatedAt: now.Unix() - 60, perMinute: 1, wantEnergy: 11, wantUpdated: now.Unix()},
+		{name: "partial minute carries over", energy: 10, updatedAt: now.Unix() - 150, perMinute: 2, wantEnergy: 14, wantUpdated: now.Unix() - 30},
+		{name: "stops at the cap", energy: 95, updatedAt: now.Unix() - 600, perMinute: 1, wantEnergy: 100, wantUpdated: now.Unix()},
+		{name: "at the cap", energy: 100, updatedAt: now.Unix() - 600, perMinute: 1, wantEnergy: 100, wantUpdated: now.Unix()},
+		{name: "above the cap", energy: 150, updatedAt: now.Unix() - 600, perMinute: 1, wantEnergy: 150, wantUpdated: now.Unix()},
+		{name: "regeneration off", energy: 10, updatedAt: now.Unix() - 600, perMinute: 0, wantEnergy: 10, wantUpdated: now.Unix()},
+		{name: "clock ahead of now", energy: 10, updatedAt: now.Unix() + 120, perMinute: 1, wantEnergy: 10, wantUpdated: now.Unix() + 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := &PlayerInfo{BloodEnergy: tt.energy, EnergyUpdatedAt: tt.updatedAt}
+			player.RegenerateEnergy(now, 100, tt.perMinute)
+			if player.BloodEnergy != tt.wantEnergy || player.EnergyUpdatedAt != tt.wantUpdated {
+				t.Errorf("energy %d updated at %d, want %d updated at %d",
+					player.BloodEnergy, player.EnergyUpdatedAt, tt.wantEnergy, tt.wantUpdated)
+			}
+		})
+	}
+}
+
+func TestGainExperience(t *testing.T) {
+	curve := map[int]int{1: 100, 2: 200, 3: 400}
+
+	tests := []struct {
+		name           string
+		curve          map[int]int
+		level          int
+		experience     int
+		amount         int
+		wantGained     int
+		wantLevel      int
+		wantExperience int
+	}{
+		{name: "below the threshold", curve: curve, level: 1, experience: 0, amount: 99, wantGained: 0, wantLevel: 1, wantExperience: 99},
+		{name: "exact threshold", curve: curve, level: 1, experience: 0, amount: 100, wantGained: 1, wantLevel: 2, wantExperience: 0},
+		{name: "existing experience counts", curve: curve, level: 1, experience: 60, amount: 50, wantGained: 1, wantLevel: 2, wantExperience: 10},
+		{name: "several levels at once", curve: curve, level: 1, experience: 0, amount: 350, wantGained: 2, wantLevel: 3, wantExperience: 50},
+		{name: "reaches the max level", curve: curve, level: 3, experience: 390, amount: 20, wantGained: 1, wantLevel: 4, wantExperience: 0},
+		{name: "overflow past the max level", curve: curve, level: 1, experience: 0, amount: 10_000, wantGained: 3, wantLevel: 4, wantExperience: 0},
+		{name: "already at the max level", curve: curve, level: 4, experience: 0, amount: 500, wantGained: 0, wantLevel: 4, wantExperience: 0},
+		{name: "zero amount", curve: curve, level: 2, experience: 150, amount: 0, wantGained: 0, wantLevel: 2, wantExperience: 150},
+		{name: "empty curve", curve: map[int]int{}, level: 1, experience: 30, amount: 500, wantGained: 0, wantLevel: 1, wantExperience: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := &PlayerInfo{Level: tt.level, Experience: tt.experience}
+			gained := player.GainExperience(tt.amount, tt.curve)
+			if gained != tt.wantGained || player.Level != tt.wantLevel || player.Experience != tt.wantExperience {
+				t.Errorf("gained %d, level %d with %d experience, want gained %d, level %d with %d experience",
+					gained, player.Level, player.Experience, tt.wantGained, tt.wantLevel, tt.wantExperience)
+			}
+		})
+	}
+}
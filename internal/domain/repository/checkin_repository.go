@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// CheckInRepository defines the interface for daily check-in data access
+type CheckInRepository interface {
+	// GetByUserID retrieves a user's check-in record, or nil if they never
+	// checked in
+	GetByUserID(ctx context.Context, userID int) (*entity.CheckIn, error)
+
+	// Save stores the record if the stored last day is still oldLastDay and
+	// reports whether it did. Users without a record have last day 0.
+	Save(ctx context.Context, record *entity.CheckIn, oldLastDay int) (bool, error)
+}
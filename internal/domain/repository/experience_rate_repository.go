@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+)
+
+// ExperienceRateRepository counts the experience granted to each user per
+// minute in shared storage, so the per-minute cap holds across nodes
+type ExperienceRateRepository interface {
+	// Reserve adds amount to the user's count for the given minute unless
+	// the count would exceed limit, and reports whether it did. Counts of
+	// earlier minutes are discarded.
+	Reserve(ctx context.Context, userID int, minute int64, amount, limit int) (bool, error)
+
+	// Release takes back amount reserved for the given minute but not granted
+	Release(ctx context.Context, userID int, minute int64, amount int) error
+}
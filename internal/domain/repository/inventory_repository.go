@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// InventoryRepository defines the interface for stackable item data access
+type InventoryRepository interface {
+	// GetByUserID retrieves the non-empty stacks a user holds
+	GetByUserID(ctx context.Context, userID int) ([]*entity.InventoryItem, error)
+
+	// AddItems adds the grants in one transaction. Nothing is added if any
+	// stack would exceed its MaxStack, in which case entity.ErrStackFull is
+	// returned.
+	AddItems(ctx context.Context, userID int, grants []*entity.ItemGrant) error
+
+	// ConsumeItem removes count of an item, reporting false without removing
+	// anything when the user holds fewer
+	ConsumeItem(ctx context.Context, userID, itemID, count int) (bool, error)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// MailRepository defines the interface for mailbox data access
+type MailRepository interface {
+	// Create stores a mail for one recipient and sets its ID
+	Create(ctx context.Context, mail *entity.Mail) error
+
+	// CreateBulk stores a copy of mail for every selected recipient in one
+	// statement and returns how many were stored
+	CreateBulk(ctx context.Context, mail *entity.Mail, recipients *entity.MailRecipients) (int, error)
+
+	// GetByUserID retrieves a user's unexpired mail, newest first
+	GetByUserID(ctx context.Context, userID, limit int) ([]*entity.Mail, error)
+
+	// GetByID retrieves one of a user's mails, or nil if there is none
+	GetByID(ctx context.Context, userID, mailID int) (*entity.Mail, error)
+
+	// MarkRead marks a mail read
+	MarkRead(ctx context.Context, userID, mailID int) error
+
+	// MarkClaimed marks a mail's attachments claimed if they were not
+	// claimed yet and the mail has not expired, reporting whether it did
+	MarkClaimed(ctx context.Context, userID, mailID int) (bool, error)
+
+	// UnmarkClaimed clears the claim of a mail's attachments
+	UnmarkClaimed(ctx context.Context, userID, mailID int) error
+
+	// Delete removes a mail unless it still has unclaimed attachments and
+	// has not expired, reporting whether it did
+	Delete(ctx context.Context, userID, mailID int) (bool, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// PrivacyRepository defines the interface for privacy settings data access
+type PrivacyRepository interface {
+	// GetByUserID retrieves a user's privacy settings, or nil if they never
+	// changed them
+	GetByUserID(ctx context.Context, userID int) (*entity.PrivacySettings, error)
+
+	// Save stores a user's privacy settings
+	Save(ctx context.Context, settings *entity.PrivacySettings) error
+}
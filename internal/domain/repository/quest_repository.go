@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// QuestRepository defines the interface for quest progress data access
+type QuestRepository interface {
+	// GetByUserID retrieves a user's progress on every quest they advanced
+	GetByUserID(ctx context.Context, userID int) ([]*entity.QuestProgress, error)
+
+	// AddProgress adds amount to a quest's progress in period, capped at
+	// target. Progress and claim from an earlier period are discarded first.
+	AddProgress(ctx context.Context, userID, questID, period, amount, target int) error
+
+	// RaiseProgress raises a quest's progress in period to value if that is
+	// higher, capped at target. Progress and claim from an earlier period
+	// are discarded first.
+	RaiseProgress(ctx context.Context, userID, questID, period, value, target int) error
+
+	// MarkClaimed marks a quest claimed if its progress in period reached
+	// target and it was not claimed yet, reporting whether it did
+	MarkClaimed(ctx context.Context, userID, questID, period, target int) (bool, error)
+
+	// UnmarkClaimed clears the claim of a quest in period
+	UnmarkClaimed(ctx context.Context, userID, questID, period int) error
+}
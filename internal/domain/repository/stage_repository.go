@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// StageRepository defines the interface for stage record data access
+type StageRepository interface {
+	// GetByUserID retrieves all stage records for a user, ordered by stage
+	GetByUserID(ctx context.Context, userID int) ([]*entity.StageRecord, error)
+
+	// RecordClear counts a clear of a stage, keeping the best time and stars,
+	// and reports whether it was the user's first clear of that stage
+	RecordClear(ctx context.Context, userID, stage, clearTime, stars int) (bool, error)
+
+	// GetByStage retrieves the record of one stage, or nil if never cleared
+	GetByStage(ctx context.Context, userID, stage int) (*entity.StageRecord, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"GameServer/internal/domain/entity"
+)
+
+// SuspicionRepository defines the interface for the anti-cheat suspicion log
+type SuspicionRepository interface {
+	// Record appends a rule violation to the suspicion log
+	Record(ctx context.Context, suspicion *entity.Suspicion) error
+
+	// CountSince counts the violations recorded for a user since the given time
+	CountSince(ctx context.Context, userID int, since time.Time) (int, error)
+
+	// FlagAccount flags a user for review and reports whether the account
+	// was not flagged before
+	FlagAccount(ctx context.Context, userID int, reason string) (bool, error)
+}
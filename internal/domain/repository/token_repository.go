@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"GameServer/internal/domain/entity"
+)
+
+// TokenRepository defines the interface for HTTP API bearer token storage,
+// shared by every server node
+type TokenRepository interface {
+	// Create stores a newly issued token
+	Create(ctx context.Context, token *entity.APIToken) error
+
+	// GetByHash retrieves a token by its hash, or nil if it does not exist
+	GetByHash(ctx context.Context, tokenHash string) (*entity.APIToken, error)
+
+	// Delete removes a token
+	Delete(ctx context.Context, tokenHash string) error
+
+	// DeleteExpired removes a user's tokens that expired before the given time
+	DeleteExpired(ctx context.Context, userID int, before time.Time) error
+}
@@ -1,77 +1,90 @@
 package repository
 
-import "GameServer/internal/domain/entity"
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	// User operations
-	GetByID(id int) (*entity.User, error)
-	GetByUsername(username string) (*entity.User, error)
-	Create(user *entity.User) error
-	Update(user *entity.User) error
-	Delete(id int) error
-	Exists(username string) (bool, error)
+	GetByID(ctx context.Context, id int) (*entity.User, error)
+	GetByUsername(ctx context.Context, username string) (*entity.User, error)
+	Create(ctx context.Context, user *entity.User) error
+	Update(ctx context.Context, user *entity.User) error
+	Delete(ctx context.Context, id int) error
+	Exists(ctx context.Context, username string) (bool, error)
 
 	// Authentication
-	VerifyCredentials(username, password string) (*entity.User, error)
+	VerifyCredentials(ctx context.Context, username, password string) (*entity.User, error)
 	
 	// Online status
-	UpdateOnlineStatus(userID int, status int) error
+	UpdateOnlineStatus(ctx context.Context, userID int, status int) error
 }
 
 // PlayerRepository defines the interface for player information data access
 type PlayerRepository interface {
-	GetByUserID(userID int) (*entity.PlayerInfo, error)
-	Create(player *entity.PlayerInfo) error
-	Update(player *entity.PlayerInfo) error
-	Delete(userID int) error
-	UpdateExperience(userID, experience int) error
-	UpdateLevel(userID, level int) error
-	UpdateBloodEnergy(userID, bloodEnergy int) error
+	GetByUserID(ctx context.Context, userID int) (*entity.PlayerInfo, error)
+	Create(ctx context.Context, player *entity.PlayerInfo) error
+	Update(ctx context.Context, player *entity.PlayerInfo) error
+	Delete(ctx context.Context, userID int) error
+	UpdateExperience(ctx context.Context, userID, experience int) error
+	UpdateLevel(ctx context.Context, userID, level int) error
+	UpdateBloodEnergy(ctx context.Context, userID, bloodEnergy int) error
+	// UpdateProgress sets level and experience only if they still hold the
+	// values read before, reporting false when another update got there first
+	UpdateProgress(ctx context.Context, userID, oldLevel, oldExperience, newLevel, newExperience int) (bool, error)
+	// UpdateEnergy writes the blood energy fields of player only if energy
+	// and its regeneration time still hold the values read before
+	UpdateEnergy(ctx context.Context, player *entity.PlayerInfo, oldEnergy int, oldUpdatedAt int64) (bool, error)
+	// AdvanceGameLevel moves the game level from one stage to the next,
+	// reporting false when it no longer holds the expected stage
+	AdvanceGameLevel(ctx context.Context, userID, fromStage int) (bool, error)
 }
 
 // FriendRepository defines the interface for friend data access
 type FriendRepository interface {
-	GetFriendsByUserID(userID int) ([]*entity.Friend, error)
-	GetFriendRequestsByUserID(userID int) ([]*entity.FriendRequest, error)
-	CreateFriendRequest(request *entity.FriendRequest) error
-	AcceptFriendRequest(requestID int) error
-	RejectFriendRequest(requestID int) error
-	RemoveFriend(fromUserID, toUserID int) error
-	AreFriends(userID1, userID2 int) (bool, error)
-	HasPendingRequest(fromUserID, toUserID int) (bool, error)
+	GetFriendsByUserID(ctx context.Context, userID int) ([]*entity.Friend, error)
+	GetFriendRequestsByUserID(ctx context.Context, userID int) ([]*entity.FriendRequest, error)
+	CreateFriendRequest(ctx context.Context, request *entity.FriendRequest) error
+	AcceptFriendRequest(ctx context.Context, requestID int) error
+	RejectFriendRequest(ctx context.Context, requestID int) error
+	RemoveFriend(ctx context.Context, fromUserID, toUserID int) error
+	AreFriends(ctx context.Context, userID1, userID2 int) (bool, error)
+	HasPendingRequest(ctx context.Context, fromUserID, toUserID int) (bool, error)
 }
 
 // RankingRepository defines the interface for ranking data access
 type RankingRepository interface {
-	GetRankingByType(rankType string, limit int) ([]*entity.Ranking, error)
-	UpdateUserRanking(userID int, rankType string, value int) error
-	GetUserRanking(userID int, rankType string) (*entity.Ranking, error)
-	RefreshRankings(rankType string) error
+	GetRankingByType(ctx context.Context, rankType string, limit int) ([]*entity.Ranking, error)
+	UpdateUserRanking(ctx context.Context, userID int, rankType string, value int) error
+	GetUserRanking(ctx context.Context, userID int, rankType string) (*entity.Ranking, error)
+	RefreshRankings(ctx context.Context, rankType string) error
 }
 
 // EquipmentRepository defines the interface for equipment data access
 type EquipmentRepository interface {
-	GetByUserID(userID int) ([]*entity.Equipment, error)
-	GetByEquipID(equipID int) (*entity.Equipment, error)
-	Create(equipment *entity.Equipment) error
-	Update(equipment *entity.Equipment) error
-	Delete(equipID int) error
-	GetUserEquipmentCount(userID int) (int, error)
+	GetByUserID(ctx context.Context, userID int) ([]*entity.Equipment, error)
+	GetByEquipID(ctx context.Context, equipID int) (*entity.Equipment, error)
+	Create(ctx context.Context, equipment *entity.Equipment) error
+	Update(ctx context.Context, equipment *entity.Equipment) error
+	Delete(ctx context.Context, equipID int) error
+	GetUserEquipmentCount(ctx context.Context, userID int) (int, error)
 }
 
 // SourceStoneRepository defines the interface for source stone data access
 type SourceStoneRepository interface {
-	GetByUserID(userID int) ([]*entity.SourceStone, error)
-	GetByEquipID(equipID int) (*entity.SourceStone, error)
-	Create(sourceStone *entity.SourceStone) error
-	Update(sourceStone *entity.SourceStone) error
-	Delete(equipID int) error
-	UpdateCount(equipID, count int) error
+	GetByUserID(ctx context.Context, userID int) ([]*entity.SourceStone, error)
+	GetByEquipID(ctx context.Context, equipID int) (*entity.SourceStone, error)
+	Create(ctx context.Context, sourceStone *entity.SourceStone) error
+	Update(ctx context.Context, sourceStone *entity.SourceStone) error
+	Delete(ctx context.Context, equipID int) error
+	UpdateCount(ctx context.Context, equipID, count int) error
 }
 
 // ExperienceRepository defines the interface for experience data access
 type ExperienceRepository interface {
-	GetByLevel(level int) (*entity.Experience, error)
-	GetAllLevels() ([]*entity.Experience, error)
+	GetByLevel(ctx context.Context, level int) (*entity.Experience, error)
+	GetAllLevels(ctx context.Context) ([]*entity.Experience, error)
 }
\ No newline at end of file
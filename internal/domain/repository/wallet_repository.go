@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// WalletRepository defines the interface for currency balances and their ledger
+type WalletRepository interface {
+	// GetBalances retrieves every balance a user holds
+	GetBalances(ctx context.Context, userID int) ([]*entity.CurrencyBalance, error)
+
+	// Apply appends the entries to the ledger and updates the balances in
+	// one transaction, filling in each entry's ID and BalanceAfter. Nothing
+	// is applied if any balance would become negative, in which case
+	// entity.ErrInsufficientBalance is returned.
+	Apply(ctx context.Context, entries []*entity.LedgerEntry) error
+
+	// GetHistory retrieves a user's ledger entries, newest first, with IDs
+	// below beforeID when it is positive. An empty currency matches all.
+	GetHistory(ctx context.Context, userID int, currency string, beforeID, limit int) ([]*entity.LedgerEntry, error)
+}
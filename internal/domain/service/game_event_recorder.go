@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"GameServer/internal/domain/entity"
+)
+
+// GameEventRecorder receives gameplay events, such as a stage clear, after
+// the change they describe has been stored
+type GameEventRecorder interface {
+	RecordEvent(ctx context.Context, event *entity.GameEvent)
+}
+
+// GameEventRecorders passes every event to each of its recorders in turn
+type GameEventRecorders []GameEventRecorder
+
+// RecordEvent records the event with every recorder
+func (r GameEventRecorders) RecordEvent(ctx context.Context, event *entity.GameEvent) {
+	for _, recorder := range r {
+		recorder.RecordEvent(ctx, event)
+	}
+}
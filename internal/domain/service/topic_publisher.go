@@ -0,0 +1,6 @@
+package service
+
+// TopicPublisher pushes events to the clients subscribed to a topic
+type TopicPublisher interface {
+	Publish(topic, event string, data interface{})
+}
@@ -0,0 +1,6 @@
+package service
+
+// UserNotifier pushes an event to a single user, wherever they are connected
+type UserNotifier interface {
+	NotifyUser(userID int, event string, data interface{})
+}
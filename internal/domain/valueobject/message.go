@@ -17,6 +17,30 @@ const (
 	MessageTypeFriend    MessageType = "friend"
 	MessageTypeRank      MessageType = "rank"
 	MessageTypeOnline    MessageType = "online"
+	MessageTypeEvent     MessageType = "event"
+	MessageTypeSession   MessageType = "session"
+	MessageTypeSub       MessageType = "sub"
+	MessageTypeWallet    MessageType = "wallet"
+	MessageTypeItem      MessageType = "item"
+	MessageTypeCheckIn   MessageType = "checkin"
+	MessageTypeQuest     MessageType = "quest"
+	MessageTypeMail      MessageType = "mail"
+)
+
+// Server push events
+const (
+	EventServerShutdown = "server:shutdown"
+	EventRankUpdated    = "rank:updated"
+	EventLevelUp        = "player:levelUp"
+	EventMailReceived   = "mail:new"
+	EventFriendPresence = "friend:presence"
+	EventAnnouncement   = "announcement"
+)
+
+// Topics services publish to besides the "rank:<type>" rankings
+const (
+	TopicAnnouncements  = "announcements"
+	TopicFriendPresence = "presence:friends" // Scoped to each subscriber's own friends
 )
 
 // MessageAction represents different actions within message types
@@ -45,8 +69,16 @@ const (
 	ActionGetEquippedBySlot MessageAction = "getEquippedBySlot"
 
 	// Player actions
-	ActionGetPlayerInfo MessageAction = "getPlayerInfo"
-	ActionUpdatePlayer  MessageAction = "updatePlayer"
+	ActionGetPlayerInfo  MessageAction = "getPlayerInfo"
+	ActionUpdatePlayer   MessageAction = "updatePlayer"
+	ActionGainExperience MessageAction = "gainExperience"
+	ActionConsumeEnergy  MessageAction = "consumeEnergy"
+	ActionBuyEnergy      MessageAction = "buyEnergy"
+	ActionGetStageMap    MessageAction = "getStageMap"
+	ActionCompleteStage  MessageAction = "completeStage"
+	ActionGetProfile     MessageAction = "getProfile"
+	ActionGetPrivacy     MessageAction = "getPrivacy"
+	ActionUpdatePrivacy  MessageAction = "updatePrivacy"
 
 	// Friend actions
 	ActionGetFriends       MessageAction = "getFriends"
@@ -62,6 +94,37 @@ const (
 
 	// Online actions
 	ActionGetOnlineUsers MessageAction = "getOnlineUsers"
+
+	// Session actions
+	ActionResume MessageAction = "resume"
+	ActionAck    MessageAction = "ack"
+
+	// Subscription actions
+	ActionSubscribe   MessageAction = "subscribe"
+	ActionUnsubscribe MessageAction = "unsubscribe"
+
+	// Wallet actions
+	ActionGetBalance MessageAction = "getBalance"
+	ActionGetHistory MessageAction = "getHistory"
+
+	// Item actions
+	ActionListItems MessageAction = "list"
+	ActionUseItem   MessageAction = "use"
+
+	// Check-in actions
+	ActionCheckInStatus MessageAction = "status"
+	ActionCheckInClaim  MessageAction = "claim"
+
+	// Quest actions
+	ActionListQuests MessageAction = "list"
+	ActionClaimQuest MessageAction = "claim"
+
+	// Mail actions
+	ActionListMail   MessageAction = "list"
+	ActionReadMail   MessageAction = "read"
+	ActionClaimMail  MessageAction = "claim"
+	ActionDeleteMail MessageAction = "delete"
+	ActionSendMail   MessageAction = "send"
 )
 
 // Message represents a WebSocket message
@@ -71,6 +134,8 @@ type Message struct {
 	Data      json.RawMessage `json:"data"`
 	RequestID string          `json:"requestId"`
 	Timestamp int64           `json:"timestamp"`
+	Nonce     string          `json:"nonce,omitempty"`
+	Signature string          `json:"signature,omitempty"`
 }
 
 // Response represents a WebSocket response
@@ -95,6 +160,8 @@ const (
 	CodeConflict       ResponseCode = 1005
 	CodeValidationError ResponseCode = 1006
 	CodeInternalError  ResponseCode = 5000
+	CodeUnavailable    ResponseCode = 5003
+	CodeTimeout        ResponseCode = 5004
 )
 
 // NewSuccessResponse creates a success response
@@ -126,6 +193,35 @@ func (r *Response) ToJSON() ([]byte, error) {
 	return json.Marshal(r)
 }
 
+// Event represents a message pushed by the server without a client request.
+// Events sent to a single user carry a per-user sequence number so a
+// reconnecting client can ask for the ones it missed; events published to a
+// topic carry the topic name.
+type Event struct {
+	Type      MessageType `json:"type"`
+	Event     string      `json:"event"`
+	Topic     string      `json:"topic,omitempty"`
+	Seq       uint64      `json:"seq,omitempty"`
+	Epoch     string      `json:"epoch,omitempty"` // Identifies the queue Seq belongs to
+	Data      interface{} `json:"data"`
+	Timestamp int64       `json:"timestamp"`
+}
+
+// NewEvent creates a server push event
+func NewEvent(event string, data interface{}) *Event {
+	return &Event{
+		Type:      MessageTypeEvent,
+		Event:     event,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+}
+
+// ToJSON converts event to JSON bytes
+func (e *Event) ToJSON() ([]byte, error) {
+	return json.Marshal(e)
+}
+
 // ParseMessage parses JSON bytes to Message
 func ParseMessage(data []byte) (*Message, error) {
 	var msg Message
@@ -0,0 +1,84 @@
+package backplane
+
+import (
+	"context"
+	"fmt"
+
+	"GameServer/internal/infrastructure/config"
+)
+
+// Subscriber receives frames that other nodes route to this node
+type Subscriber interface {
+	// DeliverToUser delivers a frame to a user connected to this node
+	DeliverToUser(userID int, payload []byte)
+
+	// DeliverBroadcast delivers a frame to every client connected to this node
+	DeliverBroadcast(payload []byte)
+
+	// DeliverToTopic delivers a frame to the clients on this node subscribed to topic
+	DeliverToTopic(topic string, payload []byte)
+
+	// DeliverDisconnect closes the user's connection on this node with an
+	// application close code
+	DeliverDisconnect(userID int, code int)
+}
+
+// Backplane connects the hubs of several server nodes so user routing,
+// broadcasts and presence work no matter which node a client is connected to
+type Backplane interface {
+	// NodeID returns the identifier of the local node
+	NodeID() string
+
+	// Subscribe starts delivering frames addressed to this node
+	Subscribe(subscriber Subscriber) error
+
+	// PublishToUser routes a frame to the node holding the user's session.
+	// It reports false when the user is not online on any node.
+	PublishToUser(ctx context.Context, userID int, payload []byte) (bool, error)
+
+	// PublishBroadcast fans a frame out to every other node
+	PublishBroadcast(ctx context.Context, payload []byte) error
+
+	// PublishTopic fans a frame for a topic out to every other node
+	PublishTopic(ctx context.Context, topic string, payload []byte) error
+
+	// PublishDisconnect asks a node to close the user's connection there
+	// with an application close code
+	PublishDisconnect(ctx context.Context, nodeID string, userID int, code int) error
+
+	// SetPresence records that the user is connected to this node
+	SetPresence(ctx context.Context, userID int) error
+
+	// ClearPresence removes the user's presence if it still points at this node
+	ClearPresence(ctx context.Context, userID int) error
+
+	// LookupPresence returns the node the user is connected to
+	LookupPresence(ctx context.Context, userID int) (nodeID string, online bool, err error)
+
+	// OnlineUsers returns the IDs of users connected to any node
+	OnlineUsers(ctx context.Context) ([]int, error)
+
+	// Close stops delivery and releases the presence held by this node
+	Close() error
+}
+
+// envelope is the wire format for frames exchanged between nodes
+type envelope struct {
+	Origin  string `json:"origin"`
+	UserID  int    `json:"userId,omitempty"`
+	Topic   string `json:"topic,omitempty"`
+	Close   int    `json:"close,omitempty"` // Close code of a disconnect request
+	Payload []byte `json:"payload"`
+}
+
+// New creates the backplane selected by configuration
+func New(cfg config.BackplaneConfig) (Backplane, error) {
+	switch cfg.Driver {
+	case config.BackplaneDriverMemory:
+		return NewMemoryBackplane(NewMemoryBus(), cfg.NodeID), nil
+	case config.BackplaneDriverRedis:
+		return NewRedisBackplane(cfg.RedisAddr, cfg.RedisPassword, cfg.Prefix, cfg.NodeID, cfg.NodeTTL)
+	default:
+		return nil, fmt.Errorf("unknown backplane driver: %s", cfg.Driver)
+	}
+}
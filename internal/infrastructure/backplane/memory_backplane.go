@@ -0,0 +1,157 @@
+package backplane
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryBus is an in-process message bus shared by memory backplanes. A
+// single-node server uses one bus with one node; several hubs in the same
+// process can share a bus to behave like separate nodes.
+type MemoryBus struct {
+	mu       sync.RWMutex
+	nodes    map[string]Subscriber
+	presence map[int]string
+}
+
+// NewMemoryBus creates an empty in-process bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		nodes:    make(map[string]Subscriber),
+		presence: make(map[int]string),
+	}
+}
+
+// memoryBackplane implements Backplane on top of a MemoryBus
+type memoryBackplane struct {
+	bus    *MemoryBus
+	nodeID string
+}
+
+// NewMemoryBackplane creates a backplane node attached to the given bus
+func NewMemoryBackplane(bus *MemoryBus, nodeID string) Backplane {
+	return &memoryBackplane{bus: bus, nodeID: nodeID}
+}
+
+// NodeID returns the identifier of the local node
+func (b *memoryBackplane) NodeID() string {
+	return b.nodeID
+}
+
+// Subscribe starts delivering frames addressed to this node
+func (b *memoryBackplane) Subscribe(subscriber Subscriber) error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+	b.bus.nodes[b.nodeID] = subscriber
+	return nil
+}
+
+// PublishToUser routes a frame to the node holding the user's session
+func (b *memoryBackplane) PublishToUser(ctx context.Context, userID int, payload []byte) (bool, error) {
+	b.bus.mu.RLock()
+	nodeID, online := b.bus.presence[userID]
+	subscriber := b.bus.nodes[nodeID]
+	b.bus.mu.RUnlock()
+
+	if !online || subscriber == nil {
+		return false, nil
+	}
+	subscriber.DeliverToUser(userID, payload)
+	return true, nil
+}
+
+// PublishBroadcast fans a frame out to every other node
+func (b *memoryBackplane) PublishBroadcast(ctx context.Context, payload []byte) error {
+	for _, subscriber := range b.otherNodes() {
+		subscriber.DeliverBroadcast(payload)
+	}
+	return nil
+}
+
+// PublishTopic fans a frame for a topic out to every other node
+func (b *memoryBackplane) PublishTopic(ctx context.Context, topic string, payload []byte) error {
+	for _, subscriber := range b.otherNodes() {
+		subscriber.DeliverToTopic(topic, payload)
+	}
+	return nil
+}
+
+// PublishDisconnect asks a node to close the user's connection there
+func (b *memoryBackplane) PublishDisconnect(ctx context.Context, nodeID string, userID int, code int) error {
+	b.bus.mu.RLock()
+	subscriber := b.bus.nodes[nodeID]
+	b.bus.mu.RUnlock()
+
+	if subscriber != nil {
+		subscriber.DeliverDisconnect(userID, code)
+	}
+	return nil
+}
+
+// otherNodes returns the subscribers of every node but this one
+func (b *memoryBackplane) otherNodes() []Subscriber {
+	b.bus.mu.RLock()
+	defer b.bus.mu.RUnlock()
+
+	subscribers := make([]Subscriber, 0, len(b.bus.nodes))
+	for nodeID, subscriber := range b.bus.nodes {
+		if nodeID != b.nodeID {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	return subscribers
+}
+
+// SetPresence records that the user is connected to this node
+func (b *memoryBackplane) SetPresence(ctx context.Context, userID int) error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+	b.bus.presence[userID] = b.nodeID
+	return nil
+}
+
+// ClearPresence removes the user's presence if it still points at this node
+func (b *memoryBackplane) ClearPresence(ctx context.Context, userID int) error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+	if b.bus.presence[userID] == b.nodeID {
+		delete(b.bus.presence, userID)
+	}
+	return nil
+}
+
+// LookupPresence returns the node the user is connected to
+func (b *memoryBackplane) LookupPresence(ctx context.Context, userID int) (string, bool, error) {
+	b.bus.mu.RLock()
+	defer b.bus.mu.RUnlock()
+	nodeID, online := b.bus.presence[userID]
+	return nodeID, online, nil
+}
+
+// OnlineUsers returns the IDs of users connected to any node
+func (b *memoryBackplane) OnlineUsers(ctx context.Context) ([]int, error) {
+	b.bus.mu.RLock()
+	defer b.bus.mu.RUnlock()
+
+	users := make([]int, 0, len(b.bus.presence))
+	for userID := range b.bus.presence {
+		users = append(users, userID)
+	}
+	sort.Ints(users)
+	return users, nil
+}
+
+// Close detaches the node from the bus and releases its presence
+func (b *memoryBackplane) Close() error {
+	b.bus.mu.Lock()
+	defer b.bus.mu.Unlock()
+
+	delete(b.bus.nodes, b.nodeID)
+	for userID, nodeID := range b.bus.presence {
+		if nodeID == b.nodeID {
+			delete(b.bus.presence, userID)
+		}
+	}
+	return nil
+}
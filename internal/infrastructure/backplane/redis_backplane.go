@@ -0,0 +1,427 @@
+package backplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	redisDialTimeout    = 5 * time.Second
+	redisCommandTimeout = 5 * time.Second
+	redisRetryDelay     = time.Second
+)
+
+// redisBackplane implements Backplane over Redis pub/sub. Presence is kept in
+// a hash mapping user IDs to node IDs; each node subscribes to its own channel
+// for user-routed frames and to a shared channel for broadcasts.
+//
+// Every node refreshes a liveness key that expires after nodeTTL. Presence
+// entries of a node whose key expired, because it crashed, are ignored; they
+// stay in the hash until the user logs in again elsewhere or the node
+// restarts with the same ID, which purges them.
+type redisBackplane struct {
+	addr     string
+	password string
+	prefix   string
+	nodeID   string
+	nodeTTL  time.Duration
+
+	mu  sync.Mutex // Serializes commands on cmd
+	cmd *respConn  // Command connection, redialed after I/O errors
+
+	subMu sync.Mutex
+	sub   *respConn // Subscription connection
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRedisBackplane connects to a Redis-protocol server, purges presence
+// left behind by a previous run of the same node and starts heartbeating so
+// other nodes see this one as alive for nodeTTL after each refresh
+func NewRedisBackplane(addr, password, prefix, nodeID string, nodeTTL time.Duration) (Backplane, error) {
+	b := &redisBackplane{
+		addr:     addr,
+		password: password,
+		prefix:   prefix,
+		nodeID:   nodeID,
+		nodeTTL:  nodeTTL,
+		closed:   make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+	defer cancel()
+
+	if _, err := b.do(ctx, "PING"); err != nil {
+		return nil, fmt.Errorf("failed to connect to backplane at %s: %w", addr, err)
+	}
+	if err := b.purgePresence(ctx); err != nil {
+		return nil, fmt.Errorf("failed to purge stale presence: %w", err)
+	}
+	if err := b.heartbeat(ctx); err != nil {
+		return nil, fmt.Errorf("failed to register node: %w", err)
+	}
+	go b.heartbeatLoop()
+
+	return b, nil
+}
+
+// NodeID returns the identifier of the local node
+func (b *redisBackplane) NodeID() string {
+	return b.nodeID
+}
+
+// Subscribe starts delivering frames addressed to this node
+func (b *redisBackplane) Subscribe(subscriber Subscriber) error {
+	go b.subscribeLoop(subscriber)
+	return nil
+}
+
+// PublishToUser routes a frame to the node holding the user's session
+func (b *redisBackplane) PublishToUser(ctx context.Context, userID int, payload []byte) (bool, error) {
+	nodeID, online, err := b.LookupPresence(ctx, userID)
+	if err != nil || !online {
+		return false, err
+	}
+
+	data, err := json.Marshal(envelope{Origin: b.nodeID, UserID: userID, Payload: payload})
+	if err != nil {
+		return false, err
+	}
+
+	reply, err := b.do(ctx, "PUBLISH", b.nodeChannel(nodeID), string(data))
+	if err != nil {
+		return false, err
+	}
+	receivers, _ := reply.(int64)
+	return receivers > 0, nil
+}
+
+// PublishBroadcast fans a frame out to every other node
+func (b *redisBackplane) PublishBroadcast(ctx context.Context, payload []byte) error {
+	data, err := json.Marshal(envelope{Origin: b.nodeID, Payload: payload})
+	if err != nil {
+		return err
+	}
+	_, err = b.do(ctx, "PUBLISH", b.broadcastChannel(), string(data))
+	return err
+}
+
+// PublishTopic fans a frame for a topic out to every other node. Topic
+// frames share the broadcast channel and are told apart by the envelope.
+func (b *redisBackplane) PublishTopic(ctx context.Context, topic string, payload []byte) error {
+	data, err := json.Marshal(envelope{Origin: b.nodeID, Topic: topic, Payload: payload})
+	if err != nil {
+		return err
+	}
+	_, err = b.do(ctx, "PUBLISH", b.broadcastChannel(), string(data))
+	return err
+}
+
+// PublishDisconnect asks a node to close the user's connection there. The
+// request travels on the node's own channel like user-routed frames.
+func (b *redisBackplane) PublishDisconnect(ctx context.Context, nodeID string, userID int, code int) error {
+	data, err := json.Marshal(envelope{Origin: b.nodeID, UserID: userID, Close: code})
+	if err != nil {
+		return err
+	}
+	_, err = b.do(ctx, "PUBLISH", b.nodeChannel(nodeID), string(data))
+	return err
+}
+
+// SetPresence records that the user is connected to this node
+func (b *redisBackplane) SetPresence(ctx context.Context, userID int) error {
+	_, err := b.do(ctx, "HSET", b.presenceKey(), strconv.Itoa(userID), b.nodeID)
+	return err
+}
+
+// ClearPresence removes the user's presence if it still points at this node
+func (b *redisBackplane) ClearPresence(ctx context.Context, userID int) error {
+	nodeID, online, err := b.LookupPresence(ctx, userID)
+	if err != nil || !online || nodeID != b.nodeID {
+		return err
+	}
+	_, err = b.do(ctx, "HDEL", b.presenceKey(), strconv.Itoa(userID))
+	return err
+}
+
+// LookupPresence returns the node the user is connected to. Presence held
+// by a node that stopped heartbeating counts as offline.
+func (b *redisBackplane) LookupPresence(ctx context.Context, userID int) (string, bool, error) {
+	reply, err := b.do(ctx, "HGET", b.presenceKey(), strconv.Itoa(userID))
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	nodeID, ok := replyString(reply)
+	if !ok {
+		return "", false, nil
+	}
+
+	alive, err := b.nodeAlive(ctx, nodeID)
+	if err != nil || !alive {
+		return "", false, err
+	}
+	return nodeID, true, nil
+}
+
+// OnlineUsers returns the IDs of users connected to any live node
+func (b *redisBackplane) OnlineUsers(ctx context.Context) ([]int, error) {
+	presence, err := b.allPresence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alive := make(map[string]bool)
+	users := make([]int, 0, len(presence))
+	for userID, nodeID := range presence {
+		nodeAlive, checked := alive[nodeID]
+		if !checked {
+			if nodeAlive, err = b.nodeAlive(ctx, nodeID); err != nil {
+				return nil, err
+			}
+			alive[nodeID] = nodeAlive
+		}
+		if nodeAlive {
+			users = append(users, userID)
+		}
+	}
+	sort.Ints(users)
+	return users, nil
+}
+
+// Close stops delivery and releases the presence held by this node
+func (b *redisBackplane) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.closed)
+
+		ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+		defer cancel()
+		err = b.purgePresence(ctx)
+		if _, delErr := b.do(ctx, "DEL", b.aliveKey(b.nodeID)); err == nil {
+			err = delErr
+		}
+
+		b.subMu.Lock()
+		if b.sub != nil {
+			b.sub.Close()
+		}
+		b.subMu.Unlock()
+
+		b.mu.Lock()
+		if b.cmd != nil {
+			b.cmd.Close()
+			b.cmd = nil
+		}
+		b.mu.Unlock()
+	})
+	return err
+}
+
+// do runs a command on the shared connection, redialing after I/O errors
+func (b *redisBackplane) do(ctx context.Context, args ...string) (interface{}, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cmd == nil {
+		conn, err := dialRESP(b.addr, b.password, redisDialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		b.cmd = conn
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisCommandTimeout)
+	}
+	b.cmd.conn.SetDeadline(deadline)
+
+	reply, err := b.cmd.do(args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		b.cmd.Close()
+		b.cmd = nil
+	}
+	return reply, err
+}
+
+// allPresence returns the whole presence hash
+func (b *redisBackplane) allPresence(ctx context.Context) (map[int]string, error) {
+	reply, err := b.do(ctx, "HGETALL", b.presenceKey())
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := reply.([]interface{})
+	presence := make(map[int]string, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		field, _ := replyString(items[i])
+		nodeID, _ := replyString(items[i+1])
+		if userID, err := strconv.Atoi(field); err == nil {
+			presence[userID] = nodeID
+		}
+	}
+	return presence, nil
+}
+
+// purgePresence removes every presence entry owned by this node
+func (b *redisBackplane) purgePresence(ctx context.Context) error {
+	presence, err := b.allPresence(ctx)
+	if err != nil {
+		return err
+	}
+
+	for userID, nodeID := range presence {
+		if nodeID != b.nodeID {
+			continue
+		}
+		if _, err := b.do(ctx, "HDEL", b.presenceKey(), strconv.Itoa(userID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heartbeat marks this node alive for another nodeTTL
+func (b *redisBackplane) heartbeat(ctx context.Context) error {
+	_, err := b.do(ctx, "SET", b.aliveKey(b.nodeID), "1", "PX", strconv.FormatInt(b.nodeTTL.Milliseconds(), 10))
+	return err
+}
+
+// heartbeatLoop refreshes the liveness key until Close, often enough that a
+// couple of failed refreshes do not let it expire
+func (b *redisBackplane) heartbeatLoop() {
+	ticker := time.NewTicker(b.nodeTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.closed:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), redisCommandTimeout)
+		if err := b.heartbeat(ctx); err != nil {
+			log.Printf("Backplane heartbeat failed: %v", err)
+		}
+		cancel()
+	}
+}
+
+// nodeAlive reports whether a node refreshed its liveness key within nodeTTL
+func (b *redisBackplane) nodeAlive(ctx context.Context, nodeID string) (bool, error) {
+	if nodeID == b.nodeID {
+		return true, nil
+	}
+	reply, err := b.do(ctx, "EXISTS", b.aliveKey(nodeID))
+	if err != nil {
+		return false, err
+	}
+	count, _ := reply.(int64)
+	return count > 0, nil
+}
+
+// subscribeLoop keeps a subscription open until Close, reconnecting on errors
+func (b *redisBackplane) subscribeLoop(subscriber Subscriber) {
+	for {
+		err := b.subscribeOnce(subscriber)
+
+		select {
+		case <-b.closed:
+			return
+		default:
+		}
+
+		log.Printf("Backplane subscription lost: %v, retrying in %s", err, redisRetryDelay)
+		select {
+		case <-b.closed:
+			return
+		case <-time.After(redisRetryDelay):
+		}
+	}
+}
+
+// subscribeOnce subscribes and delivers messages until the connection fails
+func (b *redisBackplane) subscribeOnce(subscriber Subscriber) error {
+	conn, err := dialRESP(b.addr, b.password, redisDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	b.subMu.Lock()
+	b.sub = conn
+	b.subMu.Unlock()
+
+	// Close may have run while dialing
+	select {
+	case <-b.closed:
+		return nil
+	default:
+	}
+
+	nodeChannel := b.nodeChannel(b.nodeID)
+	if err := conn.send("SUBSCRIBE", nodeChannel, b.broadcastChannel()); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := conn.readReply()
+		if err != nil {
+			return err
+		}
+
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 3 {
+			continue
+		}
+		if kind, _ := replyString(items[0]); kind != "message" {
+			continue
+		}
+		channel, _ := replyString(items[1])
+		data, _ := items[2].([]byte)
+
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			log.Printf("Backplane dropped malformed message on %s: %v", channel, err)
+			continue
+		}
+
+		switch {
+		case channel == nodeChannel && env.Close != 0:
+			subscriber.DeliverDisconnect(env.UserID, env.Close)
+		case channel == nodeChannel:
+			subscriber.DeliverToUser(env.UserID, env.Payload)
+		case env.Origin == b.nodeID:
+		case env.Topic != "":
+			subscriber.DeliverToTopic(env.Topic, env.Payload)
+		default:
+			subscriber.DeliverBroadcast(env.Payload)
+		}
+	}
+}
+
+func (b *redisBackplane) presenceKey() string {
+	return b.prefix + ":presence"
+}
+
+func (b *redisBackplane) nodeChannel(nodeID string) string {
+	return b.prefix + ":node:" + nodeID
+}
+
+func (b *redisBackplane) aliveKey(nodeID string) string {
+	return b.prefix + ":alive:" + nodeID
+}
+
+func (b *redisBackplane) broadcastChannel() string {
+	return b.prefix + ":broadcast"
+}
@@ -0,0 +1,584 @@
+package backplane
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// respServer is an in-process stand-in for a Redis server. It speaks enough
+// RESP2 for the backplane: PING, AUTH, PUBLISH, SUBSCRIBE, the hash commands
+// used for presence and SET/EXISTS/DEL with PX expiry for node liveness.
+type respServer struct {
+	listener net.Listener
+	password string
+
+	mu          sync.Mutex
+	hashes      map[string]map[string]string
+	keys        map[string]time.Time // Key to expiry; zero means no expiry
+	subscribers map[string]map[*respServerConn]struct{}
+	conns       map[*respServerConn]struct{}
+}
+
+type respServerConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	authed  bool
+}
+
+func newRESPServer(t *testing.T, password string) *respServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &respServer{
+		listener:    listener,
+		password:    password,
+		hashes:      make(map[string]map[string]string),
+		keys:        make(map[string]time.Time),
+		subscribers: make(map[string]map[*respServerConn]struct{}),
+		conns:       make(map[*respServerConn]struct{}),
+	}
+	go s.serve()
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *respServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *respServer) close() {
+	s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		c.conn.Close()
+	}
+}
+
+// subscriberCount returns how many connections are subscribed to channel
+func (s *respServer) subscriberCount(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers[channel])
+}
+
+// hashLen returns the number of fields in a hash
+func (s *respServer) hashLen(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.hashes[key])
+}
+
+func (s *respServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &respServerConn{conn: conn, authed: s.password == ""}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		go s.handle(c)
+	}
+}
+
+func (s *respServer) handle(c *respServerConn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		for _, subscribers := range s.subscribers {
+			delete(subscribers, c)
+		}
+		s.mu.Unlock()
+		c.conn.Close()
+	}()
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		c.write(s.execute(c, args))
+	}
+}
+
+func (c *respServerConn) write(reply string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	io.WriteString(c.conn, reply)
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func bulk(value string) string {
+	return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+}
+
+func integer(n int) string {
+	return ":" + strconv.Itoa(n) + "\r\n"
+}
+
+// execute runs one command and returns the encoded reply
+func (s *respServer) execute(c *respServerConn, args []string) string {
+	command := strings.ToUpper(args[0])
+	if command == "AUTH" {
+		if len(args) == 2 && args[1] == s.password {
+			c.authed = true
+			return "+OK\r\n"
+		}
+		return "-WRONGPASS invalid password\r\n"
+	}
+	if !c.authed {
+		return "-NOAUTH Authentication required.\r\n"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireKeys()
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "PUBLISH":
+		message := "*3\r\n" + bulk("message") + bulk(args[1]) + bulk(args[2])
+		for subscriber := range s.subscribers[args[1]] {
+			go subscriber.write(message)
+		}
+		return integer(len(s.subscribers[args[1]]))
+	case "SUBSCRIBE":
+		var reply strings.Builder
+		for i, channel := range args[1:] {
+			if s.subscribers[channel] == nil {
+				s.subscribers[channel] = make(map[*respServerConn]struct{})
+			}
+			s.subscribers[channel][c] = struct{}{}
+			reply.WriteString("*3\r\n" + bulk("subscribe") + bulk(channel) + integer(i+1))
+		}
+		return reply.String()
+	case "HSET":
+		hash := s.hashes[args[1]]
+		if hash == nil {
+			hash = make(map[string]string)
+			s.hashes[args[1]] = hash
+		}
+		_, existed := hash[args[2]]
+		hash[args[2]] = args[3]
+		if existed {
+			return integer(0)
+		}
+		return integer(1)
+	case "HGET":
+		value, ok := s.hashes[args[1]][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "HDEL":
+		deleted := 0
+		for _, field := range args[2:] {
+			if _, ok := s.hashes[args[1]][field]; ok {
+				delete(s.hashes[args[1]], field)
+				deleted++
+			}
+		}
+		return integer(deleted)
+	case "HGETALL":
+		hash := s.hashes[args[1]]
+		reply := "*" + strconv.Itoa(len(hash)*2) + "\r\n"
+		for field, value := range hash {
+			reply += bulk(field) + bulk(value)
+		}
+		return reply
+	case "SET":
+		var expiry time.Time
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			ms, err := strconv.Atoi(args[4])
+			if err != nil {
+				return "-ERR value is not an integer\r\n"
+			}
+			expiry = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.keys[args[1]] = expiry
+		return "+OK\r\n"
+	case "EXISTS", "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := s.keys[key]; ok {
+				n++
+				if command == "DEL" {
+					delete(s.keys, key)
+				}
+			}
+		}
+		return integer(n)
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// expireKeys drops keys whose expiry passed; s.mu must be held
+func (s *respServer) expireKeys() {
+	now := time.Now()
+	for key, expiry := range s.keys {
+		if !expiry.IsZero() && now.After(expiry) {
+			delete(s.keys, key)
+		}
+	}
+}
+
+// recordingSubscriber collects frames delivered to a node
+type recordingSubscriber struct {
+	users      chan string
+	broadcasts chan string
+	topics     chan string
+	kicks      chan string
+}
+
+func newRecordingSubscriber() *recordingSubscriber {
+	return &recordingSubscriber{
+		users:      make(chan string, 16),
+		broadcasts: make(chan string, 16),
+		topics:     make(chan string, 16),
+		kicks:      make(chan string, 16),
+	}
+}
+
+func (r *recordingSubscriber) DeliverToUser(userID int, payload []byte) {
+	r.users <- fmt.Sprintf("%d:%s", userID, payload)
+}
+
+func (r *recordingSubscriber) DeliverBroadcast(payload []byte) {
+	r.broadcasts <- string(payload)
+}
+
+func (r *recordingSubscriber) DeliverToTopic(topic string, payload []byte) {
+	r.topics <- topic + ":" + string(payload)
+}
+
+func (r *recordingSubscriber) DeliverDisconnect(userID int, code int) {
+	r.kicks <- fmt.Sprintf("%d:%d", userID, code)
+}
+
+// expect waits for one frame on ch
+func expect(t *testing.T, ch chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Errorf("delivered %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("%q was not delivered", want)
+	}
+}
+
+// expectNothing checks that no frame arrives on ch for a short while
+func expectNothing(t *testing.T, ch chan string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		t.Errorf("unexpected delivery %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// testNode is a backplane node attached to a stand-in server
+type testNode struct {
+	*redisBackplane
+	received *recordingSubscriber
+}
+
+func newTestNode(t *testing.T, server *respServer, nodeID string, nodeTTL time.Duration) *testNode {
+	t.Helper()
+
+	bp, err := NewRedisBackplane(server.addr(), server.password, "test", nodeID, nodeTTL)
+	if err != nil {
+		t.Fatalf("connect node %s: %v", nodeID, err)
+	}
+	node := &testNode{redisBackplane: bp.(*redisBackplane), received: newRecordingSubscriber()}
+	t.Cleanup(func() { node.Close() })
+
+	if err := node.Subscribe(node.received); err != nil {
+		t.Fatalf("subscribe node %s: %v", nodeID, err)
+	}
+	waitFor(t, func() bool { return server.subscriberCount(node.nodeChannel(nodeID)) == 1 })
+	return node
+}
+
+// crash stops a node's heartbeat and drops its connections without
+// releasing its presence, as if the process died
+func (n *testNode) crash() {
+	n.closeOnce.Do(func() {
+		close(n.closed)
+		n.subMu.Lock()
+		n.sub.Close()
+		n.subMu.Unlock()
+		n.mu.Lock()
+		n.cmd.Close()
+		n.cmd = nil
+		n.mu.Unlock()
+	})
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRedisBackplaneRouting(t *testing.T) {
+	ctx := context.Background()
+	server := newRESPServer(t, "secret")
+	a := newTestNode(t, server, "a", time.Minute)
+	b := newTestNode(t, server, "b", time.Minute)
+
+	if err := b.SetPresence(ctx, 42); err != nil {
+		t.Fatalf("SetPresence: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		publish   func() (bool, error)
+		wantOK    bool
+		toB, toA  chan string
+		wantFrame string
+	}{
+		{
+			name:      "user on another node",
+			publish:   func() (bool, error) { return a.PublishToUser(ctx, 42, []byte("hello")) },
+			wantOK:    true,
+			toB:       b.received.users,
+			toA:       a.received.users,
+			wantFrame: "42:hello",
+		},
+		{
+			name:    "user offline",
+			publish: func() (bool, error) { return a.PublishToUser(ctx, 43, []byte("hello")) },
+			wantOK:  false,
+			toB:     b.received.users,
+			toA:     a.received.users,
+		},
+		{
+			name:      "broadcast skips the origin",
+			publish:   func() (bool, error) { return true, a.PublishBroadcast(ctx, []byte("news")) },
+			wantOK:    true,
+			toB:       b.received.broadcasts,
+			toA:       a.received.broadcasts,
+			wantFrame: "news",
+		},
+		{
+			name:      "topic frame",
+			publish:   func() (bool, error) { return true, a.PublishTopic(ctx, "guild:1", []byte("raid")) },
+			wantOK:    true,
+			toB:       b.received.topics,
+			toA:       a.received.topics,
+			wantFrame: "guild:1:raid",
+		},
+		{
+			name:      "disconnect request",
+			publish:   func() (bool, error) { return true, a.PublishDisconnect(ctx, "b", 42, 4000) },
+			wantOK:    true,
+			toB:       b.received.kicks,
+			toA:       a.received.kicks,
+			wantFrame: "42:4000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := tt.publish()
+			if err != nil {
+				t.Fatalf("publish: %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("publish reported %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantFrame != "" {
+				expect(t, tt.toB, tt.wantFrame)
+			} else {
+				expectNothing(t, tt.toB)
+			}
+			expectNothing(t, tt.toA)
+		})
+	}
+}
+
+func TestRedisBackplanePresence(t *testing.T) {
+	ctx := context.Background()
+	server := newRESPServer(t, "")
+	a := newTestNode(t, server, "a", time.Minute)
+	b := newTestNode(t, server, "b", time.Minute)
+
+	steps := []struct {
+		name       string
+		action     func() error
+		lookup     int
+		wantNode   string
+		wantOnline []int
+	}{
+		{
+			name:       "set on a",
+			action:     func() error { return a.SetPresence(ctx, 1) },
+			lookup:     1,
+			wantNode:   "a",
+			wantOnline: []int{1},
+		},
+		{
+			name:       "user moves to b",
+			action:     func() error { return b.SetPresence(ctx, 1) },
+			lookup:     1,
+			wantNode:   "b",
+			wantOnline: []int{1},
+		},
+		{
+			name:       "stale clear from a is ignored",
+			action:     func() error { return a.ClearPresence(ctx, 1) },
+			lookup:     1,
+			wantNode:   "b",
+			wantOnline: []int{1},
+		},
+		{
+			name:       "second user on a",
+			action:     func() error { return a.SetPresence(ctx, 2) },
+			lookup:     2,
+			wantNode:   "a",
+			wantOnline: []int{1, 2},
+		},
+		{
+			name:       "clear from b",
+			action:     func() error { return b.ClearPresence(ctx, 1) },
+			lookup:     1,
+			wantOnline: []int{2},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.action(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		nodeID, online, err := a.LookupPresence(ctx, step.lookup)
+		if err != nil {
+			t.Fatalf("%s: LookupPresence: %v", step.name, err)
+		}
+		if online != (step.wantNode != "") || nodeID != step.wantNode {
+			t.Errorf("%s: user %d on %q (online %v), want %q", step.name, step.lookup, nodeID, online, step.wantNode)
+		}
+		users, err := b.OnlineUsers(ctx)
+		if err != nil {
+			t.Fatalf("%s: OnlineUsers: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(users, step.wantOnline) {
+			t.Errorf("%s: online users %v, want %v", step.name, users, step.wantOnline)
+		}
+	}
+}
+
+func TestRedisBackplaneNodeDeath(t *testing.T) {
+	ctx := context.Background()
+	server := newRESPServer(t, "")
+	const nodeTTL = 150 * time.Millisecond
+	a := newTestNode(t, server, "a", nodeTTL)
+	b := newTestNode(t, server, "b", nodeTTL)
+
+	for userID, node := range map[int]*testNode{1: a, 2: b} {
+		if err := node.SetPresence(ctx, userID); err != nil {
+			t.Fatalf("SetPresence: %v", err)
+		}
+	}
+
+	// Heartbeats keep a live node's presence past its TTL
+	time.Sleep(2 * nodeTTL)
+	if users, err := a.OnlineUsers(ctx); err != nil || !reflect.DeepEqual(users, []int{1, 2}) {
+		t.Fatalf("online users before crash %v (%v), want [1 2]", users, err)
+	}
+
+	b.crash()
+	waitFor(t, func() bool {
+		_, online, err := a.LookupPresence(ctx, 2)
+		return err == nil && !online
+	})
+
+	if users, err := a.OnlineUsers(ctx); err != nil || !reflect.DeepEqual(users, []int{1}) {
+		t.Errorf("online users after crash %v (%v), want [1]", users, err)
+	}
+	if delivered, err := a.PublishToUser(ctx, 2, []byte("lost")); err != nil || delivered {
+		t.Errorf("PublishToUser to a dead node = %v (%v), want false", delivered, err)
+	}
+
+	// The user logging in again on a live node takes over the stale entry
+	if err := a.SetPresence(ctx, 2); err != nil {
+		t.Fatalf("SetPresence: %v", err)
+	}
+	if nodeID, online, err := a.LookupPresence(ctx, 2); err != nil || !online || nodeID != "a" {
+		t.Errorf("user 2 on %q (online %v, %v), want a", nodeID, online, err)
+	}
+
+	// A node shutting down cleanly releases its presence right away
+	b2 := newTestNode(t, server, "b", nodeTTL)
+	if err := b2.SetPresence(ctx, 3); err != nil {
+		t.Fatalf("SetPresence: %v", err)
+	}
+	if err := b2.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if users, err := a.OnlineUsers(ctx); err != nil || !reflect.DeepEqual(users, []int{1, 2}) {
+		t.Errorf("online users after restart %v (%v), want [1 2]", users, err)
+	}
+	if n := server.hashLen("test:presence"); n != 2 {
+		t.Errorf("presence hash holds %d entries, want 2", n)
+	}
+}
+
+func TestRedisBackplaneAuth(t *testing.T) {
+	server := newRESPServer(t, "secret")
+	if _, err := NewRedisBackplane(server.addr(), "wrong", "test", "a", time.Minute); err == nil {
+		t.Error("connected with a wrong password")
+	}
+}
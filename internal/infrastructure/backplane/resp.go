@@ -0,0 +1,150 @@
+package backplane
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// redisError is an error reply returned by the server. Unlike I/O errors it
+// leaves the connection usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// respConn is a minimal client for the Redis serialization protocol (RESP2).
+// It only supports what the backplane needs, so any server speaking the
+// protocol, including a local stand-in, can back it.
+type respConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialRESP connects to addr and authenticates when a password is given
+func dialRESP(addr, password string, timeout time.Duration) (*respConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &respConn{conn: conn, reader: bufio.NewReader(conn)}
+	if password != "" {
+		conn.SetDeadline(time.Now().Add(timeout))
+		if _, err := c.do("AUTH", password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+		conn.SetDeadline(time.Time{})
+	}
+	return c, nil
+}
+
+// do sends a command and waits for its reply
+func (c *respConn) do(args ...string) (interface{}, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// send writes a command as an array of bulk strings
+func (c *respConn) send(args ...string) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// readReply reads one reply. Simple strings are returned as string, integers
+// as int64, bulk strings as []byte, arrays as []interface{} and nil replies as nil.
+func (c *respConn) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// readLine reads a CRLF-terminated line without the terminator
+func (c *respConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// Close closes the underlying connection
+func (c *respConn) Close() error {
+	return c.conn.Close()
+}
+
+// replyString converts a bulk or simple string reply to string
+func replyString(reply interface{}) (string, bool) {
+	switch v := reply.(type) {
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	default:
+		return "", false
+	}
+}
@@ -17,6 +17,9 @@ type Config struct {
 	Logging   LoggingConfig   `json:"logging"`
 	Cache     CacheConfig     `json:"cache"`
 	RateLimit RateLimitConfig `json:"rate_limit"`
+	Backplane BackplaneConfig `json:"backplane"`
+	Network   NetworkConfig   `json:"network"`
+	Gameplay  GameplayConfig  `json:"gameplay"`
 }
 
 // DatabaseConfig holds database configuration
@@ -34,8 +37,15 @@ type DatabaseConfig struct {
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Host string `json:"host"`
-	Port int    `json:"port"`
+	Host            string        `json:"host"`
+	Port            int           `json:"port"`
+	ReadTimeout     time.Duration `json:"read_timeout"`
+	WriteTimeout    time.Duration `json:"write_timeout"`
+	IdleTimeout     time.Duration `json:"idle_timeout"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+	// ReconnectDelay is the hint sent to clients telling them how long to
+	// wait before reconnecting when the server shuts down
+	ReconnectDelay time.Duration `json:"reconnect_delay"`
 }
 
 // WebSocketConfig holds WebSocket configuration
@@ -44,13 +54,78 @@ type WebSocketConfig struct {
 	ReadBufferSize   int           `json:"read_buffer_size"`
 	WriteBufferSize  int           `json:"write_buffer_size"`
 	HandshakeTimeout time.Duration `json:"handshake_timeout"`
+	// MaxInFlightRequests bounds how many read-only requests a single client
+	// may have processing concurrently
+	MaxInFlightRequests int `json:"max_inflight_requests"`
+	// RequestTimeout is the default deadline for handling a single message
+	RequestTimeout time.Duration `json:"request_timeout"`
+	// ActionTimeouts overrides RequestTimeout for specific "type:action" keys
+	ActionTimeouts map[string]time.Duration `json:"action_timeouts"`
+	// SendBufferSize is the number of outbound frames queued per client
+	SendBufferSize int `json:"send_buffer_size"`
+	// SlowConsumerPolicy decides what happens when a client's send buffer is full
+	SlowConsumerPolicy string `json:"slow_consumer_policy"`
+	// EventQueueSize is the number of events kept per user for replay
+	EventQueueSize int `json:"event_queue_size"`
+	// EventRetention is how long events are kept for replay after being sent
+	EventRetention time.Duration `json:"event_retention"`
+	// MaxConnections caps open connections on this node; 0 means unlimited
+	MaxConnections int `json:"max_connections"`
+	// MaxConnectionsPerIP caps open connections from one address; 0 means unlimited
+	MaxConnectionsPerIP int `json:"max_connections_per_ip"`
+	// LoginTimeout is how long a connection may stay unauthenticated; 0 disables it
+	LoginTimeout time.Duration `json:"login_timeout"`
+	// SignedActions lists "type:action" keys that must carry an HMAC
+	// signature made with the session key issued at login; empty disables signing
+	SignedActions []string `json:"signed_actions"`
+	// SignatureMaxSkew is how far a signed message's timestamp may be from server time
+	SignatureMaxSkew time.Duration `json:"signature_max_skew"`
+	// MaxSubscriptions bounds how many topics a single client may subscribe to
+	MaxSubscriptions int `json:"max_subscriptions"`
+	// PingInterval is how often the server sends a WebSocket ping frame
+	PingInterval time.Duration `json:"ping_interval"`
+	// PongTimeout is how long a connection may go without sending any frame,
+	// including pongs, before it is considered dead; must exceed PingInterval
+	PongTimeout time.Duration `json:"pong_timeout"`
+	// IdleTimeout disconnects authenticated clients that send nothing but
+	// heartbeats for this long; 0 disables it
+	IdleTimeout time.Duration `json:"idle_timeout"`
+}
+
+// Slow consumer policies
+const (
+	// SlowConsumerDropOldest discards the oldest queued frame to make room
+	SlowConsumerDropOldest = "drop_oldest"
+	// SlowConsumerDisconnect closes the connection of a client that cannot keep up
+	SlowConsumerDisconnect = "disconnect"
+)
+
+// RequiresSignature reports whether a message type and action must be signed
+func (c WebSocketConfig) RequiresSignature(msgType, action string) bool {
+	return contains(c.SignedActions, msgType+":"+action)
+}
+
+// TimeoutFor returns the handling deadline for a message type and action
+func (c WebSocketConfig) TimeoutFor(msgType, action string) time.Duration {
+	if timeout, ok := c.ActionTimeouts[msgType+":"+action]; ok {
+		return timeout
+	}
+	return c.RequestTimeout
 }
 
 // SecurityConfig holds security configuration
 type SecurityConfig struct {
 	BcryptCost int `json:"bcrypt_cost"`
+	// APITokenTTL is how long bearer tokens for the HTTP API stay valid
+	APITokenTTL time.Duration `json:"api_token_ttl"`
+	// AdminTokens maps each operator to the bearer token they use for the
+	// /admin/ API; with none configured the admin API refuses every request
+	AdminTokens map[string]string `json:"-"`
 }
 
+// minAdminTokenLength is the shortest admin token accepted
+const minAdminTokenLength = 32
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level  string `json:"level"`
@@ -69,6 +144,90 @@ type RateLimitConfig struct {
 	CleanupInterval   time.Duration `json:"cleanup_interval"`
 }
 
+// BackplaneConfig holds the configuration of the pub/sub backplane that
+// connects several server nodes
+type BackplaneConfig struct {
+	Driver        string `json:"driver"`
+	NodeID        string `json:"node_id"`
+	RedisAddr     string `json:"redis_addr"`
+	RedisPassword string `json:"-"`
+	Prefix        string `json:"prefix"`
+	// NodeTTL is how long a node counts as alive after its last heartbeat;
+	// presence held by a node that stopped heartbeating is ignored
+	NodeTTL time.Duration `json:"node_ttl"`
+}
+
+// Backplane drivers
+const (
+	// BackplaneDriverMemory keeps routing in process, for single-node deployments
+	BackplaneDriverMemory = "memory"
+	// BackplaneDriverRedis routes through a Redis-protocol server shared by all nodes
+	BackplaneDriverRedis = "redis"
+)
+
+// NetworkConfig holds the network access policy. Lists hold CIDRs or bare
+// addresses; deny wins over allow and an empty allow list allows everyone.
+type NetworkConfig struct {
+	// Client rules apply to /ws, /sse and the HTTP API
+	ClientAllow []string `json:"client_allow"`
+	ClientDeny  []string `json:"client_deny"`
+	// Admin rules apply to /metrics and /admin/
+	AdminAllow []string `json:"admin_allow"`
+	AdminDeny  []string `json:"admin_deny"`
+	// TrustedProxies are the peers whose X-Forwarded-For header is honored
+	TrustedProxies []string `json:"trusted_proxies"`
+	// PolicyFile optionally names a JSON file with the same lists. It
+	// replaces the lists above and is re-read on SIGHUP.
+	PolicyFile string `json:"policy_file"`
+}
+
+// GameplayConfig holds the game rules the server enforces
+type GameplayConfig struct {
+	// ExperienceSources maps each source allowed to grant experience to the
+	// most experience a single grant from it may award
+	ExperienceSources map[string]int `json:"experience_sources"`
+	// MaxExperiencePerMinute caps the experience a player may gain in one
+	// minute across all sources
+	MaxExperiencePerMinute int `json:"max_experience_per_minute"`
+	// MaxBloodEnergy is the most blood energy a player may hold
+	MaxBloodEnergy int `json:"max_blood_energy"`
+	// EnergyRegenPerMinute is the blood energy regenerated each minute
+	// while below MaxBloodEnergy
+	EnergyRegenPerMinute int `json:"energy_regen_per_minute"`
+	// EnergyPurchaseAmount is the blood energy one purchase adds, and
+	// EnergyPurchasesPerDay how many purchases a player may make per UTC day
+	EnergyPurchaseAmount  int `json:"energy_purchase_amount"`
+	EnergyPurchasesPerDay int `json:"energy_purchases_per_day"`
+	// StageCount is the number of stages in the stage map
+	StageCount int `json:"stage_count"`
+	// StageFirstClearExperience and StageRepeatExperience are awarded for
+	// the first and every later clear of a stage
+	StageFirstClearExperience int `json:"stage_first_clear_experience"`
+	StageRepeatExperience     int `json:"stage_repeat_experience"`
+	// StageMinClearTime is the fastest clear accepted as legitimate
+	StageMinClearTime time.Duration `json:"stage_min_clear_time"`
+	// Currencies lists the wallet currencies: gold, gems and any extras
+	// from GAME_EXTRA_CURRENCIES
+	Currencies []string `json:"currencies"`
+	// ItemsFile is the JSON file with the item definitions
+	ItemsFile string `json:"items_file"`
+	// CheckInFile is the JSON file with the daily check-in reward calendar
+	CheckInFile string `json:"checkin_file"`
+	// QuestsFile is the JSON file with the quest and achievement definitions
+	QuestsFile string `json:"quests_file"`
+	// StagesFile is the JSON file with the stage clear rewards
+	StagesFile string `json:"stages_file"`
+	// MailTTL is how long mail stays in a mailbox unless sent with an
+	// explicit expiry
+	MailTTL time.Duration `json:"mail_ttl"`
+	// SuspicionThreshold is how many anti-cheat violations within
+	// SuspicionWindow flag an account for review
+	SuspicionThreshold int           `json:"suspicion_threshold"`
+	SuspicionWindow    time.Duration `json:"suspicion_window"`
+	// RankingRefreshInterval is how often ranking positions are recalculated
+	RankingRefreshInterval time.Duration `json:"ranking_refresh_interval"`
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	config := &Config{
@@ -84,17 +243,40 @@ func Load() (*Config, error) {
 			ConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", "60s"),
 		},
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnvInt("SERVER_PORT", 8080),
+			Host:            getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:            getEnvInt("SERVER_PORT", 8080),
+			ReadTimeout:     getEnvDuration("SERVER_READ_TIMEOUT", "15s"),
+			WriteTimeout:    getEnvDuration("SERVER_WRITE_TIMEOUT", "15s"),
+			IdleTimeout:     getEnvDuration("SERVER_IDLE_TIMEOUT", "60s"),
+			ShutdownTimeout: getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", "30s"),
+			ReconnectDelay:  getEnvDuration("SERVER_RECONNECT_DELAY", "5s"),
 		},
 		WebSocket: WebSocketConfig{
-			AllowedOrigins:   getEnvStringArray("WS_ALLOWED_ORIGINS", []string{"*"}),
-			ReadBufferSize:   getEnvInt("WS_READ_BUFFER_SIZE", 1024),
-			WriteBufferSize:  getEnvInt("WS_WRITE_BUFFER_SIZE", 1024),
-			HandshakeTimeout: getEnvDuration("WS_HANDSHAKE_TIMEOUT", "10s"),
+			AllowedOrigins:      getEnvStringArray("WS_ALLOWED_ORIGINS", []string{"*"}),
+			ReadBufferSize:      getEnvInt("WS_READ_BUFFER_SIZE", 1024),
+			WriteBufferSize:     getEnvInt("WS_WRITE_BUFFER_SIZE", 1024),
+			HandshakeTimeout:    getEnvDuration("WS_HANDSHAKE_TIMEOUT", "10s"),
+			MaxInFlightRequests: getEnvInt("WS_MAX_INFLIGHT_REQUESTS", 8),
+			RequestTimeout:      getEnvDuration("WS_REQUEST_TIMEOUT", "10s"),
+			ActionTimeouts:      getEnvDurationMap("WS_ACTION_TIMEOUTS"),
+			SendBufferSize:      getEnvInt("WS_SEND_BUFFER_SIZE", 256),
+			SlowConsumerPolicy:  getEnv("WS_SLOW_CONSUMER_POLICY", SlowConsumerDisconnect),
+			EventQueueSize:      getEnvInt("WS_EVENT_QUEUE_SIZE", 100),
+			EventRetention:      getEnvDuration("WS_EVENT_RETENTION", "5m"),
+			MaxConnections:      getEnvInt("WS_MAX_CONNECTIONS", 10000),
+			MaxConnectionsPerIP: getEnvInt("WS_MAX_CONNECTIONS_PER_IP", 20),
+			LoginTimeout:        getEnvDuration("WS_LOGIN_TIMEOUT", "30s"),
+			SignedActions:       getEnvStringArray("WS_SIGNED_ACTIONS", nil),
+			SignatureMaxSkew:    getEnvDuration("WS_SIGNATURE_MAX_SKEW", "30s"),
+			MaxSubscriptions:    getEnvInt("WS_MAX_SUBSCRIPTIONS", 32),
+			PingInterval:        getEnvDuration("WS_PING_INTERVAL", "54s"),
+			PongTimeout:         getEnvDuration("WS_PONG_TIMEOUT", "60s"),
+			IdleTimeout:         getEnvDuration("WS_IDLE_TIMEOUT", "30m"),
 		},
 		Security: SecurityConfig{
-			BcryptCost: getEnvInt("BCRYPT_COST", 12),
+			BcryptCost:  getEnvInt("BCRYPT_COST", 12),
+			APITokenTTL: getEnvDuration("API_TOKEN_TTL", "24h"),
+			AdminTokens: getEnvStringMap("ADMIN_TOKENS"),
 		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -108,6 +290,43 @@ func Load() (*Config, error) {
 			RequestsPerMinute: getEnvInt("RATE_LIMIT_RPM", 60),
 			CleanupInterval:   getEnvDuration("RATE_LIMIT_CLEANUP", "1m"),
 		},
+		Network: NetworkConfig{
+			ClientAllow:    getEnvStringArray("NET_CLIENT_ALLOW", nil),
+			ClientDeny:     getEnvStringArray("NET_CLIENT_DENY", nil),
+			AdminAllow:     getEnvStringArray("NET_ADMIN_ALLOW", []string{"127.0.0.1", "::1"}),
+			AdminDeny:      getEnvStringArray("NET_ADMIN_DENY", nil),
+			TrustedProxies: getEnvStringArray("NET_TRUSTED_PROXIES", nil),
+			PolicyFile:     getEnv("NET_POLICY_FILE", ""),
+		},
+		Backplane: BackplaneConfig{
+			Driver:        getEnv("BACKPLANE_DRIVER", BackplaneDriverMemory),
+			NodeID:        getEnv("NODE_ID", defaultNodeID()),
+			RedisAddr:     getEnv("BACKPLANE_REDIS_ADDR", "127.0.0.1:6379"),
+			RedisPassword: getEnv("BACKPLANE_REDIS_PASSWORD", ""),
+			Prefix:        getEnv("BACKPLANE_PREFIX", "gameserver"),
+			NodeTTL:       getEnvDuration("BACKPLANE_NODE_TTL", "15s"),
+		},
+		Gameplay: GameplayConfig{
+			ExperienceSources:         getEnvIntMap("GAME_XP_SOURCES", "battle=500,stage=1000,quest=2000"),
+			MaxExperiencePerMinute:    getEnvInt("GAME_MAX_XP_PER_MINUTE", 3000),
+			MaxBloodEnergy:            getEnvInt("GAME_MAX_BLOOD_ENERGY", 100),
+			EnergyRegenPerMinute:      getEnvInt("GAME_ENERGY_REGEN_PER_MINUTE", 1),
+			EnergyPurchaseAmount:      getEnvInt("GAME_ENERGY_PURCHASE_AMOUNT", 50),
+			EnergyPurchasesPerDay:     getEnvInt("GAME_ENERGY_PURCHASES_PER_DAY", 3),
+			StageCount:                getEnvInt("GAME_STAGE_COUNT", 100),
+			StageFirstClearExperience: getEnvInt("GAME_STAGE_FIRST_CLEAR_XP", 500),
+			StageRepeatExperience:     getEnvInt("GAME_STAGE_REPEAT_XP", 100),
+			StageMinClearTime:         getEnvDuration("GAME_STAGE_MIN_CLEAR_TIME", "10s"),
+			Currencies:                append([]string{"gold", "gems"}, getEnvStringArray("GAME_EXTRA_CURRENCIES", nil)...),
+			ItemsFile:                 getEnv("GAME_ITEMS_FILE", "configs/items.json"),
+			CheckInFile:               getEnv("GAME_CHECKIN_FILE", "configs/checkin.json"),
+			QuestsFile:                getEnv("GAME_QUESTS_FILE", "configs/quests.json"),
+			StagesFile:                getEnv("GAME_STAGES_FILE", "configs/stages.json"),
+			MailTTL:                   getEnvDuration("GAME_MAIL_TTL", "720h"),
+			SuspicionThreshold:        getEnvInt("GAME_SUSPICION_THRESHOLD", 5),
+			SuspicionWindow:           getEnvDuration("GAME_SUSPICION_WINDOW", "24h"),
+			RankingRefreshInterval:    getEnvDuration("GAME_RANKING_REFRESH_INTERVAL", "5m"),
+		},
 	}
 
 	// Validate configuration
@@ -138,11 +357,148 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("server port must be between 1 and 65535")
 	}
+	if c.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("server shutdown timeout must be positive")
+	}
+
+	// WebSocket validation
+	if c.WebSocket.MaxInFlightRequests <= 0 {
+		return fmt.Errorf("websocket max in-flight requests must be positive")
+	}
+	if c.WebSocket.RequestTimeout <= 0 {
+		return fmt.Errorf("websocket request timeout must be positive")
+	}
+	for key, timeout := range c.WebSocket.ActionTimeouts {
+		if timeout <= 0 {
+			return fmt.Errorf("websocket timeout for %s must be positive", key)
+		}
+	}
+
+	if c.WebSocket.SendBufferSize <= 0 {
+		return fmt.Errorf("websocket send buffer size must be positive")
+	}
+	validPolicies := []string{SlowConsumerDropOldest, SlowConsumerDisconnect}
+	if !contains(validPolicies, c.WebSocket.SlowConsumerPolicy) {
+		return fmt.Errorf("slow consumer policy must be one of: %s", strings.Join(validPolicies, ", "))
+	}
+	if c.WebSocket.EventQueueSize <= 0 {
+		return fmt.Errorf("websocket event queue size must be positive")
+	}
+	if c.WebSocket.EventRetention <= 0 {
+		return fmt.Errorf("websocket event retention must be positive")
+	}
+	if c.WebSocket.MaxConnections < 0 || c.WebSocket.MaxConnectionsPerIP < 0 {
+		return fmt.Errorf("websocket connection caps must not be negative")
+	}
+	if c.WebSocket.LoginTimeout < 0 {
+		return fmt.Errorf("websocket login timeout must not be negative")
+	}
+	if len(c.WebSocket.SignedActions) > 0 && c.WebSocket.SignatureMaxSkew <= 0 {
+		return fmt.Errorf("websocket signature max skew must be positive")
+	}
+	if c.WebSocket.MaxSubscriptions <= 0 {
+		return fmt.Errorf("websocket max subscriptions must be positive")
+	}
+	if c.WebSocket.PingInterval <= 0 {
+		return fmt.Errorf("websocket ping interval must be positive")
+	}
+	if c.WebSocket.PongTimeout <= c.WebSocket.PingInterval {
+		return fmt.Errorf("websocket pong timeout must be longer than the ping interval")
+	}
+	if c.WebSocket.IdleTimeout < 0 {
+		return fmt.Errorf("websocket idle timeout must not be negative")
+	}
 
 	// Security validation
 	if c.Security.BcryptCost < 4 || c.Security.BcryptCost > 31 {
 		return fmt.Errorf("bcrypt cost must be between 4 and 31")
 	}
+	if c.Security.APITokenTTL <= 0 {
+		return fmt.Errorf("api token ttl must be positive")
+	}
+	for operator, token := range c.Security.AdminTokens {
+		if operator == "" || len(token) < minAdminTokenLength {
+			return fmt.Errorf("admin tokens need an operator name and at least %d characters", minAdminTokenLength)
+		}
+	}
+
+	// Backplane validation
+	validDrivers := []string{BackplaneDriverMemory, BackplaneDriverRedis}
+	if !contains(validDrivers, c.Backplane.Driver) {
+		return fmt.Errorf("backplane driver must be one of: %s", strings.Join(validDrivers, ", "))
+	}
+	if c.Backplane.NodeID == "" {
+		return fmt.Errorf("node id is required (set NODE_ID environment variable)")
+	}
+	if c.Backplane.NodeTTL <= 0 {
+		return fmt.Errorf("backplane node ttl must be positive")
+	}
+
+	// Gameplay validation
+	if len(c.Gameplay.ExperienceSources) == 0 {
+		return fmt.Errorf("at least one experience source is required (set GAME_XP_SOURCES)")
+	}
+	for source, limit := range c.Gameplay.ExperienceSources {
+		if limit <= 0 {
+			return fmt.Errorf("experience limit for %s must be positive", source)
+		}
+	}
+	if c.Gameplay.MaxExperiencePerMinute <= 0 {
+		return fmt.Errorf("max experience per minute must be positive")
+	}
+	if c.Gameplay.MaxBloodEnergy <= 0 {
+		return fmt.Errorf("max blood energy must be positive")
+	}
+	if c.Gameplay.EnergyRegenPerMinute < 0 {
+		return fmt.Errorf("energy regeneration per minute cannot be negative")
+	}
+	if c.Gameplay.EnergyPurchaseAmount <= 0 {
+		return fmt.Errorf("energy purchase amount must be positive")
+	}
+	if c.Gameplay.EnergyPurchasesPerDay < 0 {
+		return fmt.Errorf("energy purchases per day cannot be negative")
+	}
+	if c.Gameplay.StageCount <= 0 {
+		return fmt.Errorf("stage count must be positive")
+	}
+	if c.Gameplay.StageFirstClearExperience < 0 || c.Gameplay.StageRepeatExperience < 0 {
+		return fmt.Errorf("stage experience rewards cannot be negative")
+	}
+	if c.Gameplay.StageMinClearTime < 0 {
+		return fmt.Errorf("stage minimum clear time cannot be negative")
+	}
+	if c.Gameplay.ItemsFile == "" {
+		return fmt.Errorf("items file is required (set GAME_ITEMS_FILE)")
+	}
+	if c.Gameplay.CheckInFile == "" {
+		return fmt.Errorf("check-in file is required (set GAME_CHECKIN_FILE)")
+	}
+	if c.Gameplay.QuestsFile == "" {
+		return fmt.Errorf("quests file is required (set GAME_QUESTS_FILE)")
+	}
+	if c.Gameplay.StagesFile == "" {
+		return fmt.Errorf("stages file is required (set GAME_STAGES_FILE)")
+	}
+	if c.Gameplay.MailTTL <= 0 {
+		return fmt.Errorf("mail TTL must be positive")
+	}
+	for i, currency := range c.Gameplay.Currencies {
+		if !isCurrencyName(currency) {
+			return fmt.Errorf("invalid currency name %q: use up to 32 lowercase letters, digits and underscores", currency)
+		}
+		if contains(c.Gameplay.Currencies[:i], currency) {
+			return fmt.Errorf("currency %s is configured twice", currency)
+		}
+	}
+	if c.Gameplay.SuspicionThreshold <= 0 {
+		return fmt.Errorf("suspicion threshold must be positive")
+	}
+	if c.Gameplay.SuspicionWindow <= 0 {
+		return fmt.Errorf("suspicion window must be positive")
+	}
+	if c.Gameplay.RankingRefreshInterval <= 0 {
+		return fmt.Errorf("ranking refresh interval must be positive")
+	}
 
 	// Logging validation
 	validLogLevels := []string{"debug", "info", "warn", "error"}
@@ -209,6 +565,91 @@ func getEnvStringArray(key string, fallback []string) []string {
 	return fallback
 }
 
+// getEnvDurationMap parses "key=duration" pairs separated by commas,
+// e.g. "friend:getFriends=5s,rank:getAllRank=3s". Malformed pairs are skipped.
+func getEnvDurationMap(key string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		if duration, err := time.ParseDuration(strings.TrimSpace(raw)); err == nil {
+			result[strings.TrimSpace(name)] = duration
+		}
+	}
+	return result
+}
+
+// getEnvStringMap parses "key=value" pairs separated by commas, e.g.
+// "alice=token1,bob=token2". Values may contain "="; malformed pairs are
+// skipped.
+func getEnvStringMap(key string) map[string]string {
+	result := make(map[string]string)
+	value := os.Getenv(key)
+	if value == "" {
+		return result
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		result[strings.TrimSpace(name)] = strings.TrimSpace(raw)
+	}
+	return result
+}
+
+// getEnvIntMap parses "key=int" pairs separated by commas, e.g.
+// "battle=500,quest=2000", falling back to the same format in fallback.
+// Malformed pairs are skipped.
+func getEnvIntMap(key, fallback string) map[string]int {
+	value := os.Getenv(key)
+	if value == "" {
+		value = fallback
+	}
+
+	result := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		name, raw, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil {
+			result[strings.TrimSpace(name)] = n
+		}
+	}
+	return result
+}
+
+// defaultNodeID identifies the node by hostname so restarts reuse the same ID
+func defaultNodeID() string {
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return ""
+}
+
+// isCurrencyName reports whether name is a lowercase identifier that fits
+// the wallet tables
+func isCurrencyName(name string) bool {
+	if name == "" || len(name) > 32 || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
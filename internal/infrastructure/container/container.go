@@ -1,14 +1,17 @@
 package container
 
 import (
-	"database/sql"
 	"GameServer/internal/application/service"
 	"GameServer/internal/domain/repository"
 	domainService "GameServer/internal/domain/service"
+	"GameServer/internal/infrastructure/backplane"
 	"GameServer/internal/infrastructure/cache"
 	"GameServer/internal/infrastructure/config"
+	"GameServer/internal/infrastructure/gamedata"
 	infraRepo "GameServer/internal/infrastructure/repository"
 	"GameServer/internal/interfaces/websocket"
+	"database/sql"
+	"log"
 )
 
 // Container holds all application dependencies
@@ -16,6 +19,9 @@ type Container struct {
 	Config   *config.Config
 	Database *sql.DB
 	
+	// Backplane connecting the hubs of all server nodes
+	Backplane backplane.Backplane
+	
 	// Services
 	CacheService     cache.CacheService
 	AuthService      *service.AuthService
@@ -23,6 +29,12 @@ type Container struct {
 	FriendService    *service.FriendService
 	RankingService   *service.RankingService
 	UserEquipService *service.UserEquipService
+	WalletService    *service.WalletService
+	InventoryService *service.InventoryService
+	CheckInService   *service.CheckInService
+	QuestService     *service.QuestService
+	MailService      *service.MailService
+	ProfileService   *service.ProfileService
 	
 	// Repositories
 	UserRepo        repository.UserRepository
@@ -33,6 +45,16 @@ type Container struct {
 	SourceStoneRepo repository.SourceStoneRepository
 	ExperienceRepo  repository.ExperienceRepository
 	UserEquipRepo   repository.UserEquipRepository
+	SuspicionRepo   repository.SuspicionRepository
+	XPRateRepo      repository.ExperienceRateRepository
+	StageRepo       repository.StageRepository
+	WalletRepo      repository.WalletRepository
+	InventoryRepo   repository.InventoryRepository
+	CheckInRepo     repository.CheckInRepository
+	QuestRepo       repository.QuestRepository
+	MailRepo        repository.MailRepository
+	PrivacyRepo     repository.PrivacyRepository
+	TokenRepo       repository.TokenRepository
 	
 	// Domain Services
 	AuthDomainService domainService.AuthDomainService
@@ -54,6 +76,12 @@ func NewContainer(cfg *config.Config, db *sql.DB) (*Container, error) {
 		return nil, err
 	}
 	
+	bp, err := backplane.New(cfg.Backplane)
+	if err != nil {
+		return nil, err
+	}
+	container.Backplane = bp
+	
 	return container, nil
 }
 
@@ -67,6 +95,16 @@ func (c *Container) initializeRepositories() error {
 	c.SourceStoneRepo = infraRepo.NewMySQLSourceStoneRepository(c.Database)
 	c.ExperienceRepo = infraRepo.NewMySQLExperienceRepository(c.Database)
 	c.UserEquipRepo = infraRepo.NewMySQLUserEquipRepository(c.Database)
+	c.SuspicionRepo = infraRepo.NewMySQLSuspicionRepository(c.Database)
+	c.XPRateRepo = infraRepo.NewMySQLExperienceRateRepository(c.Database)
+	c.StageRepo = infraRepo.NewMySQLStageRepository(c.Database)
+	c.WalletRepo = infraRepo.NewMySQLWalletRepository(c.Database)
+	c.InventoryRepo = infraRepo.NewMySQLInventoryRepository(c.Database)
+	c.CheckInRepo = infraRepo.NewMySQLCheckInRepository(c.Database)
+	c.QuestRepo = infraRepo.NewMySQLQuestRepository(c.Database)
+	c.MailRepo = infraRepo.NewMySQLMailRepository(c.Database)
+	c.PrivacyRepo = infraRepo.NewMySQLPrivacyRepository(c.Database)
+	c.TokenRepo = infraRepo.NewMySQLTokenRepository(c.Database)
 	
 	return nil
 }
@@ -83,27 +121,50 @@ func (c *Container) initializeServices() error {
 	c.AuthService = service.NewAuthService(
 		c.UserRepo,
 		c.PlayerRepo,
+		c.CheckInRepo,
+		c.TokenRepo,
 		c.AuthDomainService,
 		c.CacheService,
+		c.Config.Security.APITokenTTL,
 	)
 	
 	c.PlayerService = service.NewPlayerService(
 		c.PlayerRepo,
 		c.EquipmentRepo,
 		c.SourceStoneRepo,
+		c.ExperienceRepo,
+		c.SuspicionRepo,
+		c.XPRateRepo,
+		c.StageRepo,
 		c.CacheService,
+		service.PlayerRules{
+			ExperienceSources:         c.Config.Gameplay.ExperienceSources,
+			MaxExperiencePerMinute:    c.Config.Gameplay.MaxExperiencePerMinute,
+			MaxBloodEnergy:            c.Config.Gameplay.MaxBloodEnergy,
+			EnergyRegenPerMinute:      c.Config.Gameplay.EnergyRegenPerMinute,
+			EnergyPurchaseAmount:      c.Config.Gameplay.EnergyPurchaseAmount,
+			EnergyPurchasesPerDay:     c.Config.Gameplay.EnergyPurchasesPerDay,
+			StageCount:                c.Config.Gameplay.StageCount,
+			StageFirstClearExperience: c.Config.Gameplay.StageFirstClearExperience,
+			StageRepeatExperience:     c.Config.Gameplay.StageRepeatExperience,
+			StageMinClearTime:         c.Config.Gameplay.StageMinClearTime,
+			SuspicionThreshold:        c.Config.Gameplay.SuspicionThreshold,
+			SuspicionWindow:           c.Config.Gameplay.SuspicionWindow,
+		},
 	)
 	
 	c.FriendService = service.NewFriendService(
 		c.FriendRepo,
 		c.UserRepo,
 		c.PlayerRepo,
+		c.PrivacyRepo,
 	)
 	
 	c.RankingService = service.NewRankingService(
 		c.RankingRepo,
 		c.UserRepo,
 		c.PlayerRepo,
+		c.PrivacyRepo,
 	)
 	
 	c.UserEquipService = service.NewUserEquipService(
@@ -112,6 +173,73 @@ func (c *Container) initializeServices() error {
 		c.UserRepo,
 	)
 	
+	c.WalletService = service.NewWalletService(
+		c.WalletRepo,
+		c.Config.Gameplay.Currencies,
+	)
+	
+	items, err := gamedata.LoadItems(c.Config.Gameplay.ItemsFile)
+	if err != nil {
+		return err
+	}
+	c.InventoryService = service.NewInventoryService(
+		c.InventoryRepo,
+		c.PlayerService,
+		items,
+	)
+	
+	calendar, err := gamedata.LoadCheckInCalendar(c.Config.Gameplay.CheckInFile, c.Config.Gameplay.Currencies, items)
+	if err != nil {
+		return err
+	}
+	c.CheckInService = service.NewCheckInService(
+		c.CheckInRepo,
+		c.WalletService,
+		c.InventoryService,
+		calendar,
+	)
+	
+	quests, err := gamedata.LoadQuests(c.Config.Gameplay.QuestsFile, c.Config.Gameplay.Currencies, items)
+	if err != nil {
+		return err
+	}
+	c.QuestService = service.NewQuestService(
+		c.QuestRepo,
+		c.WalletService,
+		c.InventoryService,
+		quests,
+	)
+	
+	stageRewards, err := gamedata.LoadStageRewards(c.Config.Gameplay.StagesFile, c.Config.Gameplay.Currencies, items)
+	if err != nil {
+		return err
+	}
+	c.PlayerService.SetStageRewards(stageRewards, c.WalletService, c.InventoryService)
+	
+	// Let gameplay events advance quests, and level ups update rankings
+	c.PlayerService.SetEventRecorder(domainService.GameEventRecorders{c.QuestService, c.RankingService})
+	c.UserEquipService.SetEventRecorder(c.QuestService)
+	c.FriendService.SetEventRecorder(c.QuestService)
+	
+	c.MailService = service.NewMailService(
+		c.MailRepo,
+		c.UserRepo,
+		c.FriendRepo,
+		c.PlayerService,
+		c.WalletService,
+		c.InventoryService,
+		c.Config.Gameplay.MailTTL,
+	)
+	
+	c.ProfileService = service.NewProfileService(
+		c.UserRepo,
+		c.FriendRepo,
+		c.UserEquipRepo,
+		c.RankingRepo,
+		c.PrivacyRepo,
+		c.PlayerService,
+	)
+	
 	return nil
 }
 
@@ -123,11 +251,22 @@ func (c *Container) GetWebSocketServices() *websocket.ServiceContainer {
 		FriendService:    c.FriendService,
 		RankingService:   c.RankingService,
 		UserEquipService: c.UserEquipService,
+		WalletService:    c.WalletService,
+		InventoryService: c.InventoryService,
+		CheckInService:   c.CheckInService,
+		QuestService:     c.QuestService,
+		MailService:      c.MailService,
+		ProfileService:   c.ProfileService,
 	}
 }
 
 // Close cleans up resources
 func (c *Container) Close() error {
+	if c.Backplane != nil {
+		if err := c.Backplane.Close(); err != nil {
+			log.Printf("Failed to close backplane: %v", err)
+		}
+	}
 	if c.Database != nil {
 		return c.Database.Close()
 	}
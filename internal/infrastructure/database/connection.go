@@ -76,14 +76,57 @@ func (c *Connection) CheckTables() error {
 	return nil
 }
 
-// CreateMissingTables creates any missing tables
+// CreateMissingTables creates any missing server-managed tables and columns
+// and checks that the required tables exist
 func (c *Connection) CreateMissingTables() error {
-	// This would contain the table creation logic
-	// For now, we'll just check if tables exist
 	log.Println("Checking for missing tables...")
+	for _, table := range managedTables {
+		if _, err := c.db.Exec(table.ddl); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table.name, err)
+		}
+	}
+	for _, column := range managedColumns {
+		if err := c.addColumnIfMissing(column); err != nil {
+			return err
+		}
+	}
 	return c.CheckTables()
 }
 
+// addColumnIfMissing adds a managed column to its table unless it exists,
+// then runs its backfill
+func (c *Connection) addColumnIfMissing(column managedColumn) error {
+	query := `
+		SELECT COUNT(*)
+		FROM information_schema.columns
+		WHERE table_schema = ? AND table_name = ? AND column_name = ?
+	`
+
+	var count int
+	if err := c.db.QueryRow(query, c.config.Database.Name, column.table, column.name).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check column %s.%s: %w", column.table, column.name, err)
+	}
+	if count == 0 {
+		alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", column.table, column.name, column.definition)
+		if _, err := c.db.Exec(alter); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", column.table, column.name, err)
+		}
+		log.Printf("Added column %s.%s", column.table, column.name)
+	}
+
+	if column.backfill == "" {
+		return nil
+	}
+	result, err := c.db.Exec(column.backfill)
+	if err != nil {
+		return fmt.Errorf("failed to backfill column %s.%s: %w", column.table, column.name, err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		log.Printf("Backfilled %d rows of %s.%s", rows, column.table, column.name)
+	}
+	return nil
+}
+
 // CheckTableStructure verifies table structure
 func (c *Connection) CheckTableStructure() error {
 	// This could be enhanced to check column types, constraints, etc.
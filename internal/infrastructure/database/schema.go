@@ -0,0 +1,172 @@
+package database
+
+// managedTable is a table the server creates on startup when it is missing.
+// Keep the statements in sync with internal/database/init_tables.sql.
+type managedTable struct {
+	name string
+	ddl  string
+}
+
+// managedTables lists the tables owned by server features, in creation order
+var managedTables = []managedTable{
+	{
+		name: "suspicion_log",
+		ddl: `CREATE TABLE IF NOT EXISTS suspicion_log (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			userid INT NOT NULL,
+			rule VARCHAR(64) NOT NULL,
+			detail VARCHAR(255) NOT NULL DEFAULT '',
+			payload TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_suspicion_user_time (userid, created_at)
+		)`,
+	},
+	{
+		name: "account_flag",
+		ddl: `CREATE TABLE IF NOT EXISTS account_flag (
+			userid INT PRIMARY KEY,
+			reason VARCHAR(255) NOT NULL DEFAULT '',
+			flagged_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+	},
+	{
+		name: "stage_record",
+		ddl: `CREATE TABLE IF NOT EXISTS stage_record (
+			userid INT NOT NULL,
+			stage INT NOT NULL,
+			stars TINYINT NOT NULL DEFAULT 0,
+			best_time INT NOT NULL DEFAULT 0,
+			clear_count INT NOT NULL DEFAULT 0,
+			first_cleared_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (userid, stage)
+		)`,
+	},
+	{
+		name: "wallet_balance",
+		ddl: `CREATE TABLE IF NOT EXISTS wallet_balance (
+			userid INT NOT NULL,
+			currency VARCHAR(32) NOT NULL,
+			balance BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (userid, currency)
+		)`,
+	},
+	{
+		name: "wallet_ledger",
+		ddl: `CREATE TABLE IF NOT EXISTS wallet_ledger (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			userid INT NOT NULL,
+			currency VARCHAR(32) NOT NULL,
+			amount BIGINT NOT NULL,
+			balance_after BIGINT NOT NULL,
+			reason VARCHAR(64) NOT NULL,
+			reference_id VARCHAR(64) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_wallet_ledger_user (userid, id),
+			INDEX idx_wallet_ledger_reference (reference_id)
+		)`,
+	},
+	{
+		name: "inventory",
+		ddl: `CREATE TABLE IF NOT EXISTS inventory (
+			userid INT NOT NULL,
+			item_id INT NOT NULL,
+			count INT NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (userid, item_id)
+		)`,
+	},
+	{
+		name: "checkin",
+		ddl: `CREATE TABLE IF NOT EXISTS checkin (
+			userid INT PRIMARY KEY,
+			streak INT NOT NULL DEFAULT 0,
+			last_day INT NOT NULL DEFAULT 0,
+			total_days INT NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`,
+	},
+	{
+		name: "quest_progress",
+		ddl: `CREATE TABLE IF NOT EXISTS quest_progress (
+			userid INT NOT NULL,
+			quest_id INT NOT NULL,
+			period INT NOT NULL DEFAULT 0,
+			progress INT NOT NULL DEFAULT 0,
+			claimed TINYINT(1) NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (userid, quest_id)
+		)`,
+	},
+	{
+		name: "mail",
+		ddl: `CREATE TABLE IF NOT EXISTS mail (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			userid INT NOT NULL,
+			sender_id INT NOT NULL DEFAULT 0,
+			sender_name VARCHAR(64) NOT NULL DEFAULT '',
+			kind VARCHAR(16) NOT NULL,
+			title VARCHAR(255) NOT NULL,
+			body TEXT,
+			attachments TEXT,
+			is_read TINYINT(1) NOT NULL DEFAULT 0,
+			claimed TINYINT(1) NOT NULL DEFAULT 0,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_mail_user (userid, expires_at)
+		)`,
+	},
+	{
+		name: "privacy_setting",
+		ddl: `CREATE TABLE IF NOT EXISTS privacy_setting (
+			userid INT PRIMARY KEY,
+			friends_only TINYINT(1) NOT NULL DEFAULT 0,
+			hide_equipment TINYINT(1) NOT NULL DEFAULT 0,
+			hide_rankings TINYINT(1) NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`,
+	},
+	{
+		name: "api_token",
+		ddl: `CREATE TABLE IF NOT EXISTS api_token (
+			token_hash CHAR(64) PRIMARY KEY,
+			userid INT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_api_token_user (userid, expires_at)
+		)`,
+	},
+	{
+		name: "experience_rate",
+		ddl: `CREATE TABLE IF NOT EXISTS experience_rate (
+			userid INT NOT NULL,
+			minute_index BIGINT NOT NULL,
+			amount INT NOT NULL DEFAULT 0,
+			PRIMARY KEY (userid, minute_index)
+		)`,
+	},
+}
+
+// managedColumn is a column the server adds to an existing table on startup
+// when it is missing. backfill, if set, runs on every startup after the
+// column exists and must only touch rows still holding the column default.
+type managedColumn struct {
+	table      string
+	name       string
+	definition string
+	backfill   string
+}
+
+// managedColumns lists the columns server features add to existing tables.
+// Keep them in sync with the playerinfo table in
+// internal/database/init_tables.sql and internal/database/migrate_energy_regen.sql.
+var managedColumns = []managedColumn{
+	{
+		table:      "playerinfo",
+		name:       "energy_updated_at",
+		definition: "BIGINT NOT NULL DEFAULT 0",
+		// Rows from before the column existed start regenerating from now
+		backfill: "UPDATE playerinfo SET energy_updated_at = UNIX_TIMESTAMP() WHERE energy_updated_at = 0",
+	},
+	{table: "playerinfo", name: "energy_purchases", definition: "INT NOT NULL DEFAULT 0"},
+	{table: "playerinfo", name: "energy_purchase_day", definition: "INT NOT NULL DEFAULT 0"},
+}
@@ -0,0 +1,36 @@
+package gamedata
+
+import (
+	"fmt"
+	"slices"
+
+	"GameServer/internal/domain/entity"
+)
+
+// LoadCheckInCalendar reads the daily check-in calendar from a JSON file
+// and validates its rewards against the configured currencies and items
+func LoadCheckInCalendar(path string, currencies []string, items []*entity.ItemDefinition) (*entity.CheckInCalendar, error) {
+	var calendar entity.CheckInCalendar
+	if err := readJSON(path, &calendar); err != nil {
+		return nil, err
+	}
+
+	if len(calendar.Days) == 0 {
+		return nil, fmt.Errorf("%s: the calendar needs at least one day", path)
+	}
+	for i, reward := range calendar.Days {
+		if err := checkReward(reward, currencies, items); err != nil {
+			return nil, fmt.Errorf("%s: day %d: %w", path, i+1, err)
+		}
+	}
+
+	makeUp := calendar.MakeUp
+	if makeUp.MaxDays < 0 || makeUp.Cost < 0 {
+		return nil, fmt.Errorf("%s: make_up max_days and cost cannot be negative", path)
+	}
+	if makeUp.MaxDays > 0 && makeUp.Cost > 0 && !slices.Contains(currencies, makeUp.Currency) {
+		return nil, fmt.Errorf("%s: make_up charges unknown currency %q", path, makeUp.Currency)
+	}
+
+	return &calendar, nil
+}
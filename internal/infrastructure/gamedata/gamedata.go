@@ -0,0 +1,48 @@
+// Package gamedata loads static game definitions from JSON files
+package gamedata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"GameServer/internal/domain/entity"
+)
+
+// readJSON reads a definitions file into v
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkReward validates that a reward is non-empty, pays only configured
+// currencies and known items, and grants positive amounts
+func checkReward(reward *entity.Reward, currencies []string, items []*entity.ItemDefinition) error {
+	if reward == nil || reward.IsEmpty() {
+		return fmt.Errorf("reward is empty")
+	}
+	for currency, amount := range reward.Currencies {
+		if !slices.Contains(currencies, currency) {
+			return fmt.Errorf("unknown currency %q", currency)
+		}
+		if amount <= 0 {
+			return fmt.Errorf("%s amount must be positive", currency)
+		}
+	}
+	for itemID, count := range reward.Items {
+		if !slices.ContainsFunc(items, func(item *entity.ItemDefinition) bool { return item.ID == itemID }) {
+			return fmt.Errorf("unknown item %d", itemID)
+		}
+		if count <= 0 {
+			return fmt.Errorf("item %d count must be positive", itemID)
+		}
+	}
+	return nil
+}
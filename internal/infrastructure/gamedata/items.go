@@ -0,0 +1,50 @@
+package gamedata
+
+import (
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+)
+
+// itemsFile is the layout of the item definitions file
+type itemsFile struct {
+	Items []*entity.ItemDefinition `json:"items"`
+}
+
+// LoadItems reads item definitions from a JSON file and validates them
+func LoadItems(path string) ([]*entity.ItemDefinition, error) {
+	var file itemsFile
+	if err := readJSON(path, &file); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(file.Items))
+	for _, item := range file.Items {
+		if item.ID <= 0 {
+			return nil, fmt.Errorf("%s: item ids must be positive", path)
+		}
+		if seen[item.ID] {
+			return nil, fmt.Errorf("%s: item %d is defined twice", path, item.ID)
+		}
+		seen[item.ID] = true
+
+		if item.Name == "" {
+			return nil, fmt.Errorf("%s: item %d has no name", path, item.ID)
+		}
+		if item.MaxStack <= 0 {
+			return nil, fmt.Errorf("%s: item %d needs a positive max_stack", path, item.ID)
+		}
+		if item.Effect != nil {
+			switch item.Effect.Type {
+			case entity.ItemEffectRestoreEnergy, entity.ItemEffectGrantExperience:
+			default:
+				return nil, fmt.Errorf("%s: item %d has unknown effect %q", path, item.ID, item.Effect.Type)
+			}
+			if item.Effect.Amount <= 0 {
+				return nil, fmt.Errorf("%s: item %d needs a positive effect amount", path, item.ID)
+			}
+		}
+	}
+
+	return file.Items, nil
+}
@@ -0,0 +1,72 @@
+package gamedata
+
+import (
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+)
+
+// questsFile is the layout of the quest definitions file
+type questsFile struct {
+	Quests []*entity.QuestDefinition `json:"quests"`
+}
+
+// valueEvents are the events whose value a quest can measure
+var valueEvents = map[string]bool{
+	entity.GameEventLevelUp:       true,
+	entity.GameEventCompleteStage: true,
+}
+
+// LoadQuests reads quest and achievement definitions from a JSON file and
+// validates their rewards against the configured currencies and items
+func LoadQuests(path string, currencies []string, items []*entity.ItemDefinition) ([]*entity.QuestDefinition, error) {
+	var file questsFile
+	if err := readJSON(path, &file); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool, len(file.Quests))
+	for _, quest := range file.Quests {
+		if quest.ID <= 0 {
+			return nil, fmt.Errorf("%s: quest ids must be positive", path)
+		}
+		if seen[quest.ID] {
+			return nil, fmt.Errorf("%s: quest %d is defined twice", path, quest.ID)
+		}
+		seen[quest.ID] = true
+
+		if quest.Name == "" {
+			return nil, fmt.Errorf("%s: quest %d has no name", path, quest.ID)
+		}
+		switch quest.Kind {
+		case entity.QuestKindDaily, entity.QuestKindWeekly, entity.QuestKindAchievement:
+		default:
+			return nil, fmt.Errorf("%s: quest %d has unknown kind %q", path, quest.ID, quest.Kind)
+		}
+		switch quest.Event {
+		case entity.GameEventEquipItem, entity.GameEventSaveEquipment, entity.GameEventAddFriend,
+			entity.GameEventLevelUp, entity.GameEventCompleteStage:
+		default:
+			return nil, fmt.Errorf("%s: quest %d has unknown event %q", path, quest.ID, quest.Event)
+		}
+		switch quest.Measure {
+		case "":
+			quest.Measure = entity.QuestMeasureCount
+		case entity.QuestMeasureCount:
+		case entity.QuestMeasureValue:
+			if !valueEvents[quest.Event] {
+				return nil, fmt.Errorf("%s: quest %d measures the value of %s, which has none", path, quest.ID, quest.Event)
+			}
+		default:
+			return nil, fmt.Errorf("%s: quest %d has unknown measure %q", path, quest.ID, quest.Measure)
+		}
+		if quest.Target <= 0 {
+			return nil, fmt.Errorf("%s: quest %d needs a positive target", path, quest.ID)
+		}
+		if err := checkReward(quest.Reward, currencies, items); err != nil {
+			return nil, fmt.Errorf("%s: quest %d: %w", path, quest.ID, err)
+		}
+	}
+
+	return file.Quests, nil
+}
@@ -0,0 +1,29 @@
+package gamedata
+
+import (
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+)
+
+// LoadStageRewards reads the stage clear rewards from a JSON file and
+// validates them against the configured currencies and items
+func LoadStageRewards(path string, currencies []string, items []*entity.ItemDefinition) (*entity.StageRewards, error) {
+	var rewards entity.StageRewards
+	if err := readJSON(path, &rewards); err != nil {
+		return nil, err
+	}
+
+	if rewards.FirstClear != nil && !rewards.FirstClear.IsEmpty() {
+		if err := checkReward(rewards.FirstClear, currencies, items); err != nil {
+			return nil, fmt.Errorf("%s: first_clear: %w", path, err)
+		}
+	}
+	if rewards.Repeat != nil && !rewards.Repeat.IsEmpty() {
+		if err := checkReward(rewards.Repeat, currencies, items); err != nil {
+			return nil, fmt.Errorf("%s: repeat: %w", path, err)
+		}
+	}
+
+	return &rewards, nil
+}
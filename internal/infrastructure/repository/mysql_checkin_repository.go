@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlCheckInRepository implements CheckInRepository
+type mysqlCheckInRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLCheckInRepository creates a new MySQL check-in repository
+func NewMySQLCheckInRepository(db *sql.DB) repository.CheckInRepository {
+	return &mysqlCheckInRepository{db: db}
+}
+
+// GetByUserID retrieves a user's check-in record, or nil if they never checked in
+func (r *mysqlCheckInRepository) GetByUserID(ctx context.Context, userID int) (*entity.CheckIn, error) {
+	query := `SELECT userid, streak, last_day, total_days FROM checkin WHERE userid = ?`
+
+	record := &entity.CheckIn{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&record.UserID, &record.Streak, &record.LastDay, &record.TotalDays,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get check-in record: %w", err)
+	}
+	return record, nil
+}
+
+// Save stores the record if the stored last day is still oldLastDay. A
+// user's first check-in inserts the row; the insert is ignored when a
+// concurrent request got there first.
+func (r *mysqlCheckInRepository) Save(ctx context.Context, record *entity.CheckIn, oldLastDay int) (bool, error) {
+	if oldLastDay == 0 {
+		insertQuery := `INSERT IGNORE INTO checkin (userid, streak, last_day, total_days) VALUES (?, ?, ?, ?)`
+		result, err := r.db.ExecContext(ctx, insertQuery, record.UserID, record.Streak, record.LastDay, record.TotalDays)
+		if err != nil {
+			return false, fmt.Errorf("failed to save check-in record: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return false, err
+		} else if rows == 1 {
+			return true, nil
+		}
+		// The row exists, e.g. because an earlier check-in was undone
+	}
+
+	updateQuery := `UPDATE checkin SET streak = ?, last_day = ?, total_days = ? WHERE userid = ? AND last_day = ?`
+	result, err := r.db.ExecContext(ctx, updateQuery,
+		record.Streak, record.LastDay, record.TotalDays, record.UserID, oldLastDay,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save check-in record: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
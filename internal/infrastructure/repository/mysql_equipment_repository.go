@@ -3,6 +3,7 @@ package repository
 import (
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
 	"database/sql"
 )
 
@@ -17,13 +18,13 @@ func NewMySQLEquipmentRepository(db *sql.DB) repository.EquipmentRepository {
 }
 
 // GetByUserID retrieves all equipment for a user
-func (r *mysqlEquipmentRepository) GetByUserID(userID int) ([]*entity.Equipment, error) {
+func (r *mysqlEquipmentRepository) GetByUserID(ctx context.Context, userID int) ([]*entity.Equipment, error) {
 	query := `SELECT equipid, quality, damage, crit, critdamage, damagespeed, 
 			  bloodsuck, hp, movespeed, suitid, suitname, equip_type_id, equip_type_name,
 			  userid, defense, goodfortune, type 
 			  FROM equip WHERE userid = ?`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,14 +49,14 @@ func (r *mysqlEquipmentRepository) GetByUserID(userID int) ([]*entity.Equipment,
 }
 
 // GetByEquipID retrieves equipment by ID
-func (r *mysqlEquipmentRepository) GetByEquipID(equipID int) (*entity.Equipment, error) {
+func (r *mysqlEquipmentRepository) GetByEquipID(ctx context.Context, equipID int) (*entity.Equipment, error) {
 	equip := &entity.Equipment{}
 	query := `SELECT equipid, quality, damage, crit, critdamage, damagespeed, 
 			  bloodsuck, hp, movespeed, suitid, suitname, equip_type_id, equip_type_name,
 			  userid, defense, goodfortune, type 
 			  FROM equip WHERE equipid = ?`
 
-	err := r.db.QueryRow(query, equipID).Scan(
+	err := r.db.QueryRowContext(ctx, query, equipID).Scan(
 		&equip.EquipID, &equip.Quality, &equip.Damage, &equip.Crit,
 		&equip.CritDamage, &equip.DamageSpeed, &equip.BloodSuck, &equip.HP,
 		&equip.MoveSpeed, &equip.SuitID, &equip.SuitName, &equip.EquipTypeID,
@@ -71,13 +72,13 @@ func (r *mysqlEquipmentRepository) GetByEquipID(equipID int) (*entity.Equipment,
 }
 
 // Create creates new equipment
-func (r *mysqlEquipmentRepository) Create(equipment *entity.Equipment) error {
+func (r *mysqlEquipmentRepository) Create(ctx context.Context, equipment *entity.Equipment) error {
 	query := `INSERT INTO equip (quality, damage, crit, critdamage, damagespeed, 
 			  bloodsuck, hp, movespeed, suitid, suitname, equip_type_id, equip_type_name,
 			  userid, defense, goodfortune, type) 
 			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	result, err := r.db.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		equipment.Quality, equipment.Damage, equipment.Crit,
 		equipment.CritDamage, equipment.DamageSpeed, equipment.BloodSuck, equipment.HP,
 		equipment.MoveSpeed, equipment.SuitID, equipment.SuitName, equipment.EquipTypeID,
@@ -98,13 +99,13 @@ func (r *mysqlEquipmentRepository) Create(equipment *entity.Equipment) error {
 }
 
 // Update updates existing equipment
-func (r *mysqlEquipmentRepository) Update(equipment *entity.Equipment) error {
+func (r *mysqlEquipmentRepository) Update(ctx context.Context, equipment *entity.Equipment) error {
 	query := `UPDATE equip SET quality = ?, damage = ?, crit = ?, critdamage = ?, 
 			  damagespeed = ?, bloodsuck = ?, hp = ?, movespeed = ?, suitid = ?, suitname = ?,
 			  equip_type_id = ?, equip_type_name = ?, userid = ?, defense = ?, goodfortune = ?, type = ? 
 			  WHERE equipid = ?`
 
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		equipment.Quality, equipment.Damage, equipment.Crit, equipment.CritDamage,
 		equipment.DamageSpeed, equipment.BloodSuck, equipment.HP, equipment.MoveSpeed,
 		equipment.SuitID, equipment.SuitName, equipment.EquipTypeID, equipment.EquipTypeName,
@@ -114,17 +115,17 @@ func (r *mysqlEquipmentRepository) Update(equipment *entity.Equipment) error {
 }
 
 // Delete deletes equipment by ID
-func (r *mysqlEquipmentRepository) Delete(equipID int) error {
+func (r *mysqlEquipmentRepository) Delete(ctx context.Context, equipID int) error {
 	query := "DELETE FROM equip WHERE equipid = ?"
-	_, err := r.db.Exec(query, equipID)
+	_, err := r.db.ExecContext(ctx, query, equipID)
 	return err
 }
 
 // GetUserEquipmentCount returns the count of equipment for a user
-func (r *mysqlEquipmentRepository) GetUserEquipmentCount(userID int) (int, error) {
+func (r *mysqlEquipmentRepository) GetUserEquipmentCount(ctx context.Context, userID int) (int, error) {
 	var count int
 	query := "SELECT COUNT(*) FROM equip WHERE userid = ?"
-	err := r.db.QueryRow(query, userID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
 	return count, err
 }
 
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlExperienceRateRepository implements ExperienceRateRepository
+type mysqlExperienceRateRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLExperienceRateRepository creates a new MySQL experience rate repository
+func NewMySQLExperienceRateRepository(db *sql.DB) repository.ExperienceRateRepository {
+	return &mysqlExperienceRateRepository{db: db}
+}
+
+// Reserve adds amount to the user's count for the minute in one statement,
+// so concurrent grants on any node cannot together pass the limit
+func (r *mysqlExperienceRateRepository) Reserve(ctx context.Context, userID int, minute int64, amount, limit int) (bool, error) {
+	if amount > limit {
+		return false, nil
+	}
+
+	if _, err := r.db.ExecContext(ctx,
+		`DELETE FROM experience_rate WHERE userid = ? AND minute_index < ?`, userID, minute); err != nil {
+		return false, fmt.Errorf("failed to discard old experience counts: %w", err)
+	}
+
+	// Inserting affects one row and raising the count two; a count left
+	// unchanged because of the limit affects none
+	query := `INSERT INTO experience_rate (userid, minute_index, amount) VALUES (?, ?, ?)
+			  ON DUPLICATE KEY UPDATE amount = IF(amount + ? <= ?, amount + ?, amount)`
+	result, err := r.db.ExecContext(ctx, query, userID, minute, amount, amount, limit, amount)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve experience: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// Release lowers the user's count for the minute, never below zero
+func (r *mysqlExperienceRateRepository) Release(ctx context.Context, userID int, minute int64, amount int) error {
+	query := `UPDATE experience_rate SET amount = GREATEST(amount - ?, 0) WHERE userid = ? AND minute_index = ?`
+	if _, err := r.db.ExecContext(ctx, query, amount, userID, minute); err != nil {
+		return fmt.Errorf("failed to release experience: %w", err)
+	}
+	return nil
+}
@@ -1,9 +1,10 @@
 package repository
 
 import (
-	"database/sql"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
+	"database/sql"
 )
 
 // mysqlExperienceRepository implements ExperienceRepository
@@ -17,11 +18,11 @@ func NewMySQLExperienceRepository(db *sql.DB) repository.ExperienceRepository {
 }
 
 // GetByLevel retrieves experience info by level
-func (r *mysqlExperienceRepository) GetByLevel(level int) (*entity.Experience, error) {
+func (r *mysqlExperienceRepository) GetByLevel(ctx context.Context, level int) (*entity.Experience, error) {
 	exp := &entity.Experience{}
 	query := "SELECT level, value FROM experience WHERE level = ?"
 	
-	err := r.db.QueryRow(query, level).Scan(&exp.Level, &exp.Value)
+	err := r.db.QueryRowContext(ctx, query, level).Scan(&exp.Level, &exp.Value)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -32,10 +33,10 @@ func (r *mysqlExperienceRepository) GetByLevel(level int) (*entity.Experience, e
 }
 
 // GetAllLevels retrieves all experience levels
-func (r *mysqlExperienceRepository) GetAllLevels() ([]*entity.Experience, error) {
+func (r *mysqlExperienceRepository) GetAllLevels(ctx context.Context) ([]*entity.Experience, error) {
 	query := "SELECT level, value FROM experience ORDER BY level ASC"
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -1,9 +1,10 @@
 package repository
 
 import (
-	"database/sql"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
+	"database/sql"
 )
 
 // mysqlFriendRepository implements FriendRepository
@@ -17,11 +18,11 @@ func NewMySQLFriendRepository(db *sql.DB) repository.FriendRepository {
 }
 
 // GetFriendsByUserID retrieves all friends for a user
-func (r *mysqlFriendRepository) GetFriendsByUserID(userID int) ([]*entity.Friend, error) {
+func (r *mysqlFriendRepository) GetFriendsByUserID(ctx context.Context, userID int) ([]*entity.Friend, error) {
 	query := `SELECT id, fromuserid, touserid, status, created_at, updated_at 
 			  FROM friend WHERE (fromuserid = ? OR touserid = ?) AND status = 'accepted'`
 	
-	rows, err := r.db.Query(query, userID, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,11 +45,11 @@ func (r *mysqlFriendRepository) GetFriendsByUserID(userID int) ([]*entity.Friend
 }
 
 // GetFriendRequestsByUserID retrieves all friend requests for a user
-func (r *mysqlFriendRepository) GetFriendRequestsByUserID(userID int) ([]*entity.FriendRequest, error) {
+func (r *mysqlFriendRepository) GetFriendRequestsByUserID(ctx context.Context, userID int) ([]*entity.FriendRequest, error) {
 	query := `SELECT id, fromuserid, touserid, message, status, created_at, updated_at 
 			  FROM friend_request WHERE touserid = ? AND status = 'pending'`
 	
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -71,11 +72,11 @@ func (r *mysqlFriendRepository) GetFriendRequestsByUserID(userID int) ([]*entity
 }
 
 // CreateFriendRequest creates a new friend request
-func (r *mysqlFriendRepository) CreateFriendRequest(request *entity.FriendRequest) error {
+func (r *mysqlFriendRepository) CreateFriendRequest(ctx context.Context, request *entity.FriendRequest) error {
 	query := `INSERT INTO friend_request (fromuserid, touserid, message, status) 
 			  VALUES (?, ?, ?, ?)`
 	
-	result, err := r.db.Exec(query, request.FromUserID, request.ToUserID, request.Message, request.Status)
+	result, err := r.db.ExecContext(ctx, query, request.FromUserID, request.ToUserID, request.Message, request.Status)
 	if err != nil {
 		return err
 	}
@@ -90,8 +91,8 @@ func (r *mysqlFriendRepository) CreateFriendRequest(request *entity.FriendReques
 }
 
 // AcceptFriendRequest accepts a friend request and creates friendship
-func (r *mysqlFriendRepository) AcceptFriendRequest(requestID int) error {
-	tx, err := r.db.Begin()
+func (r *mysqlFriendRepository) AcceptFriendRequest(ctx context.Context, requestID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -100,21 +101,21 @@ func (r *mysqlFriendRepository) AcceptFriendRequest(requestID int) error {
 	// Get the request details
 	var fromUserID, toUserID int
 	query := "SELECT fromuserid, touserid FROM friend_request WHERE id = ?"
-	err = tx.QueryRow(query, requestID).Scan(&fromUserID, &toUserID)
+	err = tx.QueryRowContext(ctx, query, requestID).Scan(&fromUserID, &toUserID)
 	if err != nil {
 		return err
 	}
 
 	// Update request status
 	updateQuery := "UPDATE friend_request SET status = 'accepted' WHERE id = ?"
-	_, err = tx.Exec(updateQuery, requestID)
+	_, err = tx.ExecContext(ctx, updateQuery, requestID)
 	if err != nil {
 		return err
 	}
 
 	// Create friend relationship
 	insertQuery := "INSERT INTO friend (fromuserid, touserid, status) VALUES (?, ?, 'accepted')"
-	_, err = tx.Exec(insertQuery, fromUserID, toUserID)
+	_, err = tx.ExecContext(ctx, insertQuery, fromUserID, toUserID)
 	if err != nil {
 		return err
 	}
@@ -123,26 +124,26 @@ func (r *mysqlFriendRepository) AcceptFriendRequest(requestID int) error {
 }
 
 // RejectFriendRequest rejects a friend request
-func (r *mysqlFriendRepository) RejectFriendRequest(requestID int) error {
+func (r *mysqlFriendRepository) RejectFriendRequest(ctx context.Context, requestID int) error {
 	query := "UPDATE friend_request SET status = 'rejected' WHERE id = ?"
-	_, err := r.db.Exec(query, requestID)
+	_, err := r.db.ExecContext(ctx, query, requestID)
 	return err
 }
 
 // RemoveFriend removes a friendship
-func (r *mysqlFriendRepository) RemoveFriend(fromUserID, toUserID int) error {
+func (r *mysqlFriendRepository) RemoveFriend(ctx context.Context, fromUserID, toUserID int) error {
 	query := "DELETE FROM friend WHERE (fromuserid = ? AND touserid = ?) OR (fromuserid = ? AND touserid = ?)"
-	_, err := r.db.Exec(query, fromUserID, toUserID, toUserID, fromUserID)
+	_, err := r.db.ExecContext(ctx, query, fromUserID, toUserID, toUserID, fromUserID)
 	return err
 }
 
 // AreFriends checks if two users are friends
-func (r *mysqlFriendRepository) AreFriends(userID1, userID2 int) (bool, error) {
+func (r *mysqlFriendRepository) AreFriends(ctx context.Context, userID1, userID2 int) (bool, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM friend 
 			  WHERE ((fromuserid = ? AND touserid = ?) OR (fromuserid = ? AND touserid = ?)) 
 			  AND status = 'accepted'`
-	err := r.db.QueryRow(query, userID1, userID2, userID2, userID1).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, userID1, userID2, userID2, userID1).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -150,11 +151,11 @@ func (r *mysqlFriendRepository) AreFriends(userID1, userID2 int) (bool, error) {
 }
 
 // HasPendingRequest checks if there's a pending friend request
-func (r *mysqlFriendRepository) HasPendingRequest(fromUserID, toUserID int) (bool, error) {
+func (r *mysqlFriendRepository) HasPendingRequest(ctx context.Context, fromUserID, toUserID int) (bool, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM friend_request 
 			  WHERE fromuserid = ? AND touserid = ? AND status = 'pending'`
-	err := r.db.QueryRow(query, fromUserID, toUserID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, fromUserID, toUserID).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlInventoryRepository implements InventoryRepository
+type mysqlInventoryRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLInventoryRepository creates a new MySQL inventory repository
+func NewMySQLInventoryRepository(db *sql.DB) repository.InventoryRepository {
+	return &mysqlInventoryRepository{db: db}
+}
+
+// GetByUserID retrieves the non-empty stacks a user holds
+func (r *mysqlInventoryRepository) GetByUserID(ctx context.Context, userID int) ([]*entity.InventoryItem, error) {
+	query := `SELECT userid, item_id, count FROM inventory WHERE userid = ? AND count > 0 ORDER BY item_id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*entity.InventoryItem
+	for rows.Next() {
+		item := &entity.InventoryItem{}
+		if err := rows.Scan(&item.UserID, &item.ItemID, &item.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// AddItems adds the grants in one transaction, locking stacks in item order
+// so that concurrent grants cannot deadlock
+func (r *mysqlInventoryRepository) AddItems(ctx context.Context, userID int, grants []*entity.ItemGrant) error {
+	ordered := make([]*entity.ItemGrant, len(grants))
+	copy(ordered, grants)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].ItemID < ordered[j].ItemID })
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, grant := range ordered {
+		// Make sure the stack row exists so it can be locked
+		ensureQuery := `INSERT INTO inventory (userid, item_id, count) VALUES (?, ?, 0)
+						ON DUPLICATE KEY UPDATE count = count`
+		if _, err := tx.ExecContext(ctx, ensureQuery, userID, grant.ItemID); err != nil {
+			return fmt.Errorf("failed to create inventory stack: %w", err)
+		}
+
+		var count int
+		lockQuery := `SELECT count FROM inventory WHERE userid = ? AND item_id = ? FOR UPDATE`
+		if err := tx.QueryRowContext(ctx, lockQuery, userID, grant.ItemID).Scan(&count); err != nil {
+			return fmt.Errorf("failed to lock inventory stack: %w", err)
+		}
+
+		count += grant.Count
+		if grant.MaxStack > 0 && count > grant.MaxStack {
+			return entity.ErrStackFull
+		}
+
+		updateQuery := `UPDATE inventory SET count = ? WHERE userid = ? AND item_id = ?`
+		if _, err := tx.ExecContext(ctx, updateQuery, count, userID, grant.ItemID); err != nil {
+			return fmt.Errorf("failed to update inventory stack: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ConsumeItem removes count of an item if the user holds at least that many
+func (r *mysqlInventoryRepository) ConsumeItem(ctx context.Context, userID, itemID, count int) (bool, error) {
+	query := `UPDATE inventory SET count = count - ? WHERE userid = ? AND item_id = ? AND count >= ?`
+	result, err := r.db.ExecContext(ctx, query, count, userID, itemID, count)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mailColumns are the columns scanned by scanMail
+const mailColumns = `id, userid, sender_id, sender_name, kind, title, body, attachments,
+			  is_read, claimed, expires_at, created_at`
+
+// mysqlMailRepository implements MailRepository
+type mysqlMailRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLMailRepository creates a new MySQL mail repository
+func NewMySQLMailRepository(db *sql.DB) repository.MailRepository {
+	return &mysqlMailRepository{db: db}
+}
+
+// Create stores a mail for one recipient and sets its ID
+func (r *mysqlMailRepository) Create(ctx context.Context, mail *entity.Mail) error {
+	attachments, err := encodeAttachments(mail.Attachments)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mail (userid, sender_id, sender_name, kind, title, body, attachments, expires_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query,
+		mail.UserID, mail.SenderID, mail.SenderName, mail.Kind,
+		mail.Title, mail.Body, attachments, mail.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create mail: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	mail.ID = int(id)
+	return nil
+}
+
+// CreateBulk copies mail to every selected recipient with INSERT ... SELECT.
+// Recipients come from the user table, or from playerinfo when a level
+// bound is set, so only existing users receive mail.
+func (r *mysqlMailRepository) CreateBulk(ctx context.Context, mail *entity.Mail, recipients *entity.MailRecipients) (int, error) {
+	attachments, err := encodeAttachments(mail.Attachments)
+	if err != nil {
+		return 0, err
+	}
+
+	source := "user"
+	conditions := []string{"1 = 1"}
+	args := []interface{}{mail.SenderID, mail.SenderName, mail.Kind, mail.Title, mail.Body, attachments, mail.ExpiresAt}
+
+	if recipients.MinLevel > 0 || recipients.MaxLevel > 0 {
+		source = "playerinfo"
+		if recipients.MinLevel > 0 {
+			conditions = append(conditions, "level >= ?")
+			args = append(args, recipients.MinLevel)
+		}
+		if recipients.MaxLevel > 0 {
+			conditions = append(conditions, "level <= ?")
+			args = append(args, recipients.MaxLevel)
+		}
+	}
+	if len(recipients.UserIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(recipients.UserIDs)), ", ")
+		conditions = append(conditions, "userid IN ("+placeholders+")")
+		for _, userID := range recipients.UserIDs {
+			args = append(args, userID)
+		}
+	}
+
+	query := `INSERT INTO mail (userid, sender_id, sender_name, kind, title, body, attachments, expires_at)
+			  SELECT userid, ?, ?, ?, ?, ?, ?, ? FROM ` + source + ` WHERE ` + strings.Join(conditions, " AND ")
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send bulk mail: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rows), nil
+}
+
+// GetByUserID retrieves a user's unexpired mail, newest first
+func (r *mysqlMailRepository) GetByUserID(ctx context.Context, userID, limit int) ([]*entity.Mail, error) {
+	query := `SELECT ` + mailColumns + `
+			  FROM mail WHERE userid = ? AND expires_at > NOW() ORDER BY id DESC LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mail: %w", err)
+	}
+	defer rows.Close()
+
+	var mails []*entity.Mail
+	for rows.Next() {
+		mail, err := scanMail(rows)
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, mail)
+	}
+
+	return mails, rows.Err()
+}
+
+// GetByID retrieves one of a user's mails, or nil if there is none
+func (r *mysqlMailRepository) GetByID(ctx context.Context, userID, mailID int) (*entity.Mail, error) {
+	query := `SELECT ` + mailColumns + `
+			  FROM mail WHERE id = ? AND userid = ?`
+
+	mail, err := scanMail(r.db.QueryRowContext(ctx, query, mailID, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return mail, err
+}
+
+// MarkRead marks a mail read
+func (r *mysqlMailRepository) MarkRead(ctx context.Context, userID, mailID int) error {
+	query := `UPDATE mail SET is_read = 1 WHERE id = ? AND userid = ?`
+	if _, err := r.db.ExecContext(ctx, query, mailID, userID); err != nil {
+		return fmt.Errorf("failed to mark mail read: %w", err)
+	}
+	return nil
+}
+
+// MarkClaimed marks a mail's attachments claimed in one conditional update,
+// so concurrent claims cannot both succeed
+func (r *mysqlMailRepository) MarkClaimed(ctx context.Context, userID, mailID int) (bool, error) {
+	query := `UPDATE mail SET claimed = 1, is_read = 1
+			  WHERE id = ? AND userid = ? AND claimed = 0 AND expires_at > NOW()`
+
+	result, err := r.db.ExecContext(ctx, query, mailID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim mail: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// UnmarkClaimed clears the claim of a mail's attachments
+func (r *mysqlMailRepository) UnmarkClaimed(ctx context.Context, userID, mailID int) error {
+	query := `UPDATE mail SET claimed = 0 WHERE id = ? AND userid = ?`
+	if _, err := r.db.ExecContext(ctx, query, mailID, userID); err != nil {
+		return fmt.Errorf("failed to release mail claim: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a mail unless it still has unclaimed attachments and has
+// not expired. The condition is part of the statement so a delete cannot
+// race a claim.
+func (r *mysqlMailRepository) Delete(ctx context.Context, userID, mailID int) (bool, error) {
+	query := `DELETE FROM mail WHERE id = ? AND userid = ?
+			  AND (attachments IS NULL OR claimed = 1 OR expires_at <= NOW())`
+
+	result, err := r.db.ExecContext(ctx, query, mailID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete mail: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMail scans a row of mailColumns
+func scanMail(row rowScanner) (*entity.Mail, error) {
+	mail := &entity.Mail{}
+	var body, attachments sql.NullString
+	err := row.Scan(
+		&mail.ID, &mail.UserID, &mail.SenderID, &mail.SenderName, &mail.Kind,
+		&mail.Title, &body, &attachments, &mail.Read, &mail.Claimed,
+		&mail.ExpiresAt, &mail.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan mail: %w", err)
+	}
+
+	mail.Body = body.String
+	if attachments.Valid {
+		mail.Attachments = &entity.MailAttachments{}
+		if err := json.Unmarshal([]byte(attachments.String), mail.Attachments); err != nil {
+			return nil, fmt.Errorf("failed to decode attachments of mail %d: %w", mail.ID, err)
+		}
+	}
+	return mail, nil
+}
+
+// encodeAttachments stores attachments as JSON, or NULL when there are none
+func encodeAttachments(attachments *entity.MailAttachments) (interface{}, error) {
+	if attachments.IsEmpty() {
+		return nil, nil
+	}
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mail attachments: %w", err)
+	}
+	return string(data), nil
+}
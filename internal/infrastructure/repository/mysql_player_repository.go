@@ -1,9 +1,10 @@
 package repository
 
 import (
-	"database/sql"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
+	"database/sql"
 )
 
 // mysqlPlayerRepository implements PlayerRepository
@@ -17,12 +18,15 @@ func NewMySQLPlayerRepository(db *sql.DB) repository.PlayerRepository {
 }
 
 // GetByUserID retrieves player info by user ID
-func (r *mysqlPlayerRepository) GetByUserID(userID int) (*entity.PlayerInfo, error) {
+func (r *mysqlPlayerRepository) GetByUserID(ctx context.Context, userID int) (*entity.PlayerInfo, error) {
 	player := &entity.PlayerInfo{}
-	query := "SELECT userid, level, experience, gamelevel, bloodenergy FROM playerinfo WHERE userid = ?"
-	err := r.db.QueryRow(query, userID).Scan(
+	query := `SELECT userid, level, experience, gamelevel, bloodenergy,
+			  energy_updated_at, energy_purchases, energy_purchase_day
+			  FROM playerinfo WHERE userid = ?`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
 		&player.UserID, &player.Level, &player.Experience, 
 		&player.GameLevel, &player.BloodEnergy,
+		&player.EnergyUpdatedAt, &player.EnergyPurchases, &player.EnergyPurchaseDay,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -34,9 +38,9 @@ func (r *mysqlPlayerRepository) GetByUserID(userID int) (*entity.PlayerInfo, err
 }
 
 // Create creates new player info
-func (r *mysqlPlayerRepository) Create(player *entity.PlayerInfo) error {
-	query := "INSERT INTO playerinfo (userid, level, experience, gamelevel, bloodenergy) VALUES (?, ?, ?, ?, ?)"
-	_, err := r.db.Exec(query, 
+func (r *mysqlPlayerRepository) Create(ctx context.Context, player *entity.PlayerInfo) error {
+	query := "INSERT INTO playerinfo (userid, level, experience, gamelevel, bloodenergy, energy_updated_at) VALUES (?, ?, ?, ?, ?, UNIX_TIMESTAMP())"
+	_, err := r.db.ExecContext(ctx, query, 
 		player.UserID, player.Level, player.Experience, 
 		player.GameLevel, player.BloodEnergy,
 	)
@@ -44,9 +48,9 @@ func (r *mysqlPlayerRepository) Create(player *entity.PlayerInfo) error {
 }
 
 // Update updates existing player info
-func (r *mysqlPlayerRepository) Update(player *entity.PlayerInfo) error {
+func (r *mysqlPlayerRepository) Update(ctx context.Context, player *entity.PlayerInfo) error {
 	query := "UPDATE playerinfo SET level = ?, experience = ?, gamelevel = ?, bloodenergy = ? WHERE userid = ?"
-	_, err := r.db.Exec(query, 
+	_, err := r.db.ExecContext(ctx, query, 
 		player.Level, player.Experience, player.GameLevel, 
 		player.BloodEnergy, player.UserID,
 	)
@@ -54,29 +58,75 @@ func (r *mysqlPlayerRepository) Update(player *entity.PlayerInfo) error {
 }
 
 // Delete deletes player info by user ID
-func (r *mysqlPlayerRepository) Delete(userID int) error {
+func (r *mysqlPlayerRepository) Delete(ctx context.Context, userID int) error {
 	query := "DELETE FROM playerinfo WHERE userid = ?"
-	_, err := r.db.Exec(query, userID)
+	_, err := r.db.ExecContext(ctx, query, userID)
 	return err
 }
 
 // UpdateExperience updates player experience
-func (r *mysqlPlayerRepository) UpdateExperience(userID, experience int) error {
+func (r *mysqlPlayerRepository) UpdateExperience(ctx context.Context, userID, experience int) error {
 	query := "UPDATE playerinfo SET experience = ? WHERE userid = ?"
-	_, err := r.db.Exec(query, experience, userID)
+	_, err := r.db.ExecContext(ctx, query, experience, userID)
 	return err
 }
 
 // UpdateLevel updates player level
-func (r *mysqlPlayerRepository) UpdateLevel(userID, level int) error {
+func (r *mysqlPlayerRepository) UpdateLevel(ctx context.Context, userID, level int) error {
 	query := "UPDATE playerinfo SET level = ? WHERE userid = ?"
-	_, err := r.db.Exec(query, level, userID)
+	_, err := r.db.ExecContext(ctx, query, level, userID)
 	return err
 }
 
+// UpdateProgress sets level and experience if they are unchanged since read
+func (r *mysqlPlayerRepository) UpdateProgress(ctx context.Context, userID, oldLevel, oldExperience, newLevel, newExperience int) (bool, error) {
+	query := "UPDATE playerinfo SET level = ?, experience = ? WHERE userid = ? AND level = ? AND experience = ?"
+	result, err := r.db.ExecContext(ctx, query, newLevel, newExperience, userID, oldLevel, oldExperience)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
 // UpdateBloodEnergy updates player blood energy
-func (r *mysqlPlayerRepository) UpdateBloodEnergy(userID, bloodEnergy int) error {
+func (r *mysqlPlayerRepository) UpdateBloodEnergy(ctx context.Context, userID, bloodEnergy int) error {
 	query := "UPDATE playerinfo SET bloodenergy = ? WHERE userid = ?"
-	_, err := r.db.Exec(query, bloodEnergy, userID)
+	_, err := r.db.ExecContext(ctx, query, bloodEnergy, userID)
 	return err
-}
\ No newline at end of file
+}
+
+// UpdateEnergy writes the blood energy fields if they are unchanged since read
+func (r *mysqlPlayerRepository) UpdateEnergy(ctx context.Context, player *entity.PlayerInfo, oldEnergy int, oldUpdatedAt int64) (bool, error) {
+	query := `UPDATE playerinfo SET bloodenergy = ?, energy_updated_at = ?, energy_purchases = ?, energy_purchase_day = ?
+			  WHERE userid = ? AND bloodenergy = ? AND energy_updated_at = ?`
+	result, err := r.db.ExecContext(ctx, query,
+		player.BloodEnergy, player.EnergyUpdatedAt, player.EnergyPurchases, player.EnergyPurchaseDay,
+		player.UserID, oldEnergy, oldUpdatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// AdvanceGameLevel moves the game level to the stage after fromStage
+func (r *mysqlPlayerRepository) AdvanceGameLevel(ctx context.Context, userID, fromStage int) (bool, error) {
+	query := "UPDATE playerinfo SET gamelevel = gamelevel + 1 WHERE userid = ? AND gamelevel = ?"
+	result, err := r.db.ExecContext(ctx, query, userID, fromStage)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
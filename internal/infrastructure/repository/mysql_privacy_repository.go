@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlPrivacyRepository implements PrivacyRepository
+type mysqlPrivacyRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLPrivacyRepository creates a new MySQL privacy settings repository
+func NewMySQLPrivacyRepository(db *sql.DB) repository.PrivacyRepository {
+	return &mysqlPrivacyRepository{db: db}
+}
+
+// GetByUserID retrieves a user's privacy settings, or nil if they never changed them
+func (r *mysqlPrivacyRepository) GetByUserID(ctx context.Context, userID int) (*entity.PrivacySettings, error) {
+	query := `SELECT userid, friends_only, hide_equipment, hide_rankings FROM privacy_setting WHERE userid = ?`
+
+	settings := &entity.PrivacySettings{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID, &settings.FriendsOnly, &settings.HideEquipment, &settings.HideRankings,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get privacy settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Save stores a user's privacy settings
+func (r *mysqlPrivacyRepository) Save(ctx context.Context, settings *entity.PrivacySettings) error {
+	query := `INSERT INTO privacy_setting (userid, friends_only, hide_equipment, hide_rankings)
+			  VALUES (?, ?, ?, ?)
+			  ON DUPLICATE KEY UPDATE friends_only = VALUES(friends_only),
+			  hide_equipment = VALUES(hide_equipment), hide_rankings = VALUES(hide_rankings)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		settings.UserID, settings.FriendsOnly, settings.HideEquipment, settings.HideRankings,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save privacy settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlQuestRepository implements QuestRepository
+type mysqlQuestRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLQuestRepository creates a new MySQL quest repository
+func NewMySQLQuestRepository(db *sql.DB) repository.QuestRepository {
+	return &mysqlQuestRepository{db: db}
+}
+
+// GetByUserID retrieves a user's progress on every quest they advanced
+func (r *mysqlQuestRepository) GetByUserID(ctx context.Context, userID int) ([]*entity.QuestProgress, error) {
+	query := `SELECT userid, quest_id, period, progress, claimed FROM quest_progress WHERE userid = ?`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quest progress: %w", err)
+	}
+	defer rows.Close()
+
+	var progress []*entity.QuestProgress
+	for rows.Next() {
+		p := &entity.QuestProgress{}
+		if err := rows.Scan(&p.UserID, &p.QuestID, &p.Period, &p.Progress, &p.Claimed); err != nil {
+			return nil, fmt.Errorf("failed to scan quest progress: %w", err)
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, rows.Err()
+}
+
+// AddProgress adds amount to a quest's progress in period, capped at target
+func (r *mysqlQuestRepository) AddProgress(ctx context.Context, userID, questID, period, amount, target int) error {
+	return r.upsertProgress(ctx, "progress + VALUES(progress)", userID, questID, period, amount, target)
+}
+
+// RaiseProgress raises a quest's progress in period to value, capped at target
+func (r *mysqlQuestRepository) RaiseProgress(ctx context.Context, userID, questID, period, value, target int) error {
+	return r.upsertProgress(ctx, "GREATEST(progress, VALUES(progress))", userID, questID, period, value, target)
+}
+
+// upsertProgress stores progress in one statement. A row from an earlier
+// period restarts from value; otherwise samePeriod combines the stored
+// progress with value. MySQL applies the assignments in order, so period
+// is updated last.
+func (r *mysqlQuestRepository) upsertProgress(ctx context.Context, samePeriod string, userID, questID, period, value, target int) error {
+	query := `INSERT INTO quest_progress (userid, quest_id, period, progress) VALUES (?, ?, ?, ?)
+			  ON DUPLICATE KEY UPDATE
+			  progress = LEAST(IF(period = VALUES(period), ` + samePeriod + `, VALUES(progress)), ?),
+			  claimed = IF(period = VALUES(period), claimed, 0),
+			  period = VALUES(period)`
+
+	_, err := r.db.ExecContext(ctx, query, userID, questID, period, min(value, target), target)
+	if err != nil {
+		return fmt.Errorf("failed to update quest progress: %w", err)
+	}
+	return nil
+}
+
+// MarkClaimed marks a completed quest claimed in one conditional update, so
+// concurrent claims cannot both succeed
+func (r *mysqlQuestRepository) MarkClaimed(ctx context.Context, userID, questID, period, target int) (bool, error) {
+	query := `UPDATE quest_progress SET claimed = 1
+			  WHERE userid = ? AND quest_id = ? AND period = ? AND progress >= ? AND claimed = 0`
+
+	result, err := r.db.ExecContext(ctx, query, userID, questID, period, target)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim quest: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// UnmarkClaimed clears the claim of a quest in period
+func (r *mysqlQuestRepository) UnmarkClaimed(ctx context.Context, userID, questID, period int) error {
+	query := `UPDATE quest_progress SET claimed = 0 WHERE userid = ? AND quest_id = ? AND period = ?`
+	if _, err := r.db.ExecContext(ctx, query, userID, questID, period); err != nil {
+		return fmt.Errorf("failed to release quest claim: %w", err)
+	}
+	return nil
+}
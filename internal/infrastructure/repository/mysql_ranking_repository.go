@@ -1,9 +1,10 @@
 package repository
 
 import (
-	"database/sql"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
+	"database/sql"
 )
 
 // mysqlRankingRepository implements RankingRepository
@@ -16,12 +17,17 @@ func NewMySQLRankingRepository(db *sql.DB) repository.RankingRepository {
 	return &mysqlRankingRepository{db: db}
 }
 
-// GetRankingByType retrieves ranking by type with limit
-func (r *mysqlRankingRepository) GetRankingByType(rankType string, limit int) ([]*entity.Ranking, error) {
+// GetRankingByType retrieves ranking by type with limit. Accounts flagged by
+// the anti-cheat rules are left out until they are reviewed, and players who
+// hide their rankings are left out while they keep their positions.
+func (r *mysqlRankingRepository) GetRankingByType(ctx context.Context, rankType string, limit int) ([]*entity.Ranking, error) {
 	query := `SELECT id, userid, rank_type, rank_value, rank_position, updated_at 
-			  FROM ranking WHERE rank_type = ? ORDER BY rank_position ASC LIMIT ?`
+			  FROM ranking WHERE rank_type = ?
+			  AND userid NOT IN (SELECT userid FROM account_flag)
+			  AND userid NOT IN (SELECT userid FROM privacy_setting WHERE hide_rankings = 1)
+			  ORDER BY rank_position ASC LIMIT ?`
 	
-	rows, err := r.db.Query(query, rankType, limit)
+	rows, err := r.db.QueryContext(ctx, query, rankType, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -44,23 +50,23 @@ func (r *mysqlRankingRepository) GetRankingByType(rankType string, limit int) ([
 }
 
 // UpdateUserRanking updates or creates user ranking
-func (r *mysqlRankingRepository) UpdateUserRanking(userID int, rankType string, value int) error {
+func (r *mysqlRankingRepository) UpdateUserRanking(ctx context.Context, userID int, rankType string, value int) error {
 	// Use INSERT ... ON DUPLICATE KEY UPDATE for upsert
 	query := `INSERT INTO ranking (userid, rank_type, rank_value, rank_position) 
 			  VALUES (?, ?, ?, 0) 
 			  ON DUPLICATE KEY UPDATE rank_value = ?, updated_at = CURRENT_TIMESTAMP`
 	
-	_, err := r.db.Exec(query, userID, rankType, value, value)
+	_, err := r.db.ExecContext(ctx, query, userID, rankType, value, value)
 	return err
 }
 
 // GetUserRanking retrieves user's ranking for a specific type
-func (r *mysqlRankingRepository) GetUserRanking(userID int, rankType string) (*entity.Ranking, error) {
+func (r *mysqlRankingRepository) GetUserRanking(ctx context.Context, userID int, rankType string) (*entity.Ranking, error) {
 	ranking := &entity.Ranking{}
 	query := `SELECT id, userid, rank_type, rank_value, rank_position, updated_at 
 			  FROM ranking WHERE userid = ? AND rank_type = ?`
 	
-	err := r.db.QueryRow(query, userID, rankType).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID, rankType).Scan(
 		&ranking.ID, &ranking.UserID, &ranking.RankType,
 		&ranking.RankValue, &ranking.RankPosition, &ranking.UpdatedAt,
 	)
@@ -73,35 +79,55 @@ func (r *mysqlRankingRepository) GetUserRanking(userID int, rankType string) (*e
 	return ranking, nil
 }
 
-// RefreshRankings recalculates and updates rank positions for a specific type
-func (r *mysqlRankingRepository) RefreshRankings(rankType string) error {
-	tx, err := r.db.Begin()
+// RefreshRankings recalculates and updates rank positions for a specific type.
+// Flagged accounts and players who hide their rankings get position 0, so no
+// stale rank is shown for them and the others are numbered without gaps.
+func (r *mysqlRankingRepository) RefreshRankings(ctx context.Context, rankType string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Get all rankings for this type ordered by value descending
-	query := `SELECT id FROM ranking WHERE rank_type = ? ORDER BY rank_value DESC`
-	rows, err := tx.Query(query, rankType)
+	unrankQuery := `UPDATE ranking SET rank_position = 0 WHERE rank_type = ?
+			  AND (userid IN (SELECT userid FROM account_flag)
+			  OR userid IN (SELECT userid FROM privacy_setting WHERE hide_rankings = 1))`
+	if _, err := tx.ExecContext(ctx, unrankQuery, rankType); err != nil {
+		return err
+	}
+
+	// Get the remaining rankings for this type ordered by value descending
+	query := `SELECT id FROM ranking WHERE rank_type = ?
+			  AND userid NOT IN (SELECT userid FROM account_flag)
+			  AND userid NOT IN (SELECT userid FROM privacy_setting WHERE hide_rankings = 1)
+			  ORDER BY rank_value DESC`
+	rows, err := tx.QueryContext(ctx, query, rankType)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	// Update rank positions
-	position := 1
-	updateQuery := "UPDATE ranking SET rank_position = ? WHERE id = ?"
+	// Read every ID before updating; the transaction's connection cannot run
+	// statements while rows are still being read
+	var ids []int
 	for rows.Next() {
 		var id int
 		if err := rows.Scan(&id); err != nil {
 			return err
 		}
-		
-		if _, err := tx.Exec(updateQuery, position, id); err != nil {
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// Update rank positions
+	updateQuery := "UPDATE ranking SET rank_position = ? WHERE id = ?"
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, updateQuery, i+1, id); err != nil {
 			return err
 		}
-		position++
 	}
 
 	return tx.Commit()
@@ -1,9 +1,10 @@
 package repository
 
 import (
-	"database/sql"
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
+	"database/sql"
 )
 
 // mysqlSourceStoneRepository implements SourceStoneRepository
@@ -17,11 +18,11 @@ func NewMySQLSourceStoneRepository(db *sql.DB) repository.SourceStoneRepository
 }
 
 // GetByUserID retrieves all source stones for a user
-func (r *mysqlSourceStoneRepository) GetByUserID(userID int) ([]*entity.SourceStone, error) {
+func (r *mysqlSourceStoneRepository) GetByUserID(ctx context.Context, userID int) ([]*entity.SourceStone, error) {
 	query := `SELECT equipid, sourcetype, count, quality, userid 
 			  FROM sourcestone WHERE userid = ?`
 	
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -44,12 +45,12 @@ func (r *mysqlSourceStoneRepository) GetByUserID(userID int) ([]*entity.SourceSt
 }
 
 // GetByEquipID retrieves source stone by equipment ID
-func (r *mysqlSourceStoneRepository) GetByEquipID(equipID int) (*entity.SourceStone, error) {
+func (r *mysqlSourceStoneRepository) GetByEquipID(ctx context.Context, equipID int) (*entity.SourceStone, error) {
 	stone := &entity.SourceStone{}
 	query := `SELECT equipid, sourcetype, count, quality, userid 
 			  FROM sourcestone WHERE equipid = ?`
 	
-	err := r.db.QueryRow(query, equipID).Scan(
+	err := r.db.QueryRowContext(ctx, query, equipID).Scan(
 		&stone.EquipID, &stone.SourceType, &stone.Count,
 		&stone.Quality, &stone.UserID,
 	)
@@ -63,11 +64,11 @@ func (r *mysqlSourceStoneRepository) GetByEquipID(equipID int) (*entity.SourceSt
 }
 
 // Create creates a new source stone
-func (r *mysqlSourceStoneRepository) Create(sourceStone *entity.SourceStone) error {
+func (r *mysqlSourceStoneRepository) Create(ctx context.Context, sourceStone *entity.SourceStone) error {
 	query := `INSERT INTO sourcestone (equipid, sourcetype, count, quality, userid) 
 			  VALUES (?, ?, ?, ?, ?)`
 	
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		sourceStone.EquipID, sourceStone.SourceType, sourceStone.Count,
 		sourceStone.Quality, sourceStone.UserID,
 	)
@@ -75,11 +76,11 @@ func (r *mysqlSourceStoneRepository) Create(sourceStone *entity.SourceStone) err
 }
 
 // Update updates an existing source stone
-func (r *mysqlSourceStoneRepository) Update(sourceStone *entity.SourceStone) error {
+func (r *mysqlSourceStoneRepository) Update(ctx context.Context, sourceStone *entity.SourceStone) error {
 	query := `UPDATE sourcestone SET sourcetype = ?, count = ?, quality = ?, userid = ? 
 			  WHERE equipid = ?`
 	
-	_, err := r.db.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		sourceStone.SourceType, sourceStone.Count, sourceStone.Quality,
 		sourceStone.UserID, sourceStone.EquipID,
 	)
@@ -87,15 +88,15 @@ func (r *mysqlSourceStoneRepository) Update(sourceStone *entity.SourceStone) err
 }
 
 // Delete deletes a source stone by equipment ID
-func (r *mysqlSourceStoneRepository) Delete(equipID int) error {
+func (r *mysqlSourceStoneRepository) Delete(ctx context.Context, equipID int) error {
 	query := "DELETE FROM sourcestone WHERE equipid = ?"
-	_, err := r.db.Exec(query, equipID)
+	_, err := r.db.ExecContext(ctx, query, equipID)
 	return err
 }
 
 // UpdateCount updates the count of a source stone
-func (r *mysqlSourceStoneRepository) UpdateCount(equipID, count int) error {
+func (r *mysqlSourceStoneRepository) UpdateCount(ctx context.Context, equipID, count int) error {
 	query := "UPDATE sourcestone SET count = ? WHERE equipid = ?"
-	_, err := r.db.Exec(query, count, equipID)
+	_, err := r.db.ExecContext(ctx, query, count, equipID)
 	return err
 }
\ No newline at end of file
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlStageRepository implements StageRepository
+type mysqlStageRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLStageRepository creates a new MySQL stage repository
+func NewMySQLStageRepository(db *sql.DB) repository.StageRepository {
+	return &mysqlStageRepository{db: db}
+}
+
+// GetByUserID retrieves all stage records for a user, ordered by stage
+func (r *mysqlStageRepository) GetByUserID(ctx context.Context, userID int) ([]*entity.StageRecord, error) {
+	query := `SELECT userid, stage, stars, best_time, clear_count, first_cleared_at
+			  FROM stage_record WHERE userid = ? ORDER BY stage`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stage records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*entity.StageRecord
+	for rows.Next() {
+		record := &entity.StageRecord{}
+		if err := rows.Scan(
+			&record.UserID, &record.Stage, &record.Stars,
+			&record.BestTime, &record.ClearCount, &record.FirstClearedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stage record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// GetByStage retrieves the record of one stage, or nil if never cleared
+func (r *mysqlStageRepository) GetByStage(ctx context.Context, userID, stage int) (*entity.StageRecord, error) {
+	query := `SELECT userid, stage, stars, best_time, clear_count, first_cleared_at
+			  FROM stage_record WHERE userid = ? AND stage = ?`
+
+	record := &entity.StageRecord{}
+	err := r.db.QueryRowContext(ctx, query, userID, stage).Scan(
+		&record.UserID, &record.Stage, &record.Stars,
+		&record.BestTime, &record.ClearCount, &record.FirstClearedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get stage record: %w", err)
+	}
+	return record, nil
+}
+
+// RecordClear inserts the first clear of a stage or updates the existing
+// record in one statement, so concurrent clears cannot both count as first
+func (r *mysqlStageRepository) RecordClear(ctx context.Context, userID, stage, clearTime, stars int) (bool, error) {
+	query := `INSERT INTO stage_record (userid, stage, stars, best_time, clear_count)
+			  VALUES (?, ?, ?, ?, 1)
+			  ON DUPLICATE KEY UPDATE
+			  stars = GREATEST(stars, VALUES(stars)),
+			  best_time = LEAST(best_time, VALUES(best_time)),
+			  clear_count = clear_count + 1`
+
+	result, err := r.db.ExecContext(ctx, query, userID, stage, stars, clearTime)
+	if err != nil {
+		return false, fmt.Errorf("failed to record stage clear: %w", err)
+	}
+
+	// MySQL reports 1 affected row for an insert and 2 for an update
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlSuspicionRepository implements SuspicionRepository
+type mysqlSuspicionRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLSuspicionRepository creates a new MySQL suspicion repository
+func NewMySQLSuspicionRepository(db *sql.DB) repository.SuspicionRepository {
+	return &mysqlSuspicionRepository{db: db}
+}
+
+// Record appends a rule violation to the suspicion log
+func (r *mysqlSuspicionRepository) Record(ctx context.Context, suspicion *entity.Suspicion) error {
+	query := `INSERT INTO suspicion_log (userid, rule, detail, payload) VALUES (?, ?, ?, ?)`
+
+	result, err := r.db.ExecContext(ctx, query,
+		suspicion.UserID, suspicion.Rule, suspicion.Detail, suspicion.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to record suspicion: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err == nil {
+		suspicion.ID = int(id)
+	}
+	return nil
+}
+
+// CountSince counts the violations recorded for a user since the given time
+func (r *mysqlSuspicionRepository) CountSince(ctx context.Context, userID int, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM suspicion_log WHERE userid = ? AND created_at >= ?`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count suspicions: %w", err)
+	}
+	return count, nil
+}
+
+// FlagAccount flags a user for review; an existing flag is kept as it is
+func (r *mysqlSuspicionRepository) FlagAccount(ctx context.Context, userID int, reason string) (bool, error) {
+	query := `INSERT IGNORE INTO account_flag (userid, reason) VALUES (?, ?)`
+
+	result, err := r.db.ExecContext(ctx, query, userID, reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to flag account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
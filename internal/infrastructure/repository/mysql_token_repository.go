@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlTokenRepository implements TokenRepository
+type mysqlTokenRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLTokenRepository creates a new MySQL API token repository
+func NewMySQLTokenRepository(db *sql.DB) repository.TokenRepository {
+	return &mysqlTokenRepository{db: db}
+}
+
+// Create stores a newly issued token
+func (r *mysqlTokenRepository) Create(ctx context.Context, token *entity.APIToken) error {
+	query := `INSERT INTO api_token (token_hash, userid, expires_at) VALUES (?, ?, ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, token.TokenHash, token.UserID, token.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create api token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash retrieves a token by its hash, or nil if it does not exist
+func (r *mysqlTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entity.APIToken, error) {
+	query := `SELECT token_hash, userid, expires_at FROM api_token WHERE token_hash = ?`
+
+	token := &entity.APIToken{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&token.TokenHash, &token.UserID, &token.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+	return token, nil
+}
+
+// Delete removes a token
+func (r *mysqlTokenRepository) Delete(ctx context.Context, tokenHash string) error {
+	query := `DELETE FROM api_token WHERE token_hash = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, tokenHash); err != nil {
+		return fmt.Errorf("failed to delete api token: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes a user's tokens that expired before the given time
+func (r *mysqlTokenRepository) DeleteExpired(ctx context.Context, userID int, before time.Time) error {
+	query := `DELETE FROM api_token WHERE userid = ? AND expires_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, before); err != nil {
+		return fmt.Errorf("failed to delete expired api tokens: %w", err)
+	}
+	return nil
+}
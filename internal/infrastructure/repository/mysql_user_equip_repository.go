@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -19,10 +20,10 @@ func NewMySQLUserEquipRepository(db *sql.DB) repository.UserEquipRepository {
 }
 
 // GetUserEquippedItems retrieves all equipped items for a user
-func (r *MySQLUserEquipRepository) GetUserEquippedItems(userID int) ([]*entity.UserEquip, error) {
+func (r *MySQLUserEquipRepository) GetUserEquippedItems(ctx context.Context, userID int) ([]*entity.UserEquip, error) {
 	query := `SELECT id, userid, equip_slot, equipid FROM user_equip WHERE userid = ?`
 	
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user equipped items: %w", err)
 	}
@@ -50,13 +51,13 @@ func (r *MySQLUserEquipRepository) GetUserEquippedItems(userID int) ([]*entity.U
 }
 
 // GetUserEquipBySlot retrieves equipment for a specific slot
-func (r *MySQLUserEquipRepository) GetUserEquipBySlot(userID int, slot string) (*entity.UserEquip, error) {
+func (r *MySQLUserEquipRepository) GetUserEquipBySlot(ctx context.Context, userID int, slot string) (*entity.UserEquip, error) {
 	query := `SELECT id, userid, equip_slot, equipid FROM user_equip WHERE userid = ? AND equip_slot = ?`
 	
 	var ue entity.UserEquip
 	var equipID sql.NullInt32
 	
-	err := r.db.QueryRow(query, userID, slot).Scan(&ue.ID, &ue.UserID, &ue.EquipSlot, &equipID)
+	err := r.db.QueryRowContext(ctx, query, userID, slot).Scan(&ue.ID, &ue.UserID, &ue.EquipSlot, &equipID)
 	if err == sql.ErrNoRows {
 		return nil, nil // No equipment in this slot
 	}
@@ -73,9 +74,9 @@ func (r *MySQLUserEquipRepository) GetUserEquipBySlot(userID int, slot string) (
 }
 
 // UpdateUserEquip updates equipment for a specific slot
-func (r *MySQLUserEquipRepository) UpdateUserEquip(userEquip *entity.UserEquip) error {
+func (r *MySQLUserEquipRepository) UpdateUserEquip(ctx context.Context, userEquip *entity.UserEquip) error {
 	// First check if a record exists for this user and slot
-	existing, err := r.GetUserEquipBySlot(userEquip.UserID, userEquip.EquipSlot)
+	existing, err := r.GetUserEquipBySlot(ctx, userEquip.UserID, userEquip.EquipSlot)
 	if err != nil {
 		return fmt.Errorf("failed to check existing user equip: %w", err)
 	}
@@ -83,14 +84,14 @@ func (r *MySQLUserEquipRepository) UpdateUserEquip(userEquip *entity.UserEquip)
 	if existing == nil {
 		// Insert new record
 		query := `INSERT INTO user_equip (userid, equip_slot, equipid) VALUES (?, ?, ?)`
-		_, err = r.db.Exec(query, userEquip.UserID, userEquip.EquipSlot, userEquip.EquipID)
+		_, err = r.db.ExecContext(ctx, query, userEquip.UserID, userEquip.EquipSlot, userEquip.EquipID)
 		if err != nil {
 			return fmt.Errorf("failed to insert user equip: %w", err)
 		}
 	} else {
 		// Update existing record
 		query := `UPDATE user_equip SET equipid = ? WHERE userid = ? AND equip_slot = ?`
-		_, err = r.db.Exec(query, userEquip.EquipID, userEquip.UserID, userEquip.EquipSlot)
+		_, err = r.db.ExecContext(ctx, query, userEquip.EquipID, userEquip.UserID, userEquip.EquipSlot)
 		if err != nil {
 			return fmt.Errorf("failed to update user equip: %w", err)
 		}
@@ -100,10 +101,10 @@ func (r *MySQLUserEquipRepository) UpdateUserEquip(userEquip *entity.UserEquip)
 }
 
 // UnequipItem removes equipment from a slot (sets equipid to NULL)
-func (r *MySQLUserEquipRepository) UnequipItem(userID int, slot string) error {
+func (r *MySQLUserEquipRepository) UnequipItem(ctx context.Context, userID int, slot string) error {
 	query := `UPDATE user_equip SET equipid = NULL WHERE userid = ? AND equip_slot = ?`
 	
-	result, err := r.db.Exec(query, userID, slot)
+	result, err := r.db.ExecContext(ctx, query, userID, slot)
 	if err != nil {
 		return fmt.Errorf("failed to unequip item: %w", err)
 	}
@@ -116,7 +117,7 @@ func (r *MySQLUserEquipRepository) UnequipItem(userID int, slot string) error {
 	if rowsAffected == 0 {
 		// No existing record, create one with NULL equipid
 		insertQuery := `INSERT INTO user_equip (userid, equip_slot, equipid) VALUES (?, ?, NULL)`
-		_, err = r.db.Exec(insertQuery, userID, slot)
+		_, err = r.db.ExecContext(ctx, insertQuery, userID, slot)
 		if err != nil {
 			return fmt.Errorf("failed to create empty equip slot: %w", err)
 		}
@@ -126,9 +127,9 @@ func (r *MySQLUserEquipRepository) UnequipItem(userID int, slot string) error {
 }
 
 // InitializeUserEquipSlots creates initial empty slots for a new user
-func (r *MySQLUserEquipRepository) InitializeUserEquipSlots(userID int) error {
+func (r *MySQLUserEquipRepository) InitializeUserEquipSlots(ctx context.Context, userID int) error {
 	// Begin transaction
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -138,7 +139,7 @@ func (r *MySQLUserEquipRepository) InitializeUserEquipSlots(userID int) error {
 	query := `INSERT INTO user_equip (userid, equip_slot, equipid) VALUES (?, ?, NULL)`
 	
 	for _, slot := range entity.ValidEquipSlots {
-		_, err = tx.Exec(query, userID, slot)
+		_, err = tx.ExecContext(ctx, query, userID, slot)
 		if err != nil {
 			return fmt.Errorf("failed to initialize equip slot %s: %w", slot, err)
 		}
@@ -154,7 +155,7 @@ func (r *MySQLUserEquipRepository) InitializeUserEquipSlots(userID int) error {
 }
 
 // GetEquippedItemDetails retrieves full equipment details for equipped items
-func (r *MySQLUserEquipRepository) GetEquippedItemDetails(userID int) ([]*entity.Equipment, error) {
+func (r *MySQLUserEquipRepository) GetEquippedItemDetails(ctx context.Context, userID int) ([]*entity.Equipment, error) {
 	query := `
 		SELECT 
 			e.equipid, e.quality, e.damage, e.crit, e.critdamage, e.damagespeed,
@@ -165,7 +166,7 @@ func (r *MySQLUserEquipRepository) GetEquippedItemDetails(userID int) ([]*entity
 		WHERE ue.userid = ? AND ue.equipid IS NOT NULL
 	`
 	
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get equipped item details: %w", err)
 	}
@@ -3,6 +3,7 @@ package repository
 import (
 	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/repository"
+	"context"
 	"database/sql"
 )
 
@@ -17,10 +18,10 @@ func NewMySQLUserRepository(db *sql.DB) repository.UserRepository {
 }
 
 // GetByID retrieves a user by ID
-func (r *mysqlUserRepository) GetByID(id int) (*entity.User, error) {
+func (r *mysqlUserRepository) GetByID(ctx context.Context, id int) (*entity.User, error) {
 	user := &entity.User{}
 	query := "SELECT userid, username, online_status FROM user WHERE userid = ?"
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.OnlineStatus)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.OnlineStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -31,10 +32,10 @@ func (r *mysqlUserRepository) GetByID(id int) (*entity.User, error) {
 }
 
 // GetByUsername retrieves a user by username
-func (r *mysqlUserRepository) GetByUsername(username string) (*entity.User, error) {
+func (r *mysqlUserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
 	user := &entity.User{}
 	query := "SELECT userid, username, online_status FROM user WHERE username = ?"
-	err := r.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.OnlineStatus)
+	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.OnlineStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -45,9 +46,9 @@ func (r *mysqlUserRepository) GetByUsername(username string) (*entity.User, erro
 }
 
 // Create creates a new user
-func (r *mysqlUserRepository) Create(user *entity.User) error {
+func (r *mysqlUserRepository) Create(ctx context.Context, user *entity.User) error {
 	query := "INSERT INTO user (username, password) VALUES (?, ?)"
-	result, err := r.db.Exec(query, user.Username, user.Password)
+	result, err := r.db.ExecContext(ctx, query, user.Username, user.Password)
 	if err != nil {
 		return err
 	}
@@ -62,24 +63,24 @@ func (r *mysqlUserRepository) Create(user *entity.User) error {
 }
 
 // Update updates an existing user
-func (r *mysqlUserRepository) Update(user *entity.User) error {
+func (r *mysqlUserRepository) Update(ctx context.Context, user *entity.User) error {
 	query := "UPDATE user SET username = ?, password = ? WHERE userid = ?"
-	_, err := r.db.Exec(query, user.Username, user.Password, user.ID)
+	_, err := r.db.ExecContext(ctx, query, user.Username, user.Password, user.ID)
 	return err
 }
 
 // Delete deletes a user by ID
-func (r *mysqlUserRepository) Delete(id int) error {
+func (r *mysqlUserRepository) Delete(ctx context.Context, id int) error {
 	query := "DELETE FROM user WHERE userid = ?"
-	_, err := r.db.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
 
 // Exists checks if a user with the given username exists
-func (r *mysqlUserRepository) Exists(username string) (bool, error) {
+func (r *mysqlUserRepository) Exists(ctx context.Context, username string) (bool, error) {
 	var count int
 	query := "SELECT COUNT(*) FROM user WHERE username = ?"
-	err := r.db.QueryRow(query, username).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, username).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -87,11 +88,11 @@ func (r *mysqlUserRepository) Exists(username string) (bool, error) {
 }
 
 // VerifyCredentials verifies user credentials and returns user if valid
-func (r *mysqlUserRepository) VerifyCredentials(username, password string) (*entity.User, error) {
+func (r *mysqlUserRepository) VerifyCredentials(ctx context.Context, username, password string) (*entity.User, error) {
 	user := &entity.User{}
 	var storedPassword string
 	query := "SELECT userid, username, password, online_status FROM user WHERE username = ?"
-	err := r.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &storedPassword, &user.OnlineStatus)
+	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &storedPassword, &user.OnlineStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -105,8 +106,8 @@ func (r *mysqlUserRepository) VerifyCredentials(username, password string) (*ent
 }
 
 // UpdateOnlineStatus updates the online status of a user
-func (r *mysqlUserRepository) UpdateOnlineStatus(userID int, status int) error {
+func (r *mysqlUserRepository) UpdateOnlineStatus(ctx context.Context, userID int, status int) error {
 	query := "UPDATE user SET online_status = ? WHERE userid = ?"
-	_, err := r.db.Exec(query, status, userID)
+	_, err := r.db.ExecContext(ctx, query, status, userID)
 	return err
 }
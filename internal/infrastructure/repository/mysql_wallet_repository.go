@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/repository"
+)
+
+// mysqlWalletRepository implements WalletRepository
+type mysqlWalletRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLWalletRepository creates a new MySQL wallet repository
+func NewMySQLWalletRepository(db *sql.DB) repository.WalletRepository {
+	return &mysqlWalletRepository{db: db}
+}
+
+// GetBalances retrieves every balance a user holds
+func (r *mysqlWalletRepository) GetBalances(ctx context.Context, userID int) ([]*entity.CurrencyBalance, error) {
+	query := `SELECT userid, currency, balance FROM wallet_balance WHERE userid = ?`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []*entity.CurrencyBalance
+	for rows.Next() {
+		balance := &entity.CurrencyBalance{}
+		if err := rows.Scan(&balance.UserID, &balance.Currency, &balance.Balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, rows.Err()
+}
+
+// Apply appends the entries to the ledger and updates the balances in one
+// transaction. Balance rows are locked in a fixed order so that concurrent
+// transactions touching the same balances cannot deadlock.
+func (r *mysqlWalletRepository) Apply(ctx context.Context, entries []*entity.LedgerEntry) error {
+	ordered := make([]*entity.LedgerEntry, len(entries))
+	copy(ordered, entries)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].UserID != ordered[j].UserID {
+			return ordered[i].UserID < ordered[j].UserID
+		}
+		return ordered[i].Currency < ordered[j].Currency
+	})
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range ordered {
+		// Make sure the balance row exists so it can be locked
+		ensureQuery := `INSERT INTO wallet_balance (userid, currency, balance) VALUES (?, ?, 0)
+						ON DUPLICATE KEY UPDATE balance = balance`
+		if _, err := tx.ExecContext(ctx, ensureQuery, entry.UserID, entry.Currency); err != nil {
+			return fmt.Errorf("failed to create balance: %w", err)
+		}
+
+		var balance int
+		lockQuery := `SELECT balance FROM wallet_balance WHERE userid = ? AND currency = ? FOR UPDATE`
+		if err := tx.QueryRowContext(ctx, lockQuery, entry.UserID, entry.Currency).Scan(&balance); err != nil {
+			return fmt.Errorf("failed to lock balance: %w", err)
+		}
+
+		balance += entry.Amount
+		if balance < 0 {
+			return entity.ErrInsufficientBalance
+		}
+
+		updateQuery := `UPDATE wallet_balance SET balance = ? WHERE userid = ? AND currency = ?`
+		if _, err := tx.ExecContext(ctx, updateQuery, balance, entry.UserID, entry.Currency); err != nil {
+			return fmt.Errorf("failed to update balance: %w", err)
+		}
+
+		ledgerQuery := `INSERT INTO wallet_ledger (userid, currency, amount, balance_after, reason, reference_id)
+						VALUES (?, ?, ?, ?, ?, ?)`
+		result, err := tx.ExecContext(ctx, ledgerQuery,
+			entry.UserID, entry.Currency, entry.Amount, balance, entry.Reason, entry.ReferenceID)
+		if err != nil {
+			return fmt.Errorf("failed to append ledger entry: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		entry.ID = int(id)
+		entry.BalanceAfter = balance
+	}
+
+	return tx.Commit()
+}
+
+// GetHistory retrieves a user's ledger entries, newest first
+func (r *mysqlWalletRepository) GetHistory(ctx context.Context, userID int, currency string, beforeID, limit int) ([]*entity.LedgerEntry, error) {
+	query := `SELECT id, userid, currency, amount, balance_after, reason, reference_id, created_at
+			  FROM wallet_ledger WHERE userid = ?`
+	args := []interface{}{userID}
+	if currency != "" {
+		query += " AND currency = ?"
+		args = append(args, currency)
+	}
+	if beforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entity.LedgerEntry
+	for rows.Next() {
+		entry := &entity.LedgerEntry{}
+		if err := rows.Scan(
+			&entry.ID, &entry.UserID, &entry.Currency, &entry.Amount,
+			&entry.BalanceAfter, &entry.Reason, &entry.ReferenceID, &entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,164 @@
+// Package admin exposes operator endpoints under /admin/. They are only
+// reachable from addresses allowed by the admin network policy, and every
+// request must carry an operator's admin token.
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/valueobject"
+
+	"github.com/google/uuid"
+)
+
+// maxBodySize limits the size of a request body
+const maxBodySize = 1 << 20
+
+// maxAnnouncementLength is the longest announcement in characters
+const maxAnnouncementLength = 500
+
+// MailServiceInterface defines the mail operations used by the admin API
+type MailServiceInterface interface {
+	SendBulk(ctx context.Context, req *dto.BulkMailRequest) (*dto.BulkMailResponse, error)
+}
+
+// TopicPublisher pushes events to the clients subscribed to a topic
+type TopicPublisher interface {
+	Publish(topic, event string, data interface{})
+}
+
+// API serves the admin endpoints
+type API struct {
+	mailService MailServiceInterface
+	publisher   TopicPublisher
+	// tokens maps each operator to the SHA-256 of their token, so every
+	// comparison takes the same time whatever the token's length
+	tokens map[string][sha256.Size]byte
+}
+
+// NewAPI creates a new admin API accepting the given operator tokens.
+// Announcements are published through publisher.
+func NewAPI(mailService MailServiceInterface, publisher TopicPublisher, tokens map[string]string) *API {
+	hashed := make(map[string][sha256.Size]byte, len(tokens))
+	for operator, token := range tokens {
+		hashed[operator] = sha256.Sum256([]byte(token))
+	}
+	return &API{mailService: mailService, publisher: publisher, tokens: hashed}
+}
+
+// operator returns the operator whose token the request carries as a
+// bearer token, or "" if it carries none of them
+func (a *API) operator(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+
+	// Compare against every token so the time taken does not reveal which
+	// operator matched
+	matched := ""
+	for operator, want := range a.tokens {
+		if subtle.ConstantTimeCompare(sum[:], want[:]) == 1 {
+			matched = operator
+		}
+	}
+	return matched
+}
+
+// RegisterRoutes registers the admin endpoints on mux
+func (a *API) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /admin/mail", a.handleSendMail)
+	mux.HandleFunc("POST /admin/announcements", a.handleAnnounce)
+}
+
+// authorize returns the request ID and the operator making the request. A
+// request without a valid admin token is answered and ok is false.
+func (a *API) authorize(w http.ResponseWriter, r *http.Request, what string) (requestID, operator string, ok bool) {
+	requestID = r.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	operator = a.operator(r)
+	if operator == "" {
+		log.Printf("Rejected admin %s request %s from %s: missing or unknown admin token", what, requestID, r.RemoteAddr)
+		writeResponse(w, http.StatusUnauthorized, valueobject.NewErrorResponse(requestID, valueobject.CodeUnauthorized, "Admin token required"))
+		return requestID, "", false
+	}
+	return requestID, operator, true
+}
+
+// handleSendMail sends a system mail to all users or a filtered set
+func (a *API) handleSendMail(w http.ResponseWriter, r *http.Request) {
+	requestID, operator, ok := a.authorize(w, r, "mail")
+	if !ok {
+		return
+	}
+
+	var req dto.BulkMailRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, valueobject.NewErrorResponse(requestID, valueobject.CodeInvalidRequest, "Invalid mail data"))
+		return
+	}
+
+	response, err := a.mailService.SendBulk(r.Context(), &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			writeResponse(w, http.StatusBadRequest, valueobject.NewErrorResponse(requestID, valueobject.CodeValidationError, err.Error()))
+			return
+		}
+		// Internal errors may carry database details; they only go to the log
+		log.Printf("Failed to send bulk mail for admin %s (request %s): %v", operator, requestID, err)
+		writeResponse(w, http.StatusInternalServerError, valueobject.NewErrorResponse(requestID, valueobject.CodeInternalError, "Failed to send mail"))
+		return
+	}
+
+	log.Printf("Admin %s sent bulk mail %q to %d users (request %s)", operator, req.Title, response.Sent, requestID)
+	writeResponse(w, http.StatusOK, valueobject.NewSuccessResponse(requestID, response))
+}
+
+// handleAnnounce publishes an announcement to the clients subscribed to the
+// announcements topic on every node
+func (a *API) handleAnnounce(w http.ResponseWriter, r *http.Request) {
+	requestID, operator, ok := a.authorize(w, r, "announcement")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, valueobject.NewErrorResponse(requestID, valueobject.CodeInvalidRequest, "Invalid announcement data"))
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" || utf8.RuneCountInString(req.Message) > maxAnnouncementLength {
+		writeResponse(w, http.StatusBadRequest, valueobject.NewErrorResponse(requestID, valueobject.CodeValidationError, "announcement must be 1 to 500 characters"))
+		return
+	}
+
+	a.publisher.Publish(valueobject.TopicAnnouncements, valueobject.EventAnnouncement, map[string]interface{}{"message": req.Message})
+
+	log.Printf("Admin %s published an announcement (request %s)", operator, requestID)
+	writeResponse(w, http.StatusOK, valueobject.NewSuccessResponse(requestID, map[string]string{"message": req.Message}))
+}
+
+// writeResponse writes the response envelope with the given HTTP status
+func writeResponse(w http.ResponseWriter, status int, response *valueobject.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to write admin response: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/valueobject"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeMailService records the bulk mail it is asked to send, or fails with err
+type fakeMailService struct {
+	sent []*dto.BulkMailRequest
+	err  error
+}
+
+func (s *fakeMailService) SendBulk(ctx context.Context, req *dto.BulkMailRequest) (*dto.BulkMailResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.sent = append(s.sent, req)
+	return &dto.BulkMailResponse{Sent: 3}, nil
+}
+
+// fakePublisher records the events it is asked to publish
+type fakePublisher struct {
+	topics []string
+	data   []interface{}
+}
+
+func (p *fakePublisher) Publish(topic, event string, data interface{}) {
+	p.topics = append(p.topics, topic)
+	p.data = append(p.data, data)
+}
+
+func TestSendMailRequiresAdminToken(t *testing.T) {
+	aliceToken := strings.Repeat("a", 40)
+	bobToken := strings.Repeat("b", 40)
+	operators := map[string]string{"alice": aliceToken, "bob": bobToken}
+
+	tests := []struct {
+		name          string
+		tokens        map[string]string
+		authorization string
+		wantStatus    int
+		wantOperator  string
+	}{
+		{name: "no header", tokens: operators, wantStatus: http.StatusUnauthorized},
+		{name: "unknown token", tokens: operators, authorization: "Bearer " + strings.Repeat("c", 40), wantStatus: http.StatusUnauthorized},
+		{name: "token prefix", tokens: operators, authorization: "Bearer " + aliceToken[:39], wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", tokens: operators, authorization: "Basic " + aliceToken, wantStatus: http.StatusUnauthorized},
+		{name: "empty bearer", tokens: operators, authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "no tokens configured", authorization: "Bearer " + aliceToken, wantStatus: http.StatusUnauthorized},
+		{name: "first operator", tokens: operators, authorization: "Bearer " + aliceToken, wantStatus: http.StatusOK, wantOperator: "alice"},
+		{name: "second operator", tokens: operators, authorization: "bearer " + bobToken, wantStatus: http.StatusOK, wantOperator: "bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mail := &fakeMailService{}
+			mux := http.NewServeMux()
+			api := NewAPI(mail, &fakePublisher{}, tt.tokens)
+			api.RegisterRoutes(mux)
+
+			var logged bytes.Buffer
+			log.SetOutput(&logged)
+			defer log.SetOutput(io.Discard)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/mail", strings.NewReader(`{"title":"Maintenance"}`))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantOperator == "" {
+				if len(mail.sent) != 0 {
+					t.Error("mail sent without a valid admin token")
+				}
+				return
+			}
+			if len(mail.sent) != 1 {
+				t.Fatalf("sent %d mails, want 1", len(mail.sent))
+			}
+			if !strings.Contains(logged.String(), "Admin "+tt.wantOperator+" sent bulk mail") {
+				t.Errorf("log %q does not name operator %s", logged.String(), tt.wantOperator)
+			}
+		})
+	}
+}
+
+func TestSendMailErrors(t *testing.T) {
+	token := strings.Repeat("a", 40)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+		wantLogged  bool // The error is logged with the request ID
+	}{
+		{name: "validation error", err: entity.NewDomainError("mail title is required"), wantStatus: http.StatusBadRequest, wantMessage: "mail title is required"},
+		{name: "internal error", err: errors.New("dial tcp 10.0.0.5:3306: connection refused"), wantStatus: http.StatusInternalServerError, wantMessage: "Failed to send mail", wantLogged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			NewAPI(&fakeMailService{err: tt.err}, &fakePublisher{}, map[string]string{"alice": token}).RegisterRoutes(mux)
+
+			var logged bytes.Buffer
+			log.SetOutput(&logged)
+			defer log.SetOutput(io.Discard)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/mail", strings.NewReader(`{"title":"Maintenance"}`))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("X-Request-ID", "req-42")
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			var response valueobject.Response
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if response.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", response.Message, tt.wantMessage)
+			}
+			if tt.wantLogged && (!strings.Contains(logged.String(), "req-42") || !strings.Contains(logged.String(), tt.err.Error())) {
+				t.Errorf("log %q does not hold the request ID and error", logged.String())
+			}
+		})
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	token := strings.Repeat("a", 40)
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          string
+		wantStatus    int
+		wantMessage   string
+	}{
+		{name: "publishes", authorization: "Bearer " + token, body: `{"message":"  Maintenance at 02:00  "}`, wantStatus: http.StatusOK, wantMessage: "Maintenance at 02:00"},
+		{name: "no token", body: `{"message":"Maintenance"}`, wantStatus: http.StatusUnauthorized},
+		{name: "empty message", authorization: "Bearer " + token, body: `{"message":"   "}`, wantStatus: http.StatusBadRequest},
+		{name: "message too long", authorization: "Bearer " + token, body: `{"message":"` + strings.Repeat("公", maxAnnouncementLength+1) + `"}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed body", authorization: "Bearer " + token, body: `{"message":`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			mux := http.NewServeMux()
+			NewAPI(&fakeMailService{}, publisher, map[string]string{"alice": token}).RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/announcements", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantMessage == "" {
+				if len(publisher.topics) != 0 {
+					t.Errorf("published %v for a rejected request", publisher.topics)
+				}
+				return
+			}
+			if len(publisher.topics) != 1 || publisher.topics[0] != valueobject.TopicAnnouncements {
+				t.Fatalf("published to %v, want [%s]", publisher.topics, valueobject.TopicAnnouncements)
+			}
+			if data := publisher.data[0].(map[string]interface{}); data["message"] != tt.wantMessage {
+				t.Errorf("published message %q, want %q", data["message"], tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/interfaces/websocket"
+
+	"github.com/google/uuid"
+)
+
+// maxBodySize limits the size of a request body
+const maxBodySize = 1 << 20
+
+// TokenServiceInterface defines the token operations used by the gateway
+type TokenServiceInterface interface {
+	IssueToken(ctx context.Context, req *dto.LoginRequest) (*dto.TokenResponse, error)
+	ValidateToken(ctx context.Context, token string) (int, error)
+	RevokeToken(ctx context.Context, token string) error
+}
+
+// Gateway exposes the WebSocket message handlers over HTTP. Each request is
+// dispatched through the hub's router on a synthetic client and answered with
+// the same response envelope a WebSocket client would receive.
+type Gateway struct {
+	hub    *websocket.Hub
+	tokens TokenServiceInterface
+}
+
+// NewGateway creates a new HTTP gateway
+func NewGateway(hub *websocket.Hub, tokens TokenServiceInterface) *Gateway {
+	return &Gateway{hub: hub, tokens: tokens}
+}
+
+// RegisterRoutes registers the gateway endpoints on mux
+func (g *Gateway) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/token", g.handleIssueToken)
+	mux.HandleFunc("DELETE /api/token", g.handleRevokeToken)
+	mux.HandleFunc("POST /api/{type}/{action}", g.handleMessage)
+}
+
+// handleIssueToken exchanges a username and password for a bearer token
+func (g *Gateway) handleIssueToken(w http.ResponseWriter, r *http.Request) {
+	requestID := requestIDFrom(r)
+
+	var req dto.LoginRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeResponse(w, valueobject.NewErrorResponse(requestID, valueobject.CodeInvalidRequest, "Invalid login data"))
+		return
+	}
+
+	token, err := g.tokens.IssueToken(r.Context(), &req)
+	if err != nil {
+		if err.Error() == "invalid username or password" {
+			writeResponse(w, valueobject.NewErrorResponse(requestID, valueobject.CodeUnauthorized, err.Error()))
+			return
+		}
+		writeResponse(w, valueobject.NewErrorResponse(requestID, valueobject.CodeInternalError, err.Error()))
+		return
+	}
+
+	writeResponse(w, valueobject.NewSuccessResponse(requestID, token))
+}
+
+// handleRevokeToken invalidates the bearer token of the request
+func (g *Gateway) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	requestID := requestIDFrom(r)
+
+	token := bearerToken(r)
+	if token == "" {
+		writeResponse(w, valueobject.NewErrorResponse(requestID, valueobject.CodeUnauthorized, "Authentication required"))
+		return
+	}
+
+	if err := g.tokens.RevokeToken(r.Context(), token); err != nil {
+		writeResponse(w, valueobject.NewErrorResponse(requestID, valueobject.CodeInternalError, err.Error()))
+		return
+	}
+
+	writeResponse(w, valueobject.NewSuccessResponse(requestID, map[string]string{"message": "Token revoked"}))
+}
+
+// handleMessage dispatches POST /api/{type}/{action}; the request body is the
+// message data
+func (g *Gateway) handleMessage(w http.ResponseWriter, r *http.Request) {
+	message := &valueobject.Message{
+		Type:      valueobject.MessageType(r.PathValue("type")),
+		Action:    valueobject.MessageAction(r.PathValue("action")),
+		RequestID: requestIDFrom(r),
+	}
+
+	// Login and logout bind a user to a connection, which HTTP does not have
+	if message.Type == valueobject.MessageTypeAuth &&
+		(message.Action == valueobject.ActionLogin || message.Action == valueobject.ActionLogout) {
+		writeResponse(w, valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Use /api/token to authenticate over HTTP"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeResponse(w, valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		body = []byte("{}")
+	}
+	if !json.Valid(body) {
+		writeResponse(w, valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid message format"))
+		return
+	}
+	message.Data = body
+
+	userID := 0
+	if token := bearerToken(r); token != "" {
+		userID, err = g.tokens.ValidateToken(r.Context(), token)
+		if err != nil {
+			if _, ok := err.(*entity.DomainError); ok {
+				writeResponse(w, valueobject.NewErrorResponse(message.RequestID, valueobject.CodeUnauthorized, err.Error()))
+				return
+			}
+			writeResponse(w, valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error()))
+			return
+		}
+	}
+
+	client := websocket.NewSyntheticClient(r.Context(), g.hub, userID)
+	defer client.Close()
+
+	response := client.ProcessMessage(message)
+	if response == nil {
+		// The caller went away before the handler finished
+		return
+	}
+	writeResponse(w, response)
+}
+
+// bearerToken extracts the token from the Authorization header
+func bearerToken(r *http.Request) string {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// requestIDFrom returns the caller's X-Request-ID or a new one
+func requestIDFrom(r *http.Request) string {
+	if requestID := r.Header.Get("X-Request-ID"); requestID != "" {
+		return requestID
+	}
+	return uuid.New().String()
+}
+
+// writeResponse writes the response envelope with a matching HTTP status
+func writeResponse(w http.ResponseWriter, response *valueobject.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(valueobject.ResponseCode(response.Code)))
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to write gateway response: %v", err)
+	}
+}
+
+// httpStatus maps a response code to an HTTP status
+func httpStatus(code valueobject.ResponseCode) int {
+	switch code {
+	case valueobject.CodeSuccess:
+		return http.StatusOK
+	case valueobject.CodeInvalidRequest, valueobject.CodeValidationError:
+		return http.StatusBadRequest
+	case valueobject.CodeUnauthorized:
+		return http.StatusUnauthorized
+	case valueobject.CodeForbidden:
+		return http.StatusForbidden
+	case valueobject.CodeNotFound:
+		return http.StatusNotFound
+	case valueobject.CodeConflict:
+		return http.StatusConflict
+	case valueobject.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case valueobject.CodeTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
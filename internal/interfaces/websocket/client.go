@@ -2,8 +2,13 @@ package websocket
 
 import (
 	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/config"
+	"GameServer/pkg/metrics"
+	"context"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,26 +25,110 @@ var upgrader = websocket.Upgrader{
 // Client represents a WebSocket client connection
 type Client struct {
 	ID       string          // Client unique ID
-	UserID   int             // User ID (set after authentication)
+	UserID   int             // User ID (set after authentication), guarded by authMu
 	Conn     *websocket.Conn // WebSocket connection
 	Send     chan []byte     // Send message channel
 	Hub      *Hub            // Owning hub
-	IsAuth   bool            // Authentication status
-	LastPing time.Time       // Last ping time
+	IsAuth   bool            // Authentication status, guarded by authMu
+	LastPing time.Time       // Last ping time, guarded by livenessMu
+
+	authMu sync.RWMutex // Guards UserID and IsAuth, which handlers and timers read concurrently
+
+	livenessMu   sync.Mutex // Guards LastPing and lastActivity
+	lastActivity time.Time  // Last message other than a heartbeat, for the idle timeout
+
+	remoteIP   string      // Address the connection was accepted from
+	loginTimer *time.Timer // Closes the connection if the client does not log in in time
+	synthetic  bool        // Set for gateway clients, which authenticate with bearer tokens instead
+	signer     sessionSigner
+
+	topics       map[string]string // Subscribed topics to their routing keys, guarded by Hub.topicMu
+	topicsClosed bool              // Set once the client is unregistered, guarded by Hub.topicMu
+
+	ctx    context.Context    // Cancelled when the connection goes away
+	cancel context.CancelFunc // Cancels ctx and every request derived from it
+
+	requests   chan *valueobject.Message // Parsed messages waiting to be dispatched
+	recvMu     sync.RWMutex              // Guards recvClosed so messages are never queued on a closed channel
+	recvClosed bool                      // Set once requests has been closed
+	inFlight   chan struct{}             // Semaphore bounding concurrent read-only handlers
+	ordering   sync.RWMutex              // Orders requests sent before login; afterwards the user's lock in Hub.ordering is used
+	handlers   sync.WaitGroup            // Tracks the dispatcher and running handlers
+
+	sendMu         sync.RWMutex  // Guards sendClosed so frames are never queued on a closed channel
+	sendClosed     bool          // Set once Send has been closed
+	disconnectOnce sync.Once     // Ensures only one close frame is sent
+	writeDone      chan struct{} // Closed when WritePump returns
+	streamDone     chan struct{} // Closed by Disconnect to end an SSE stream; nil for WebSocket clients
+	streamToken    string        // Secret an SSE client sends with each posted message
+	closeCode      int           // Code passed to Disconnect, reported at the end of an SSE stream
+	closeReason    string        // Reason passed to Disconnect
+
+	invalidFrames atomic.Int32 // Consecutive frames that could not be parsed
 }
 
+// maxInvalidFrames is how many unparseable frames in a row a client may send
+// before it is disconnected with CloseProtocolError
+const maxInvalidFrames = 5
+
 // NewClient creates a new client instance
 func NewClient(conn *websocket.Conn, hub *Hub) *Client {
+	maxInFlight := hub.Config.MaxInFlightRequests
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sendBufferSize := hub.Config.SendBufferSize
+	if sendBufferSize <= 0 {
+		sendBufferSize = 256
+	}
+
+	return &Client{
+		ID:        uuid.New().String(),
+		Conn:      conn,
+		Send:      make(chan []byte, sendBufferSize),
+		Hub:       hub,
+		IsAuth:    false,
+		LastPing:  time.Now(),
+		requests:  make(chan *valueobject.Message, maxInFlight),
+		inFlight:  make(chan struct{}, maxInFlight),
+		ctx:       ctx,
+		cancel:    cancel,
+		writeDone: make(chan struct{}),
+
+		lastActivity: time.Now(),
+	}
+}
+
+// NewSyntheticClient creates a client that is not backed by a WebSocket
+// connection, for dispatching requests that arrive over other transports.
+// It is never registered with the hub, so pushes are not delivered to it.
+// The client is authenticated as userID when userID is positive, and its
+// requests are cancelled together with ctx.
+func NewSyntheticClient(ctx context.Context, hub *Hub, userID int) *Client {
+	ctx, cancel := context.WithCancel(ctx)
+
 	return &Client{
-		ID:       uuid.New().String(),
-		Conn:     conn,
-		Send:     make(chan []byte, 256),
-		Hub:      hub,
-		IsAuth:   false,
-		LastPing: time.Now(),
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Send:      make(chan []byte, 1),
+		Hub:       hub,
+		IsAuth:    userID > 0,
+		LastPing:  time.Now(),
+		synthetic: true,
+		ctx:       ctx,
+		cancel:    cancel,
+		writeDone: make(chan struct{}),
 	}
 }
 
+// Close releases a synthetic client once its request has been handled
+func (c *Client) Close() {
+	c.cancel()
+}
+
 // GetID returns client ID
 func (c *Client) GetID() string {
 	return c.ID
@@ -47,21 +136,29 @@ func (c *Client) GetID() string {
 
 // GetUserID returns user ID
 func (c *Client) GetUserID() int {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
 	return c.UserID
 }
 
 // SetAuth sets authentication status
 func (c *Client) SetAuth(auth bool) {
+	c.authMu.Lock()
 	c.IsAuth = auth
+	c.authMu.Unlock()
 }
 
 // SetUserID sets user ID
 func (c *Client) SetUserID(userID int) {
+	c.authMu.Lock()
 	c.UserID = userID
+	c.authMu.Unlock()
 }
 
 // IsAuthenticated returns authentication status
 func (c *Client) IsAuthenticated() bool {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
 	return c.IsAuth
 }
 
@@ -73,57 +170,309 @@ func (c *Client) SendResponse(response *valueobject.Response) {
 		return
 	}
 
+	c.enqueue(data)
+}
+
+// enqueue queues an outbound frame, applying the hub's slow-consumer policy
+// when the send buffer is full. It reports whether the frame was queued.
+func (c *Client) enqueue(data []byte) bool {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
+	if c.sendClosed {
+		return false
+	}
+
 	select {
 	case c.Send <- data:
+		return true
 	default:
-		log.Printf("Client %s send channel is full", c.ID)
 	}
+
+	metrics.IncrementDroppedFrames()
+
+	if c.Hub.Config.SlowConsumerPolicy == config.SlowConsumerDropOldest {
+		log.Printf("Client %s send buffer full, dropping oldest frame", c.ID)
+		select {
+		case <-c.Send:
+		default:
+		}
+		select {
+		case c.Send <- data:
+			return true
+		default:
+			return false
+		}
+	}
+
+	log.Printf("Client %s send buffer full, disconnecting slow consumer", c.ID)
+	metrics.IncrementSlowConsumers()
+	go c.Disconnect(CloseSlowConsumer, "")
+	return false
+}
+
+// closeSend closes the send channel exactly once, letting WritePump flush
+// what is already queued before it stops
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if !c.sendClosed {
+		c.sendClosed = true
+		close(c.Send)
+	}
+}
+
+// Disconnect sends a close frame with the given code and reason, then closes
+// the connection. An empty reason is replaced by the default for the code.
+// ReadPump notices the closed socket and unregisters the client.
+func (c *Client) Disconnect(code int, reason string) {
+	if reason == "" {
+		reason = CloseReason(code)
+	}
+	c.disconnectOnce.Do(func() {
+		c.closeCode, c.closeReason = code, reason
+		if c.streamDone != nil {
+			close(c.streamDone)
+		}
+		if c.Conn == nil {
+			return
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		if err := c.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline); err != nil {
+			log.Printf("Failed to send close frame to client %s: %v", c.ID, err)
+		}
+		c.Conn.Close()
+	})
 }
 
 // ReadPump handles reading messages from the WebSocket connection
 func (c *Client) ReadPump() {
+	c.startReceiving()
+
 	defer func() {
-		c.Hub.Unregister <- c
+		c.stopReceiving()
+		c.Hub.unregisterClient(c)
 		c.Conn.Close()
+		c.Hub.releaseConnection(c.remoteIP)
 	}()
 
-	// Set read deadline and pong handler
-	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	pongTimeout := c.Hub.Config.PongTimeout
+	if pongTimeout <= 0 {
+		pongTimeout = 60 * time.Second
+	}
+
+	// Any frame from the client, a pong or an application message, proves
+	// the connection is alive and extends the read deadline
+	c.Conn.SetReadDeadline(time.Now().Add(pongTimeout))
 	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-		c.LastPing = time.Now()
+		c.Conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		c.touchPing()
 		return nil
 	})
 
 	for {
-		_, messageData, err := c.Conn.ReadMessage()
+		messageType, messageData, err := c.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
 			break
 		}
+		if messageType != websocket.TextMessage {
+			log.Printf("Client %s sent a binary frame, disconnecting", c.ID)
+			c.Disconnect(CloseProtocolError, "binary frames are not supported")
+			break
+		}
 
-		// Parse message
-		message, err := valueobject.ParseMessage(messageData)
-		if err != nil {
-			log.Printf("Failed to parse message: %v", err)
-			response := valueobject.NewErrorResponse("", valueobject.CodeInvalidRequest, "Invalid message format")
-			c.SendResponse(response)
-			continue
+		c.Conn.SetReadDeadline(time.Now().Add(pongTimeout))
+		c.receive(messageData)
+	}
+}
+
+// watchLogin disconnects the client if it has not logged in within timeout.
+// It must be called before the client's pumps start.
+func (c *Client) watchLogin(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	c.loginTimer = time.AfterFunc(timeout, func() {
+		if c.IsAuthenticated() {
+			return
 		}
+		log.Printf("Client %s did not log in within %s, disconnecting", c.ID, timeout)
+		metrics.IncrementLoginTimeouts()
+		c.Disconnect(CloseAuthTimeout, "")
+	})
+}
+
+// startReceiving starts dispatching received messages
+func (c *Client) startReceiving() {
+	c.handlers.Add(1)
+	go c.dispatchRequests()
+}
+
+// stopReceiving aborts work for the departed client, then lets queued and
+// running handlers return so the send channel can be closed safely
+func (c *Client) stopReceiving() {
+	c.cancel()
+	if c.loginTimer != nil {
+		c.loginTimer.Stop()
+	}
+
+	c.recvMu.Lock()
+	c.recvClosed = true
+	close(c.requests)
+	c.recvMu.Unlock()
 
-		// Handle message
+	c.handlers.Wait()
+}
+
+// receive parses an inbound frame and queues it for dispatch. It reports
+// false once the client has stopped receiving.
+func (c *Client) receive(data []byte) bool {
+	message, err := valueobject.ParseMessage(data)
+	if err != nil {
+		log.Printf("Failed to parse message: %v", err)
+		if c.invalidFrames.Add(1) >= maxInvalidFrames {
+			log.Printf("Client %s sent %d invalid frames in a row, disconnecting", c.ID, maxInvalidFrames)
+			go c.Disconnect(CloseProtocolError, "")
+			return true
+		}
+		response := valueobject.NewErrorResponse("", valueobject.CodeInvalidRequest, "Invalid message format")
+		c.SendResponse(response)
+		return true
+	}
+	c.invalidFrames.Store(0)
+
+	// Heartbeats are answered inline so liveness never queues behind slow requests
+	if message.Type == valueobject.MessageTypeHeartbeat {
 		c.HandleMessage(message)
+		return true
+	}
+
+	c.recvMu.RLock()
+	defer c.recvMu.RUnlock()
+	if c.recvClosed {
+		return false
+	}
+	c.requests <- message
+	return true
+}
+
+// dispatchRequests runs queued messages in arrival order. Read-only actions run
+// concurrently up to the configured in-flight limit; a mutating action waits for
+// every earlier request of the user to finish and blocks later ones until it
+// completes, so state changes are applied in the order the user sent them.
+func (c *Client) dispatchRequests() {
+	defer c.handlers.Done()
+
+	for message := range c.requests {
+		if !c.Hub.Router.IsReadOnly(message.Type, message.Action) {
+			unlock := c.lockOrdering(message)
+			c.HandleMessage(message)
+			unlock()
+			continue
+		}
+
+		c.inFlight <- struct{}{}
+		unlock := c.lockOrdering(message)
+		c.handlers.Add(1)
+		go func(message *valueobject.Message) {
+			defer func() {
+				unlock()
+				<-c.inFlight
+				c.handlers.Done()
+			}()
+			c.HandleMessage(message)
+		}(message)
+	}
+}
+
+// lockOrdering takes the ordering lock for message, shared for read-only
+// actions and exclusive for mutating ones, and returns the function that
+// releases it. Once logged in, the lock is the one shared by every connection
+// and gateway request of the user on this node.
+func (c *Client) lockOrdering(message *valueobject.Message) (unlock func()) {
+	userID := c.GetUserID()
+
+	var lock *sync.RWMutex
+	release := func() {}
+	if userID > 0 {
+		lock = &c.Hub.ordering.acquire(userID).RWMutex
+		release = func() { c.Hub.ordering.release(userID) }
+	} else {
+		lock = &c.ordering
+	}
+
+	if c.Hub.Router.IsReadOnly(message.Type, message.Action) {
+		lock.RLock()
+		return func() {
+			lock.RUnlock()
+			release()
+		}
+	}
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		release()
+	}
+}
+
+// touchPing records that the client answered or sent a heartbeat
+func (c *Client) touchPing() {
+	c.livenessMu.Lock()
+	c.LastPing = time.Now()
+	c.livenessMu.Unlock()
+}
+
+// touchActivity records that the client sent a message other than a heartbeat
+func (c *Client) touchActivity() {
+	c.livenessMu.Lock()
+	c.lastActivity = time.Now()
+	c.livenessMu.Unlock()
+}
+
+// GetLastPing returns when the client last answered or sent a heartbeat
+func (c *Client) GetLastPing() time.Time {
+	c.livenessMu.Lock()
+	defer c.livenessMu.Unlock()
+	return c.LastPing
+}
+
+// disconnectIfIdle closes the connection of an authenticated client that has
+// sent nothing but heartbeats for longer than the idle timeout. It reports
+// whether the client was disconnected.
+func (c *Client) disconnectIfIdle() bool {
+	timeout := c.Hub.Config.IdleTimeout
+	if timeout <= 0 || !c.IsAuthenticated() {
+		return false
 	}
+
+	c.livenessMu.Lock()
+	idle := time.Since(c.lastActivity)
+	c.livenessMu.Unlock()
+	if idle < timeout {
+		return false
+	}
+
+	log.Printf("Client %s idle for %s, disconnecting", c.ID, idle.Round(time.Second))
+	c.Disconnect(CloseIdleTimeout, "")
+	return true
 }
 
 // WritePump handles writing messages to the WebSocket connection
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(54 * time.Second)
+	pingInterval := c.Hub.Config.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = 54 * time.Second
+	}
+
+	ticker := time.NewTicker(pingInterval)
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
+		close(c.writeDone)
 	}()
 
 	for {
@@ -131,7 +480,11 @@ func (c *Client) WritePump() {
 		case message, ok := <-c.Send:
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMessage := []byte{}
+				if c.Hub.IsDraining() {
+					closeMessage = websocket.FormatCloseMessage(CloseServerShutdown, CloseReason(CloseServerShutdown))
+				}
+				c.Conn.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
@@ -141,6 +494,9 @@ func (c *Client) WritePump() {
 			}
 
 		case <-ticker.C:
+			if c.disconnectIfIdle() {
+				return
+			}
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
@@ -149,14 +505,70 @@ func (c *Client) WritePump() {
 	}
 }
 
-// HandleMessage routes the message to appropriate handler
+// HandleMessage routes the message to appropriate handler and sends the response
 func (c *Client) HandleMessage(message *valueobject.Message) {
+	if response := c.ProcessMessage(message); response != nil {
+		c.SendResponse(response)
+	}
+}
+
+// ProcessMessage routes the message and returns the response instead of
+// queueing it, so transports other than WebSocket can deliver it. It returns
+// nil when the client went away before the handler finished.
+func (c *Client) ProcessMessage(message *valueobject.Message) *valueobject.Response {
+	c.Hub.inFlight.Add(1)
+	defer c.Hub.inFlight.Add(-1)
+
+	if c.Hub.IsDraining() {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeUnavailable, "Server is shutting down")
+	}
+
+	if message.Type != valueobject.MessageTypeHeartbeat {
+		c.touchActivity()
+	}
+
+	if c.Hub.Config.RequiresSignature(string(message.Type), string(message.Action)) {
+		// Gateway requests have no session key to sign with, so signed
+		// actions are only accepted over a WebSocket or SSE session
+		if c.synthetic {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeForbidden, "Action requires a signed session")
+		}
+		if err := c.verifySignature(message, c.Hub.Config.SignatureMaxSkew); err != nil {
+			log.Printf("Client %s rejected %s:%s: %v", c.ID, message.Type, message.Action, err)
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeForbidden, err.Error())
+		}
+	}
+
+	// Gateway requests bypass dispatchRequests, so they are ordered against
+	// the user's other requests here
+	if c.synthetic {
+		unlock := c.lockOrdering(message)
+		defer unlock()
+	}
+
+	timeout := c.Hub.Config.TimeoutFor(string(message.Type), string(message.Action))
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+
 	// Use the message router to handle the message
-	response := c.Hub.Router.Handle(c, message)
+	response := c.Hub.Router.Handle(ctx, c, message)
+
+	if c.ctx.Err() != nil {
+		// The client is gone, nobody is left to read the response
+		return nil
+	}
+
+	// A handler that finished its work after the deadline passed keeps its
+	// response; only one that gave up because of it reports a timeout
+	if response == nil && ctx.Err() == context.DeadlineExceeded {
+		response = valueobject.NewErrorResponse(message.RequestID, valueobject.CodeTimeout, "Request timed out")
+	}
+	if response != nil && response.Code == int(valueobject.CodeTimeout) {
+		log.Printf("Client %s request %s:%s timed out after %s", c.ID, message.Type, message.Action, timeout)
+	}
 
-	// Send response if provided
 	if response != nil {
 		response.Timestamp = time.Now().Unix()
-		c.SendResponse(response)
 	}
-}
\ No newline at end of file
+	return response
+}
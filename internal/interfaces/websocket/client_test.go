@@ -0,0 +1,284 @@
+package websocket
+
+import (
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/config"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	actionWrite valueobject.MessageAction = "write"
+	actionRead  valueobject.MessageAction = "read"
+)
+
+// concurrencyProbe records the most handlers that ran at the same time
+type concurrencyProbe struct {
+	active atomic.Int32
+	peak   atomic.Int32
+	done   atomic.Int32
+}
+
+func (p *concurrencyProbe) handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	n := p.active.Add(1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(2 * time.Millisecond)
+	p.active.Add(-1)
+	p.done.Add(1)
+	return valueobject.NewSuccessResponse(message.RequestID, nil)
+}
+
+func TestRequestOrdering(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   valueobject.MessageAction
+		users    [2]int // Users of the two WebSocket connections
+		gateway  bool   // Also send requests for the first user through the gateway
+		wantPeak func(peak int32) bool
+	}{
+		{
+			name:     "mutations of one user over two connections",
+			action:   actionWrite,
+			users:    [2]int{7, 7},
+			wantPeak: func(peak int32) bool { return peak == 1 },
+		},
+		{
+			name:     "mutations of one user over websocket and gateway",
+			action:   actionWrite,
+			users:    [2]int{7, 7},
+			gateway:  true,
+			wantPeak: func(peak int32) bool { return peak == 1 },
+		},
+		{
+			name:     "mutations of different users",
+			action:   actionWrite,
+			users:    [2]int{7, 8},
+			wantPeak: func(peak int32) bool { return peak == 2 },
+		},
+		{
+			name:     "reads of one user",
+			action:   actionRead,
+			users:    [2]int{7, 7},
+			wantPeak: func(peak int32) bool { return peak > 1 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := &concurrencyProbe{}
+			router := &testRouter{handle: probe.handle, readOnly: map[valueobject.MessageAction]bool{actionRead: true}}
+			hub := newTestHub(t, config.WebSocketConfig{SendBufferSize: 64}, router)
+
+			const perClient = 10
+			sent := 0
+
+			var clients []*Client
+			for _, userID := range tt.users {
+				client := newTestClient(hub, userID)
+				client.startReceiving()
+				clients = append(clients, client)
+			}
+
+			var wg sync.WaitGroup
+			for _, client := range clients {
+				wg.Add(1)
+				go func(client *Client) {
+					defer wg.Done()
+					for i := 0; i < perClient; i++ {
+						client.receive(frame(t, &valueobject.Message{Type: "test", Action: tt.action, RequestID: fmt.Sprint(i)}))
+					}
+				}(client)
+				sent += perClient
+			}
+			if tt.gateway {
+				for i := 0; i < perClient; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						client := NewSyntheticClient(context.Background(), hub, tt.users[0])
+						defer client.Close()
+						client.ProcessMessage(&valueobject.Message{Type: "test", Action: tt.action})
+					}()
+				}
+				sent += perClient
+			}
+			wg.Wait()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for probe.done.Load() < int32(sent) && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			for _, client := range clients {
+				client.stopReceiving()
+			}
+
+			if got := probe.done.Load(); got != int32(sent) {
+				t.Fatalf("handled %d of %d requests", got, sent)
+			}
+			if peak := probe.peak.Load(); !tt.wantPeak(peak) {
+				t.Errorf("peak concurrency %d not expected", peak)
+			}
+			if n := hub.ordering.len(); n != 0 {
+				t.Errorf("%d ordering locks left behind", n)
+			}
+		})
+	}
+}
+
+func TestAuthStateIsSynchronized(t *testing.T) {
+	hub := newTestHub(t, config.WebSocketConfig{}, nil)
+	client := newTestClient(hub, 0)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 1000; i++ {
+			client.SetUserID(i)
+			client.SetAuth(i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			client.GetUserID()
+			client.IsAuthenticated()
+		}
+	}()
+	wg.Wait()
+
+	if got := client.GetUserID(); got != 1000 {
+		t.Errorf("GetUserID() = %d, want 1000", got)
+	}
+}
+
+func TestProcessMessageTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		handle   func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response
+		wantCode valueobject.ResponseCode
+	}{
+		{
+			name: "handler finishes in time",
+			handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				return valueobject.NewSuccessResponse(message.RequestID, nil)
+			},
+			wantCode: valueobject.CodeSuccess,
+		},
+		{
+			name: "handler commits after the deadline",
+			handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				<-ctx.Done()
+				return valueobject.NewSuccessResponse(message.RequestID, nil)
+			},
+			wantCode: valueobject.CodeSuccess,
+		},
+		{
+			name: "handler fails with the deadline",
+			handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				<-ctx.Done()
+				return internalError(message.RequestID, ctx.Err())
+			},
+			wantCode: valueobject.CodeTimeout,
+		},
+		{
+			name: "handler fails with a wrapped deadline",
+			handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				<-ctx.Done()
+				return internalError(message.RequestID, fmt.Errorf("query player: %w", ctx.Err()))
+			},
+			wantCode: valueobject.CodeTimeout,
+		},
+		{
+			name: "handler fails for another reason after the deadline",
+			handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				<-ctx.Done()
+				return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, "stage is locked")
+			},
+			wantCode: valueobject.CodeValidationError,
+		},
+		{
+			name: "handler returns nothing after the deadline",
+			handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				<-ctx.Done()
+				return nil
+			},
+			wantCode: valueobject.CodeTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := &testRouter{handle: tt.handle}
+			hub := newTestHub(t, config.WebSocketConfig{RequestTimeout: 10 * time.Millisecond}, router)
+			client := newTestClient(hub, 7)
+
+			response := client.ProcessMessage(&valueobject.Message{Type: "test", Action: actionWrite, RequestID: "r1"})
+			if response == nil {
+				t.Fatal("ProcessMessage returned no response")
+			}
+			if response.Code != int(tt.wantCode) {
+				t.Errorf("code = %d, want %d", response.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestProcessMessageClientGone(t *testing.T) {
+	router := &testRouter{handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+		client.cancel()
+		return valueobject.NewSuccessResponse(message.RequestID, nil)
+	}}
+	hub := newTestHub(t, config.WebSocketConfig{}, router)
+	client := newTestClient(hub, 7)
+
+	if response := client.ProcessMessage(&valueobject.Message{Type: "test", Action: actionWrite}); response != nil {
+		t.Errorf("ProcessMessage returned %+v for a departed client, want nil", response)
+	}
+}
+
+func TestSlowConsumerPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     string
+		wantQueued []string // Frames left in the send buffer
+		wantClose  int
+	}{
+		{name: "drop oldest", policy: config.SlowConsumerDropOldest, wantQueued: []string{"2", "3"}},
+		{name: "disconnect", policy: config.SlowConsumerDisconnect, wantQueued: []string{"1", "2"}, wantClose: CloseSlowConsumer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newTestHub(t, config.WebSocketConfig{SendBufferSize: 2, SlowConsumerPolicy: tt.policy}, nil)
+			client := loginTestClient(hub, 7)
+
+			for i, data := range []string{"1", "2", "3"} {
+				queued := client.enqueue([]byte(data))
+				if wantQueued := i < 2 || tt.wantClose == 0; queued != wantQueued {
+					t.Errorf("frame %s queued = %v, want %v", data, queued, wantQueued)
+				}
+			}
+
+			var got []string
+			for len(client.Send) > 0 {
+				got = append(got, string(<-client.Send))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantQueued) {
+				t.Errorf("queued frames = %v, want %v", got, tt.wantQueued)
+			}
+			if code := closedWith(client, 100*time.Millisecond); code != tt.wantClose {
+				t.Errorf("close code = %d, want %d", code, tt.wantClose)
+			}
+		})
+	}
+}
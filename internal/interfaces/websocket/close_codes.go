@@ -0,0 +1,40 @@
+package websocket
+
+// Application close codes sent in WebSocket close frames, and in the final
+// "close" event of an SSE stream. RFC 6455 reserves 4000-4999 for
+// applications; clients use the code to decide whether to reconnect.
+const (
+	// CloseDuplicateLogin: the user logged in on another connection. Do not reconnect automatically.
+	CloseDuplicateLogin = 4000
+	// CloseBanned: the account was banned. Do not reconnect.
+	CloseBanned = 4001
+	// CloseRateLimited: the client sent too much. Reconnect after a back-off.
+	CloseRateLimited = 4002
+	// CloseServerShutdown: the server is going away. Reconnect after the delay from server:shutdown.
+	CloseServerShutdown = 4003
+	// CloseAuthTimeout: the client did not log in in time. Reconnect and log in promptly.
+	CloseAuthTimeout = 4004
+	// CloseProtocolError: the client sent frames the server cannot process. Fix the client before reconnecting.
+	CloseProtocolError = 4005
+	// CloseIdleTimeout: the session sent nothing but heartbeats for too long. Reconnect on user activity.
+	CloseIdleTimeout = 4006
+	// CloseSlowConsumer: the client did not read its frames fast enough. Reconnect and resume the session.
+	CloseSlowConsumer = 4007
+)
+
+// closeReasons holds the default reason sent with each close code
+var closeReasons = map[int]string{
+	CloseDuplicateLogin: "duplicate login",
+	CloseBanned:         "banned",
+	CloseRateLimited:    "rate limited",
+	CloseServerShutdown: "server shutting down",
+	CloseAuthTimeout:    "authentication timeout",
+	CloseProtocolError:  "protocol error",
+	CloseIdleTimeout:    "idle timeout",
+	CloseSlowConsumer:   "slow consumer",
+}
+
+// CloseReason returns the default reason for a close code
+func CloseReason(code int) string {
+	return closeReasons[code]
+}
@@ -2,11 +2,25 @@ package websocket
 
 import (
 	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/entity"
 	"GameServer/internal/domain/valueobject"
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"time"
 )
 
+// internalError answers a request that failed for a reason other than bad
+// input. Failures caused by the request's deadline get CodeTimeout, so clients
+// can tell them apart from server errors.
+func internalError(requestID string, err error) *valueobject.Response {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return valueobject.NewErrorResponse(requestID, valueobject.CodeTimeout, "Request timed out")
+	}
+	return valueobject.NewErrorResponse(requestID, valueobject.CodeInternalError, err.Error())
+}
+
 // AuthHandler handles authentication messages
 type AuthHandler struct {
 	authService AuthServiceInterface
@@ -18,52 +32,63 @@ func NewAuthHandler(authService AuthServiceInterface) *AuthHandler {
 }
 
 // Handle handles authentication messages
-func (h *AuthHandler) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *AuthHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	switch message.Action {
 	case valueobject.ActionLogin:
-		return h.handleLogin(client, message)
+		return h.handleLogin(ctx, client, message)
 	case valueobject.ActionRegister:
-		return h.handleRegister(client, message)
+		return h.handleRegister(ctx, client, message)
 	case valueobject.ActionLogout:
-		return h.handleLogout(client, message)
+		return h.handleLogout(ctx, client, message)
 	default:
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown auth action")
 	}
 }
 
-func (h *AuthHandler) handleLogin(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *AuthHandler) handleLogin(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.LoginRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid login data")
 	}
 
-	response, err := h.authService.Login(&req)
+	response, err := h.authService.Login(ctx, &req)
 	if err != nil {
 		// Check for specific error types to return appropriate error codes
 		errorMsg := err.Error()
-		if errorMsg == "user is already logged in" {
-			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeConflict, err.Error())
-		} else if errorMsg == "invalid username or password" {
+		if errorMsg == "invalid username or password" {
 			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeUnauthorized, err.Error())
 		}
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 
-	// Set client authentication
-	client.SetAuth(true)
+	// Set client authentication; subscriptions of a user logged in before on
+	// this connection do not carry over
+	if client.GetUserID() != response.UserID {
+		client.Hub.unsubscribeUserTopics(client)
+	}
 	client.SetUserID(response.UserID)
+	client.SetAuth(true)
 	client.Hub.SetUserClient(response.UserID, client)
 
+	// Issue a key for signing sensitive actions when any are configured
+	if len(client.Hub.Config.SignedActions) > 0 {
+		key, err := client.issueSigningKey()
+		if err != nil {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, "Failed to issue signing key")
+		}
+		response.SigningKey = key
+	}
+
 	return valueobject.NewSuccessResponse(message.RequestID, response)
 }
 
-func (h *AuthHandler) handleRegister(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *AuthHandler) handleRegister(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.RegisterRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid register data")
 	}
 
-	response, err := h.authService.Register(&req)
+	response, err := h.authService.Register(ctx, &req)
 	if err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
 	}
@@ -71,15 +96,17 @@ func (h *AuthHandler) handleRegister(client *Client, message *valueobject.Messag
 	return valueobject.NewSuccessResponse(message.RequestID, response)
 }
 
-func (h *AuthHandler) handleLogout(client *Client, message *valueobject.Message) *valueobject.Response {
-	if err := h.authService.Logout(client.GetUserID()); err != nil {
+func (h *AuthHandler) handleLogout(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	if err := h.authService.Logout(ctx, client.GetUserID()); err != nil {
 		log.Printf("Logout error: %v", err)
 	}
 
 	// Clear client authentication
 	client.Hub.RemoveUserClient(client.GetUserID())
+	client.Hub.unsubscribeUserTopics(client)
 	client.SetAuth(false)
 	client.SetUserID(0)
+	client.clearSigningKey()
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Logged out successfully"})
 }
@@ -93,13 +120,137 @@ func NewHeartbeatHandler() *HeartbeatHandler {
 }
 
 // Handle handles heartbeat messages
-func (h *HeartbeatHandler) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *HeartbeatHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	if message.Action == valueobject.ActionPing {
-		return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"pong": "pong"})
+		client.touchPing()
+		// serverTime lets the client estimate its clock offset; clientTime
+		// echoes the request timestamp so it can pair the two
+		return valueobject.NewSuccessResponse(message.RequestID, map[string]interface{}{
+			"pong":       "pong",
+			"serverTime": time.Now().UnixMilli(),
+			"clientTime": message.Timestamp,
+		})
 	}
 	return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown heartbeat action")
 }
 
+// OnlineHandler handles online presence messages
+type OnlineHandler struct{}
+
+// NewOnlineHandler creates a new online handler
+func NewOnlineHandler() *OnlineHandler {
+	return &OnlineHandler{}
+}
+
+// Handle handles online messages
+func (h *OnlineHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	if message.Action != valueobject.ActionGetOnlineUsers {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown online action")
+	}
+
+	users, err := client.Hub.OnlineUsers(ctx)
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+
+	return valueobject.NewSuccessResponse(message.RequestID, map[string]interface{}{
+		"users": users,
+		"count": len(users),
+	})
+}
+
+// SessionHandler handles event replay for reconnecting clients
+type SessionHandler struct{}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{}
+}
+
+// Handle handles session messages
+func (h *SessionHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionResume:
+		return h.handleResume(ctx, client, message)
+	case valueobject.ActionAck:
+		return h.handleAck(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown session action")
+	}
+}
+
+func (h *SessionHandler) handleResume(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req struct {
+		Epoch   string `json:"epoch"`
+		LastSeq uint64 `json:"lastSeq"`
+	}
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid resume data")
+	}
+
+	events, epoch, currentSeq, complete := client.Hub.sessions.resume(client.GetUserID(), req.Epoch, req.LastSeq)
+
+	return valueobject.NewSuccessResponse(message.RequestID, map[string]interface{}{
+		"events":   events,
+		"epoch":    epoch,
+		"lastSeq":  currentSeq,
+		"complete": complete,
+	})
+}
+
+func (h *SessionHandler) handleAck(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req struct {
+		Epoch string `json:"epoch"`
+		Seq   uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid ack data")
+	}
+
+	client.Hub.sessions.ack(client.GetUserID(), req.Epoch, req.Seq)
+
+	return valueobject.NewSuccessResponse(message.RequestID, map[string]uint64{"seq": req.Seq})
+}
+
+// SubscriptionHandler handles topic subscriptions
+type SubscriptionHandler struct{}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler() *SubscriptionHandler {
+	return &SubscriptionHandler{}
+}
+
+// Handle handles subscription messages
+func (h *SubscriptionHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	// Gateway clients live for a single request and never receive pushes
+	if client.synthetic {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Subscriptions require a WebSocket or SSE connection")
+	}
+
+	var req struct {
+		Topic string `json:"topic"`
+	}
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid subscription data")
+	}
+
+	switch message.Action {
+	case valueobject.ActionSubscribe:
+		if err := client.Hub.Subscribe(client, req.Topic); err != nil {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+	case valueobject.ActionUnsubscribe:
+		client.Hub.Unsubscribe(client, req.Topic)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown subscription action")
+	}
+
+	return valueobject.NewSuccessResponse(message.RequestID, map[string]interface{}{
+		"topic":  req.Topic,
+		"topics": client.Hub.Subscriptions(client),
+	})
+}
+
 // PlayerHandler handles player-related messages
 type PlayerHandler struct {
 	playerService PlayerServiceInterface
@@ -111,63 +262,158 @@ func NewPlayerHandler(playerService PlayerServiceInterface) *PlayerHandler {
 }
 
 // Handle handles player messages
-func (h *PlayerHandler) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *PlayerHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	switch message.Action {
 	case valueobject.ActionGetPlayerInfo:
-		return h.handleGetPlayerInfo(client, message)
+		return h.handleGetPlayerInfo(ctx, client, message)
 	case valueobject.ActionUpdatePlayer:
-		return h.handleUpdatePlayer(client, message)
+		return h.handleUpdatePlayer(ctx, client, message)
+	case valueobject.ActionGainExperience:
+		return h.handleGainExperience(ctx, client, message)
+	case valueobject.ActionConsumeEnergy:
+		return h.handleConsumeEnergy(ctx, client, message)
+	case valueobject.ActionBuyEnergy:
+		return h.handleBuyEnergy(ctx, client, message)
+	case valueobject.ActionGetStageMap:
+		return h.handleGetStageMap(ctx, client, message)
+	case valueobject.ActionCompleteStage:
+		return h.handleCompleteStage(ctx, client, message)
 	case valueobject.ActionGetEquip:
-		return h.handleGetEquipment(client, message)
+		return h.handleGetEquipment(ctx, client, message)
 	case valueobject.ActionSaveEquip:
-		return h.handleSaveEquipment(client, message)
+		return h.handleSaveEquipment(ctx, client, message)
 	case valueobject.ActionDeleteEquip:
-		return h.handleDeleteEquipment(client, message)
+		return h.handleDeleteEquipment(ctx, client, message)
 	case valueobject.ActionDelEquip:
-		return h.handleDeleteEquipment(client, message)
+		return h.handleDeleteEquipment(ctx, client, message)
 	default:
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown player action")
 	}
 }
 
-func (h *PlayerHandler) handleGetPlayerInfo(client *Client, message *valueobject.Message) *valueobject.Response {
-	response, err := h.playerService.GetPlayerInfo(client.GetUserID())
+func (h *PlayerHandler) handleGetPlayerInfo(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	response, err := h.playerService.GetPlayerInfo(ctx, client.GetUserID())
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, response)
 }
 
-func (h *PlayerHandler) handleUpdatePlayer(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *PlayerHandler) handleUpdatePlayer(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.UpdatePlayerRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid update data")
 	}
 
 	req.UserID = client.GetUserID() // Ensure user can only update their own data
-	if err := h.playerService.UpdatePlayer(&req); err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+	req.Payload = message.Data
+	if err := h.playerService.UpdatePlayer(ctx, &req); err != nil {
+		if err.Error() == "level and experience can only change through gainExperience" ||
+			err.Error() == "blood energy can only change through consumeEnergy and buyEnergy" ||
+			err.Error() == "game level can only change through completeStage" {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeForbidden, err.Error())
+		}
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Player updated successfully"})
 }
 
-func (h *PlayerHandler) handleGetEquipment(client *Client, message *valueobject.Message) *valueobject.Response {
-	equipment, err := h.playerService.GetUserEquipment(client.GetUserID())
+func (h *PlayerHandler) handleGainExperience(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.GainExperienceRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid experience data")
+	}
+
+	req.UserID = client.GetUserID()
+	req.Payload = message.Data
+	response, err := h.playerService.GainExperience(ctx, &req)
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *PlayerHandler) handleConsumeEnergy(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.ConsumeEnergyRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid energy data")
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.playerService.ConsumeEnergy(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *PlayerHandler) handleBuyEnergy(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	response, err := h.playerService.BuyEnergy(ctx, client.GetUserID())
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *PlayerHandler) handleGetStageMap(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	response, err := h.playerService.GetStageMap(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *PlayerHandler) handleCompleteStage(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.CompleteStageRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid stage data")
+	}
+
+	req.UserID = client.GetUserID()
+	req.Payload = message.Data
+	response, err := h.playerService.CompleteStage(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *PlayerHandler) handleGetEquipment(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	equipment, err := h.playerService.GetUserEquipment(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, equipment)
 }
 
-func (h *PlayerHandler) handleSaveEquipment(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *PlayerHandler) handleSaveEquipment(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.SaveEquipmentRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid equipment data")
 	}
 
 	req.UserID = client.GetUserID() // Ensure user can only save their own equipment
-	equipment, err := h.playerService.SaveEquipment(&req)
+	equipment, err := h.playerService.SaveEquipment(ctx, &req)
 	if err != nil {
 		// Check for specific error types
 		errorMsg := err.Error()
@@ -177,13 +423,13 @@ func (h *PlayerHandler) handleSaveEquipment(client *Client, message *valueobject
 		   errorMsg == "equipment sequence limit reached for this type and quality" {
 			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
 		}
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, equipment)
 }
 
-func (h *PlayerHandler) handleDeleteEquipment(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *PlayerHandler) handleDeleteEquipment(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req struct {
 		EquipID int `json:"equipid"`
 	}
@@ -191,8 +437,8 @@ func (h *PlayerHandler) handleDeleteEquipment(client *Client, message *valueobje
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid delete data")
 	}
 
-	if err := h.playerService.DeleteEquipment(req.EquipID, client.GetUserID()); err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+	if err := h.playerService.DeleteEquipment(ctx, req.EquipID, client.GetUserID()); err != nil {
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Equipment deleted successfully"})
@@ -209,89 +455,89 @@ func NewFriendHandler(friendService FriendServiceInterface) *FriendHandler {
 }
 
 // Handle handles friend messages
-func (h *FriendHandler) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *FriendHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	switch message.Action {
 	case valueobject.ActionGetFriends:
-		return h.handleGetFriends(client, message)
+		return h.handleGetFriends(ctx, client, message)
 	case valueobject.ActionAddFriend:
-		return h.handleAddFriend(client, message)
+		return h.handleAddFriend(ctx, client, message)
 	case valueobject.ActionRemoveFriend:
-		return h.handleRemoveFriend(client, message)
+		return h.handleRemoveFriend(ctx, client, message)
 	case valueobject.ActionAcceptFriend:
-		return h.handleAcceptFriend(client, message)
+		return h.handleAcceptFriend(ctx, client, message)
 	case valueobject.ActionRejectFriend:
-		return h.handleRejectFriend(client, message)
+		return h.handleRejectFriend(ctx, client, message)
 	case valueobject.ActionGetFriendRank:
-		return h.handleGetFriendRank(client, message)
+		return h.handleGetFriendRank(ctx, client, message)
 	default:
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown friend action")
 	}
 }
 
-func (h *FriendHandler) handleGetFriends(client *Client, message *valueobject.Message) *valueobject.Response {
-	friends, err := h.friendService.GetFriends(client.GetUserID())
+func (h *FriendHandler) handleGetFriends(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	friends, err := h.friendService.GetFriends(ctx, client.GetUserID())
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, friends)
 }
 
-func (h *FriendHandler) handleAddFriend(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *FriendHandler) handleAddFriend(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.AddFriendRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid friend request data")
 	}
 
-	if err := h.friendService.SendFriendRequest(client.GetUserID(), &req); err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+	if err := h.friendService.SendFriendRequest(ctx, client.GetUserID(), &req); err != nil {
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Friend request sent"})
 }
 
-func (h *FriendHandler) handleRemoveFriend(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *FriendHandler) handleRemoveFriend(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.RemoveFriendRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid remove friend data")
 	}
 
-	if err := h.friendService.RemoveFriend(client.GetUserID(), &req); err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+	if err := h.friendService.RemoveFriend(ctx, client.GetUserID(), &req); err != nil {
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Friend removed"})
 }
 
-func (h *FriendHandler) handleAcceptFriend(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *FriendHandler) handleAcceptFriend(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.FriendActionRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid accept friend data")
 	}
 
-	if err := h.friendService.AcceptFriendRequest(client.GetUserID(), &req); err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+	if err := h.friendService.AcceptFriendRequest(ctx, client.GetUserID(), &req); err != nil {
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Friend request accepted"})
 }
 
-func (h *FriendHandler) handleRejectFriend(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *FriendHandler) handleRejectFriend(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.FriendActionRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid reject friend data")
 	}
 
-	if err := h.friendService.RejectFriendRequest(client.GetUserID(), &req); err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+	if err := h.friendService.RejectFriendRequest(ctx, client.GetUserID(), &req); err != nil {
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Friend request rejected"})
 }
 
-func (h *FriendHandler) handleGetFriendRank(client *Client, message *valueobject.Message) *valueobject.Response {
-	ranking, err := h.friendService.GetFriendRanking(client.GetUserID())
+func (h *FriendHandler) handleGetFriendRank(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	ranking, err := h.friendService.GetFriendRanking(ctx, client.GetUserID())
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, ranking)
 }
@@ -307,18 +553,18 @@ func NewRankingHandler(rankingService RankingServiceInterface) *RankingHandler {
 }
 
 // Handle handles ranking messages
-func (h *RankingHandler) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *RankingHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	switch message.Action {
 	case valueobject.ActionGetAllRank:
-		return h.handleGetAllRank(client, message)
+		return h.handleGetAllRank(ctx, client, message)
 	case valueobject.ActionGetRank:
-		return h.handleGetRank(client, message)
+		return h.handleGetRank(ctx, client, message)
 	default:
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown ranking action")
 	}
 }
 
-func (h *RankingHandler) handleGetAllRank(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *RankingHandler) handleGetAllRank(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req dto.GetRankingRequest
 	if err := json.Unmarshal(message.Data, &req); err != nil {
 		// Set default values if no data provided
@@ -326,14 +572,14 @@ func (h *RankingHandler) handleGetAllRank(client *Client, message *valueobject.M
 		req.Limit = 50
 	}
 
-	ranking, err := h.rankingService.GetRanking(&req)
+	ranking, err := h.rankingService.GetRanking(ctx, &req)
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, ranking)
 }
 
-func (h *RankingHandler) handleGetRank(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *RankingHandler) handleGetRank(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req struct {
 		RankType string `json:"rank_type"`
 	}
@@ -341,9 +587,9 @@ func (h *RankingHandler) handleGetRank(client *Client, message *valueobject.Mess
 		req.RankType = "level" // Default to level ranking
 	}
 
-	ranking, err := h.rankingService.GetUserRanking(client.GetUserID(), req.RankType)
+	ranking, err := h.rankingService.GetUserRanking(ctx, client.GetUserID(), req.RankType)
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, ranking)
 }
@@ -359,32 +605,32 @@ func NewUserEquipHandler(userEquipService UserEquipServiceInterface) *UserEquipH
 }
 
 // Handle handles user equipment messages
-func (h *UserEquipHandler) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *UserEquipHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	switch message.Action {
 	case valueobject.ActionGetEquippedItems:
-		return h.handleGetEquippedItems(client, message)
+		return h.handleGetEquippedItems(ctx, client, message)
 	case valueobject.ActionEquipItem:
-		return h.handleEquipItem(client, message)
+		return h.handleEquipItem(ctx, client, message)
 	case valueobject.ActionUnequipItem:
-		return h.handleUnequipItem(client, message)
+		return h.handleUnequipItem(ctx, client, message)
 	case valueobject.ActionGetEquipmentStats:
-		return h.handleGetEquipmentStats(client, message)
+		return h.handleGetEquipmentStats(ctx, client, message)
 	case valueobject.ActionGetEquippedBySlot:
-		return h.handleGetEquippedBySlot(client, message)
+		return h.handleGetEquippedBySlot(ctx, client, message)
 	default:
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown user equipment action")
 	}
 }
 
-func (h *UserEquipHandler) handleGetEquippedItems(client *Client, message *valueobject.Message) *valueobject.Response {
-	equippedItems, err := h.userEquipService.GetUserEquippedItems(client.GetUserID())
+func (h *UserEquipHandler) handleGetEquippedItems(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	equippedItems, err := h.userEquipService.GetUserEquippedItems(ctx, client.GetUserID())
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, equippedItems)
 }
 
-func (h *UserEquipHandler) handleEquipItem(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *UserEquipHandler) handleEquipItem(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req struct {
 		EquipSlot string `json:"equip_slot"`
 		EquipID   int    `json:"equipid"`
@@ -397,15 +643,15 @@ func (h *UserEquipHandler) handleEquipItem(client *Client, message *valueobject.
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Equipment slot and equipment ID are required")
 	}
 
-	err := h.userEquipService.EquipItem(client.GetUserID(), req.EquipSlot, req.EquipID)
+	err := h.userEquipService.EquipItem(ctx, client.GetUserID(), req.EquipSlot, req.EquipID)
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Item equipped successfully"})
 }
 
-func (h *UserEquipHandler) handleUnequipItem(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *UserEquipHandler) handleUnequipItem(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req struct {
 		EquipSlot string `json:"equip_slot"`
 	}
@@ -417,23 +663,23 @@ func (h *UserEquipHandler) handleUnequipItem(client *Client, message *valueobjec
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Equipment slot is required")
 	}
 
-	err := h.userEquipService.UnequipItem(client.GetUserID(), req.EquipSlot)
+	err := h.userEquipService.UnequipItem(ctx, client.GetUserID(), req.EquipSlot)
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 
 	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Item unequipped successfully"})
 }
 
-func (h *UserEquipHandler) handleGetEquipmentStats(client *Client, message *valueobject.Message) *valueobject.Response {
-	stats, err := h.userEquipService.GetEquipmentStats(client.GetUserID())
+func (h *UserEquipHandler) handleGetEquipmentStats(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	stats, err := h.userEquipService.GetEquipmentStats(ctx, client.GetUserID())
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, stats)
 }
 
-func (h *UserEquipHandler) handleGetEquippedBySlot(client *Client, message *valueobject.Message) *valueobject.Response {
+func (h *UserEquipHandler) handleGetEquippedBySlot(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	var req struct {
 		EquipSlot string `json:"equip_slot"`
 	}
@@ -445,9 +691,366 @@ func (h *UserEquipHandler) handleGetEquippedBySlot(client *Client, message *valu
 		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Equipment slot is required")
 	}
 
-	equipment, err := h.userEquipService.GetEquippedItemsBySlot(client.GetUserID(), req.EquipSlot)
+	equipment, err := h.userEquipService.GetEquippedItemsBySlot(ctx, client.GetUserID(), req.EquipSlot)
 	if err != nil {
-		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInternalError, err.Error())
+		return internalError(message.RequestID, err)
 	}
 	return valueobject.NewSuccessResponse(message.RequestID, equipment)
-}
\ No newline at end of file
+}
+// WalletHandler handles wallet messages
+type WalletHandler struct {
+	walletService WalletServiceInterface
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler(walletService WalletServiceInterface) *WalletHandler {
+	return &WalletHandler{walletService: walletService}
+}
+
+// Handle handles wallet messages
+func (h *WalletHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionGetBalance:
+		return h.handleGetBalance(ctx, client, message)
+	case valueobject.ActionGetHistory:
+		return h.handleGetHistory(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown wallet action")
+	}
+}
+
+func (h *WalletHandler) handleGetBalance(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	response, err := h.walletService.GetBalance(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *WalletHandler) handleGetHistory(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.WalletHistoryRequest
+	if len(message.Data) > 0 {
+		if err := json.Unmarshal(message.Data, &req); err != nil {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid history data")
+		}
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.walletService.GetHistory(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+// ItemHandler handles inventory item messages
+type ItemHandler struct {
+	inventoryService InventoryServiceInterface
+}
+
+// NewItemHandler creates a new item handler
+func NewItemHandler(inventoryService InventoryServiceInterface) *ItemHandler {
+	return &ItemHandler{inventoryService: inventoryService}
+}
+
+// Handle handles inventory item messages
+func (h *ItemHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionListItems:
+		return h.handleListItems(ctx, client, message)
+	case valueobject.ActionUseItem:
+		return h.handleUseItem(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown item action")
+	}
+}
+
+func (h *ItemHandler) handleListItems(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	items, err := h.inventoryService.ListItems(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, items)
+}
+
+func (h *ItemHandler) handleUseItem(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.UseItemRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid item data")
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.inventoryService.UseItem(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+// CheckInHandler handles daily check-in messages
+type CheckInHandler struct {
+	checkInService CheckInServiceInterface
+}
+
+// NewCheckInHandler creates a new check-in handler
+func NewCheckInHandler(checkInService CheckInServiceInterface) *CheckInHandler {
+	return &CheckInHandler{checkInService: checkInService}
+}
+
+// Handle handles daily check-in messages
+func (h *CheckInHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionCheckInStatus:
+		return h.handleStatus(ctx, client, message)
+	case valueobject.ActionCheckInClaim:
+		return h.handleClaim(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown check-in action")
+	}
+}
+
+func (h *CheckInHandler) handleStatus(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	status, err := h.checkInService.GetStatus(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, status)
+}
+
+func (h *CheckInHandler) handleClaim(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.CheckInClaimRequest
+	if len(message.Data) > 0 {
+		if err := json.Unmarshal(message.Data, &req); err != nil {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid check-in data")
+		}
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.checkInService.Claim(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+// QuestHandler handles quest and achievement messages
+type QuestHandler struct {
+	questService QuestServiceInterface
+}
+
+// NewQuestHandler creates a new quest handler
+func NewQuestHandler(questService QuestServiceInterface) *QuestHandler {
+	return &QuestHandler{questService: questService}
+}
+
+// Handle handles quest and achievement messages
+func (h *QuestHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionListQuests:
+		return h.handleListQuests(ctx, client, message)
+	case valueobject.ActionClaimQuest:
+		return h.handleClaimQuest(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown quest action")
+	}
+}
+
+func (h *QuestHandler) handleListQuests(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	quests, err := h.questService.ListQuests(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, quests)
+}
+
+func (h *QuestHandler) handleClaimQuest(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.QuestClaimRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid quest data")
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.questService.Claim(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+// MailHandler handles mailbox messages
+type MailHandler struct {
+	mailService MailServiceInterface
+}
+
+// NewMailHandler creates a new mail handler
+func NewMailHandler(mailService MailServiceInterface) *MailHandler {
+	return &MailHandler{mailService: mailService}
+}
+
+// Handle handles mailbox messages
+func (h *MailHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionListMail:
+		return h.handleListMail(ctx, client, message)
+	case valueobject.ActionReadMail:
+		return h.handleReadMail(ctx, client, message)
+	case valueobject.ActionClaimMail:
+		return h.handleClaimMail(ctx, client, message)
+	case valueobject.ActionDeleteMail:
+		return h.handleDeleteMail(ctx, client, message)
+	case valueobject.ActionSendMail:
+		return h.handleSendMail(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown mail action")
+	}
+}
+
+func (h *MailHandler) handleListMail(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	mails, err := h.mailService.ListMail(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, mails)
+}
+
+func (h *MailHandler) handleReadMail(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.MailActionRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid mail data")
+	}
+
+	req.UserID = client.GetUserID()
+	mail, err := h.mailService.ReadMail(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, mail)
+}
+
+func (h *MailHandler) handleClaimMail(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.MailActionRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid mail data")
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.mailService.ClaimMail(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+func (h *MailHandler) handleDeleteMail(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.MailActionRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid mail data")
+	}
+
+	req.UserID = client.GetUserID()
+	if err := h.mailService.DeleteMail(ctx, &req); err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, map[string]string{"message": "Mail deleted"})
+}
+
+func (h *MailHandler) handleSendMail(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.SendMailRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid mail data")
+	}
+
+	req.UserID = client.GetUserID()
+	response, err := h.mailService.SendMail(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, response)
+}
+
+// ProfileHandler handles player profile and privacy messages
+type ProfileHandler struct {
+	profileService ProfileServiceInterface
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(profileService ProfileServiceInterface) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+// Handle handles player profile and privacy messages
+func (h *ProfileHandler) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	switch message.Action {
+	case valueobject.ActionGetProfile:
+		return h.handleGetProfile(ctx, client, message)
+	case valueobject.ActionGetPrivacy:
+		return h.handleGetPrivacy(ctx, client, message)
+	case valueobject.ActionUpdatePrivacy:
+		return h.handleUpdatePrivacy(ctx, client, message)
+	default:
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Unknown profile action")
+	}
+}
+
+func (h *ProfileHandler) handleGetProfile(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.GetProfileRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid profile data")
+	}
+
+	req.ViewerID = client.GetUserID()
+	profile, err := h.profileService.GetProfile(ctx, &req)
+	if err != nil {
+		if _, ok := err.(*entity.DomainError); ok {
+			return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeValidationError, err.Error())
+		}
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, profile)
+}
+
+func (h *ProfileHandler) handleGetPrivacy(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	settings, err := h.profileService.GetPrivacySettings(ctx, client.GetUserID())
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, settings)
+}
+
+func (h *ProfileHandler) handleUpdatePrivacy(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	var req dto.UpdatePrivacyRequest
+	if err := json.Unmarshal(message.Data, &req); err != nil {
+		return valueobject.NewErrorResponse(message.RequestID, valueobject.CodeInvalidRequest, "Invalid privacy data")
+	}
+
+	req.UserID = client.GetUserID()
+	settings, err := h.profileService.UpdatePrivacySettings(ctx, &req)
+	if err != nil {
+		return internalError(message.RequestID, err)
+	}
+	return valueobject.NewSuccessResponse(message.RequestID, settings)
+}
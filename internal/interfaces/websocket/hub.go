@@ -1,24 +1,31 @@
 package websocket
 
 import (
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/backplane"
+	"GameServer/internal/infrastructure/config"
+	"GameServer/pkg/metrics"
+	"GameServer/pkg/netpolicy"
+	"context"
+	"encoding/json"
 	"log"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// broadcastBufferSize is the number of broadcasts that may wait for Run
+const broadcastBufferSize = 256
+
 // Hub maintains the set of active clients and broadcasts messages to clients
 type Hub struct {
-	// Registered clients
-	Clients map[*Client]bool
-
-	// User ID to client mapping for direct messaging
-	UserClients map[int]*Client
-
-	// Register requests from the clients
-	Register chan *Client
+	// Registered clients, sharded by client ID
+	clients *clientRegistry
 
-	// Unregister requests from clients
-	Unregister chan *Client
+	// User ID to *Client mapping for direct messaging; lookups take no lock
+	userClients sync.Map
 
 	// Broadcast message to all clients
 	Broadcast chan []byte
@@ -26,11 +33,36 @@ type Hub struct {
 	// Message router for handling different message types
 	Router MessageRouter
 
-	// Mutex for thread-safe operations
-	Mutex sync.RWMutex
-
 	// Services
 	Services *ServiceContainer
+
+	// WebSocket configuration
+	Config config.WebSocketConfig
+
+	// Routes frames and presence between server nodes
+	backplane backplane.Backplane
+
+	// Per-user event queues for replay after reconnecting
+	sessions *sessionStore
+
+	// Per-user locks ordering requests across connections and transports
+	ordering *userOrdering
+
+	// Subscribers by routing key, which is the topic or a user's copy of a
+	// user topic; each client also keeps the topics it follows
+	topicMu sync.RWMutex
+	topics  map[string]map[*Client]struct{}
+
+	// Open connections in total and per remote address, for enforcing caps
+	connMu        sync.Mutex
+	connections   int
+	ipConnections map[string]int
+
+	// Set once Shutdown starts; new upgrades and requests are refused
+	draining atomic.Bool
+
+	// Number of messages currently being handled across all clients
+	inFlight atomic.Int64
 }
 
 // ServiceContainer holds all application services
@@ -40,153 +72,486 @@ type ServiceContainer struct {
 	FriendService    FriendServiceInterface
 	RankingService   RankingServiceInterface
 	UserEquipService UserEquipServiceInterface
+	WalletService    WalletServiceInterface
+	InventoryService InventoryServiceInterface
+	CheckInService   CheckInServiceInterface
+	QuestService     QuestServiceInterface
+	MailService      MailServiceInterface
+	ProfileService   ProfileServiceInterface
 }
 
-// NewHub creates a new Hub instance
-func NewHub(services *ServiceContainer) *Hub {
+// NewHub creates a new Hub instance attached to the given backplane
+func NewHub(services *ServiceContainer, wsConfig config.WebSocketConfig, bp backplane.Backplane) *Hub {
 	hub := &Hub{
-		Clients:     make(map[*Client]bool),
-		UserClients: make(map[int]*Client),
-		Register:    make(chan *Client),
-		Unregister:  make(chan *Client),
-		Broadcast:   make(chan []byte),
-		Services:    services,
-		Router:      NewMessageRouter(services),
+		clients:       newClientRegistry(),
+		Broadcast:     make(chan []byte, broadcastBufferSize),
+		Services:      services,
+		Router:        NewMessageRouter(services),
+		Config:        wsConfig,
+		backplane:     bp,
+		sessions:      newSessionStore(wsConfig.EventQueueSize, wsConfig.EventRetention),
+		ordering:      newUserOrdering(),
+		topics:        make(map[string]map[*Client]struct{}),
+		ipConnections: make(map[string]int),
+	}
+
+	if err := bp.Subscribe(hub); err != nil {
+		log.Printf("Failed to subscribe to backplane: %v", err)
 	}
 
 	return hub
 }
 
-// Run starts the hub's main loop
+// Run delivers broadcasts one at a time, so every client receives them in
+// the order they were queued
 func (h *Hub) Run() {
-	for {
-		select {
-		case client := <-h.Register:
-			h.registerClient(client)
+	for message := range h.Broadcast {
+		h.broadcastMessage(message)
+		h.publishBroadcast(message)
+	}
+}
 
-		case client := <-h.Unregister:
-			h.unregisterClient(client)
+// BroadcastEvent queues an event for every connected client on every node.
+// The event is encoded once. It never blocks; false means the broadcast queue
+// was full and the event was dropped.
+func (h *Hub) BroadcastEvent(event *valueobject.Event) bool {
+	data, err := event.ToJSON()
+	if err != nil {
+		log.Printf("Failed to encode broadcast event: %v", err)
+		return false
+	}
 
-		case message := <-h.Broadcast:
-			h.broadcastMessage(message)
-		}
+	select {
+	case h.Broadcast <- data:
+		return true
+	default:
+		log.Printf("Broadcast queue full, dropped %s event", event.Event)
+		return false
 	}
 }
 
-// registerClient registers a new client
+// registerClient registers a new client. A client arriving after Shutdown
+// took the clients over is closed straight away.
 func (h *Hub) registerClient(client *Client) {
-	h.Mutex.Lock()
-	defer h.Mutex.Unlock()
-
-	h.Clients[client] = true
+	if !h.clients.add(client) {
+		client.closeSend()
+		return
+	}
 	log.Printf("Client %s connected", client.ID)
 }
 
 // unregisterClient unregisters a client
 func (h *Hub) unregisterClient(client *Client) {
-	h.Mutex.Lock()
-	defer h.Mutex.Unlock()
-
-	if _, ok := h.Clients[client]; !ok {
+	if !h.clients.remove(client) {
 		return
 	}
 
-	// Remove from clients map
-	delete(h.Clients, client)
+	h.unsubscribeAll(client)
+
+	// Remove from user clients map if authenticated and not replaced by a
+	// newer connection of the same user
+	if userID := client.GetUserID(); userID > 0 && h.userClients.CompareAndDelete(userID, client) {
+		h.clearPresence(userID)
+		h.publishFriendPresence(userID, false)
 
-	// Remove from user clients map if authenticated
-	if client.UserID > 0 {
-		delete(h.UserClients, client.UserID)
-		
 		// Set user offline status
 		if h.Services.AuthService != nil {
-			if err := h.Services.AuthService.Logout(client.UserID); err != nil {
-				log.Printf("Failed to set user %d offline: %v", client.UserID, err)
+			if err := h.logoutUser(userID); err != nil {
+				log.Printf("Failed to set user %d offline: %v", userID, err)
 			}
 		}
-		
-		log.Printf("User %d disconnected", client.UserID)
+
+		log.Printf("User %d disconnected", userID)
 	}
 
 	// Close send channel
-	close(client.Send)
-	
+	client.closeSend()
+
 	log.Printf("Client %s disconnected", client.ID)
 }
 
-// broadcastMessage broadcasts a message to all clients
+// broadcastMessage broadcasts a message to all clients, fanning out over the
+// registry shards concurrently. Clients whose send buffer is full are handled
+// by the slow-consumer policy; removal from the registry happens in
+// unregisterClient once their connection is closed.
 func (h *Hub) broadcastMessage(message []byte) {
-	h.Mutex.RLock()
-	defer h.Mutex.RUnlock()
+	h.clients.fanOut(func(client *Client) {
+		client.enqueue(message)
+	})
+}
 
-	for client := range h.Clients {
-		select {
-		case client.Send <- message:
-		default:
-			// Client's send channel is full, close it
-			close(client.Send)
-			delete(h.Clients, client)
-			if client.UserID > 0 {
-				delete(h.UserClients, client.UserID)
-				// Set user offline status when force-disconnecting
-				if h.Services.AuthService != nil {
-					if err := h.Services.AuthService.Logout(client.UserID); err != nil {
-						log.Printf("Failed to set user %d offline during force disconnect: %v", client.UserID, err)
-					}
-				}
-			}
-		}
+// publishBroadcast forwards a broadcast to the other nodes
+func (h *Hub) publishBroadcast(message []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+	if err := h.backplane.PublishBroadcast(ctx, message); err != nil {
+		log.Printf("Failed to publish broadcast: %v", err)
+	}
+}
+
+// DeliverToUser delivers an event routed here by another node. The event is
+// sequenced here, where the user's queue lives, and kept for replay even if
+// the user disconnected in the meantime.
+func (h *Hub) DeliverToUser(userID int, payload []byte) {
+	var event valueobject.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		log.Printf("Dropped malformed event for user %d: %v", userID, err)
+		return
 	}
+	h.sessions.push(userID, &event, h.GetClientByUserID(userID))
 }
 
-// SetUserClient associates a user ID with a client
+// DeliverBroadcast delivers a broadcast published by another node to local clients
+func (h *Hub) DeliverBroadcast(payload []byte) {
+	h.broadcastMessage(payload)
+}
+
+// setPresence records on the backplane that the user is connected to this node
+func (h *Hub) setPresence(userID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+	if err := h.backplane.SetPresence(ctx, userID); err != nil {
+		log.Printf("Failed to set presence for user %d: %v", userID, err)
+	}
+}
+
+// clearPresence releases the user's presence held by this node
+func (h *Hub) clearPresence(userID int) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+	if err := h.backplane.ClearPresence(ctx, userID); err != nil {
+		log.Printf("Failed to clear presence for user %d: %v", userID, err)
+	}
+}
+
+// logoutUser marks a user offline outside of any client request
+func (h *Hub) logoutUser(userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+	return h.Services.AuthService.Logout(ctx, userID)
+}
+
+// SetUserClient associates a user ID with a client. The newest login takes
+// over: a different connection still holding the user, on this node or on
+// another one, is closed with CloseDuplicateLogin.
 func (h *Hub) SetUserClient(userID int, client *Client) {
-	h.Mutex.Lock()
-	defer h.Mutex.Unlock()
-	h.UserClients[userID] = client
+	if previous, loaded := h.userClients.Swap(userID, client); loaded && previous.(*Client) != client {
+		log.Printf("User %d logged in again, closing client %s", userID, previous.(*Client).ID)
+		go previous.(*Client).Disconnect(CloseDuplicateLogin, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+
+	// Take presence over before asking the other node to let go, so the
+	// departing connection cannot clear it
+	nodeID, online, err := h.backplane.LookupPresence(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to look up presence for user %d: %v", userID, err)
+	}
+	h.setPresence(userID)
+
+	if online && nodeID != h.backplane.NodeID() {
+		log.Printf("User %d logged in again, closing connection on node %s", userID, nodeID)
+		if err := h.backplane.PublishDisconnect(ctx, nodeID, userID, CloseDuplicateLogin); err != nil {
+			log.Printf("Failed to close connection of user %d on node %s: %v", userID, nodeID, err)
+		}
+	}
+
+	h.publishFriendPresence(userID, true)
+}
+
+// DisconnectUser closes the connection of a user with one of the application
+// close codes, e.g. CloseBanned. A user connected to another node is closed
+// there through the backplane. An empty reason is replaced by the default for
+// the code. It reports whether the user was found.
+func (h *Hub) DisconnectUser(userID int, code int, reason string) bool {
+	if client := h.GetClientByUserID(userID); client != nil {
+		client.Disconnect(code, reason)
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+
+	nodeID, online, err := h.backplane.LookupPresence(ctx, userID)
+	if err != nil || !online || nodeID == h.backplane.NodeID() {
+		return false
+	}
+	if err := h.backplane.PublishDisconnect(ctx, nodeID, userID, code); err != nil {
+		log.Printf("Failed to close connection of user %d on node %s: %v", userID, nodeID, err)
+		return false
+	}
+	return true
+}
+
+// DeliverDisconnect closes a user's connection on this node at the request of
+// another node. After a duplicate login the user is unmapped first, so the
+// departing connection neither marks the user offline nor clears the
+// presence the other node now holds.
+func (h *Hub) DeliverDisconnect(userID int, code int) {
+	client := h.GetClientByUserID(userID)
+	if client == nil {
+		return
+	}
+	if code == CloseDuplicateLogin {
+		h.userClients.CompareAndDelete(userID, client)
+	}
+	client.Disconnect(code, "")
 }
 
 // GetClientByUserID retrieves a client by user ID
 func (h *Hub) GetClientByUserID(userID int) *Client {
-	h.Mutex.RLock()
-	defer h.Mutex.RUnlock()
-	return h.UserClients[userID]
+	if client, ok := h.userClients.Load(userID); ok {
+		return client.(*Client)
+	}
+	return nil
 }
 
 // RemoveUserClient removes a user client mapping
 func (h *Hub) RemoveUserClient(userID int) {
-	h.Mutex.Lock()
-	defer h.Mutex.Unlock()
-	delete(h.UserClients, userID)
+	h.userClients.Delete(userID)
+	h.clearPresence(userID)
+	h.publishFriendPresence(userID, false)
 }
 
-// SendToUser sends a message to a specific user
-func (h *Hub) SendToUser(userID int, message []byte) bool {
-	client := h.GetClientByUserID(userID)
-	if client == nil {
-		return false
+// publishFriendPresence tells the friends of a user who follow
+// valueobject.TopicFriendPresence that the user came online or went offline
+func (h *Hub) publishFriendPresence(userID int, online bool) {
+	if h.Services.FriendService == nil {
+		return
 	}
 
-	select {
-	case client.Send <- message:
-		return true
-	default:
-		return false
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	friends, err := h.Services.FriendService.GetFriends(ctx, userID)
+	cancel()
+	if err != nil {
+		log.Printf("Failed to load friends of user %d for presence: %v", userID, err)
+		return
+	}
+
+	data := map[string]interface{}{"userId": userID, "online": online}
+	for _, friend := range friends {
+		friendID := friend.ToUserID
+		if friendID == userID {
+			friendID = friend.FromUserID
+		}
+		h.PublishToUser(friendID, valueobject.TopicFriendPresence, valueobject.EventFriendPresence, data)
+	}
+}
+
+// ClientCount returns the number of clients connected to this node
+func (h *Hub) ClientCount() int {
+	return h.clients.len()
+}
+
+// SendToUser pushes an event to a specific user. Users connected to another
+// node are reached through the backplane. Events for users who are offline
+// are kept so they can be replayed with session:resume; the return value
+// reports whether the event was handed to a live connection.
+func (h *Hub) SendToUser(userID int, event *valueobject.Event) bool {
+	if client := h.GetClientByUserID(userID); client != nil {
+		return h.sessions.push(userID, event, client)
+	}
+
+	if payload, err := event.ToJSON(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+		delivered, err := h.backplane.PublishToUser(ctx, userID, payload)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to route event to user %d: %v", userID, err)
+		}
+		if delivered {
+			return true
+		}
 	}
+
+	h.sessions.push(userID, event, nil)
+	return false
+}
+
+// NotifyUser pushes a new event to a user, see SendToUser
+func (h *Hub) NotifyUser(userID int, event string, data interface{}) {
+	h.SendToUser(userID, valueobject.NewEvent(event, data))
+}
+
+// OnlineUsers returns the IDs of users connected to any node
+func (h *Hub) OnlineUsers(ctx context.Context) ([]int, error) {
+	return h.backplane.OnlineUsers(ctx)
 }
 
 // HandleWebSocket handles WebSocket upgrade and creates new client
 func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ip, ok := h.admit(w, r)
+	if !ok {
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
+		metrics.IncrementRejectedHandshakes()
+		h.releaseConnection(ip)
 		return
 	}
 
 	client := NewClient(conn, h)
-	h.Register <- client
+	client.remoteIP = ip
+	client.watchLogin(h.Config.LoginTimeout)
+	h.registerClient(client)
 
 	// Start client goroutines
 	go client.WritePump()
 	go client.ReadPump()
-}
\ No newline at end of file
+}
+
+// admit checks whether a new connection may be opened and reserves a slot
+// for it. Refused requests are answered and counted.
+func (h *Hub) admit(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if h.IsDraining() {
+		metrics.IncrementRejectedHandshakes()
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return "", false
+	}
+
+	ip := clientIP(r)
+	if status := h.acquireConnection(ip); status != http.StatusOK {
+		log.Printf("Rejected connection from %s: %s", ip, http.StatusText(status))
+		metrics.IncrementRejectedHandshakes()
+		http.Error(w, "Too many connections", status)
+		return "", false
+	}
+	return ip, true
+}
+
+// acquireConnection reserves a connection slot for ip. It returns
+// http.StatusOK, or the status to refuse the connection with when a cap is
+// reached.
+func (h *Hub) acquireConnection(ip string) int {
+	h.connMu.Lock()
+	defer h.connMu.Unlock()
+
+	if h.Config.MaxConnections > 0 && h.connections >= h.Config.MaxConnections {
+		return http.StatusServiceUnavailable
+	}
+	if h.Config.MaxConnectionsPerIP > 0 && h.ipConnections[ip] >= h.Config.MaxConnectionsPerIP {
+		return http.StatusTooManyRequests
+	}
+
+	h.connections++
+	h.ipConnections[ip]++
+	metrics.IncrementConnections()
+	return http.StatusOK
+}
+
+// releaseConnection frees the slot reserved by acquireConnection
+func (h *Hub) releaseConnection(ip string) {
+	h.connMu.Lock()
+	defer h.connMu.Unlock()
+
+	h.connections--
+	if h.ipConnections[ip]--; h.ipConnections[ip] <= 0 {
+		delete(h.ipConnections, ip)
+	}
+	metrics.DecrementConnections()
+}
+
+// clientIP returns the address of the client that sent the request,
+// preferring the one resolved by the network policy
+func clientIP(r *http.Request) string {
+	if ip, ok := netpolicy.ClientIP(r.Context()); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// IsDraining reports whether the hub is shutting down
+func (h *Hub) IsDraining() bool {
+	return h.draining.Load()
+}
+
+// Shutdown drains the hub: it refuses new connections and requests, tells
+// connected clients the server is going away and when to reconnect, waits for
+// in-flight handlers, flushes queued frames and finally logs users out. It
+// returns ctx.Err() if the deadline passes before draining completes; users
+// are logged out and connections closed either way.
+func (h *Hub) Shutdown(ctx context.Context, reconnectAfter time.Duration) error {
+	h.draining.Store(true)
+
+	// Take ownership of every client so unregisterClient leaves them alone
+	clients := h.clients.drain()
+	h.userClients.Clear()
+	for _, client := range clients {
+		h.unsubscribeAll(client)
+	}
+
+	log.Printf("Draining %d connected clients...", len(clients))
+
+	event := valueobject.NewEvent(valueobject.EventServerShutdown, map[string]interface{}{
+		"message":          "Server shutting down",
+		"reconnectAfterMs": reconnectAfter.Milliseconds(),
+	})
+	if data, err := event.ToJSON(); err == nil {
+		for _, client := range clients {
+			client.enqueue(data)
+		}
+	}
+
+	err := h.waitForInFlight(ctx)
+	if err != nil {
+		log.Printf("Timed out waiting for %d in-flight requests", h.inFlight.Load())
+	}
+
+	// Closing the send channel lets WritePump flush what is queued, then
+	// send a close frame and exit
+	for _, client := range clients {
+		client.closeSend()
+	}
+	for _, client := range clients {
+		select {
+		case <-client.writeDone:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	for _, client := range clients {
+		userID := client.GetUserID()
+		if userID > 0 {
+			h.clearPresence(userID)
+			h.publishFriendPresence(userID, false)
+		}
+		if userID > 0 && h.Services.AuthService != nil {
+			if logoutErr := h.logoutUser(userID); logoutErr != nil {
+				log.Printf("Warning: Failed to logout user %d during shutdown: %v", userID, logoutErr)
+			} else {
+				log.Printf("User %d logged out during shutdown", userID)
+			}
+		}
+		client.cancel()
+		if client.Conn != nil {
+			client.Conn.Close()
+		}
+	}
+
+	return err
+}
+
+// waitForInFlight blocks until no message is being handled or ctx is done
+func (h *Hub) waitForInFlight(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for h.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"GameServer/internal/infrastructure/backplane"
+	"GameServer/internal/infrastructure/config"
+	"context"
+	"testing"
+	"time"
+)
+
+// loginTestClient registers a client on hub and logs it in as userID, the way
+// handleLogin does. Disconnecting the client closes its streamDone channel.
+func loginTestClient(hub *Hub, userID int) *Client {
+	client := NewClient(nil, hub)
+	client.streamDone = make(chan struct{})
+	hub.registerClient(client)
+	client.SetUserID(userID)
+	client.SetAuth(true)
+	hub.SetUserClient(userID, client)
+	return client
+}
+
+// closedWith waits for client to be disconnected and returns the close code,
+// or 0 if it stays connected
+func closedWith(client *Client, wait time.Duration) int {
+	select {
+	case <-client.streamDone:
+		return client.closeCode
+	case <-time.After(wait):
+		return 0
+	}
+}
+
+func TestDuplicateLogin(t *testing.T) {
+	const userID = 7
+
+	tests := []struct {
+		name      string
+		otherNode bool // Log in the second time on node b instead
+		sameConn  bool // Log in again on the same connection
+		wantClose int
+	}{
+		{name: "again on the same node", wantClose: CloseDuplicateLogin},
+		{name: "again on another node", otherNode: true, wantClose: CloseDuplicateLogin},
+		{name: "again on the same connection", sameConn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			bus := backplane.NewMemoryBus()
+			a := newTestNode(t, config.WebSocketConfig{}, nil, bus, "a")
+			b := newTestNode(t, config.WebSocketConfig{}, nil, bus, "b")
+
+			first := loginTestClient(a, userID)
+
+			second, secondHub := first, a
+			switch {
+			case tt.sameConn:
+				a.SetUserClient(userID, first)
+			case tt.otherNode:
+				second, secondHub = loginTestClient(b, userID), b
+			default:
+				second = loginTestClient(a, userID)
+			}
+
+			wait := 100 * time.Millisecond
+			if tt.wantClose == 0 {
+				wait = 20 * time.Millisecond
+			}
+			if got := closedWith(first, wait); got != tt.wantClose {
+				t.Fatalf("first connection closed with %d, want %d", got, tt.wantClose)
+			}
+			if got := secondHub.GetClientByUserID(userID); got != second {
+				t.Error("user is not mapped to the newest connection")
+			}
+
+			// The departing connection must leave the new login alone
+			if first != second {
+				a.unregisterClient(first)
+			}
+			nodeID, online, _ := a.backplane.LookupPresence(ctx, userID)
+			if want := secondHub.backplane.NodeID(); !online || nodeID != want {
+				t.Errorf("presence = %q (online %v), want %q", nodeID, online, want)
+			}
+			if got := secondHub.GetClientByUserID(userID); got != second {
+				t.Error("unregistering the old connection unmapped the new one")
+			}
+		})
+	}
+}
+
+func TestDisconnectUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		loginOn   string // Node the user is connected to; empty for offline
+		wantFound bool
+	}{
+		{name: "user on this node", loginOn: "a", wantFound: true},
+		{name: "user on another node", loginOn: "b", wantFound: true},
+		{name: "user offline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := backplane.NewMemoryBus()
+			nodes := map[string]*Hub{
+				"a": newTestNode(t, config.WebSocketConfig{}, nil, bus, "a"),
+				"b": newTestNode(t, config.WebSocketConfig{}, nil, bus, "b"),
+			}
+
+			var client *Client
+			if tt.loginOn != "" {
+				client = loginTestClient(nodes[tt.loginOn], 7)
+			}
+
+			if found := nodes["a"].DisconnectUser(7, CloseBanned, ""); found != tt.wantFound {
+				t.Fatalf("DisconnectUser found = %v, want %v", found, tt.wantFound)
+			}
+			if client != nil {
+				if got := closedWith(client, 100*time.Millisecond); got != CloseBanned {
+					t.Errorf("connection closed with %d, want %d", got, CloseBanned)
+				}
+			}
+		})
+	}
+}
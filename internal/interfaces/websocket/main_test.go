@@ -0,0 +1,115 @@
+package websocket
+
+import (
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/backplane"
+	"GameServer/internal/infrastructure/config"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Handler registration and connection churn log a line each
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testRouter routes every message to handle; actions listed in readOnly may
+// run concurrently
+type testRouter struct {
+	handle   func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response
+	readOnly map[valueobject.MessageAction]bool
+}
+
+func (r *testRouter) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	return r.handle(ctx, client, message)
+}
+
+func (r *testRouter) IsReadOnly(msgType valueobject.MessageType, action valueobject.MessageAction) bool {
+	return r.readOnly[action]
+}
+
+// newTestHub returns a hub on its own in-process backplane. A nil router
+// keeps the real one.
+func newTestHub(t testing.TB, cfg config.WebSocketConfig, router MessageRouter) *Hub {
+	t.Helper()
+	return newTestNode(t, cfg, router, backplane.NewMemoryBus(), "test")
+}
+
+// newTestNode returns a hub acting as node nodeID on a bus shared with other
+// test nodes
+func newTestNode(t testing.TB, cfg config.WebSocketConfig, router MessageRouter, bus *backplane.MemoryBus, nodeID string) *Hub {
+	t.Helper()
+
+	if cfg.MaxInFlightRequests == 0 {
+		cfg.MaxInFlightRequests = 4
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = time.Second
+	}
+	if cfg.EventQueueSize == 0 {
+		cfg.EventQueueSize = 16
+	}
+	if cfg.EventRetention == 0 {
+		cfg.EventRetention = time.Minute
+	}
+
+	bp := backplane.NewMemoryBackplane(bus, nodeID)
+	t.Cleanup(func() { bp.Close() })
+
+	hub := NewHub(&ServiceContainer{}, cfg, bp)
+	if router != nil {
+		hub.Router = router
+	}
+	return hub
+}
+
+// configForSessions keeps every pushed event and never evicts a queue
+// during a test
+func configForSessions() config.WebSocketConfig {
+	return config.WebSocketConfig{SendBufferSize: 1024, EventQueueSize: 1024, EventRetention: time.Hour}
+}
+
+// newTestClient returns a client without a connection, logged in as userID
+// when userID is positive
+func newTestClient(hub *Hub, userID int) *Client {
+	client := NewClient(nil, hub)
+	if userID > 0 {
+		client.SetUserID(userID)
+		client.SetAuth(true)
+	}
+	return client
+}
+
+// frame encodes a client message
+func frame(t testing.TB, message *valueobject.Message) []byte {
+	t.Helper()
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+	return data
+}
+
+// drainEvents decodes every frame queued for client
+func drainEvents(t testing.TB, client *Client) []valueobject.Event {
+	t.Helper()
+	var events []valueobject.Event
+	for {
+		select {
+		case data := <-client.Send:
+			var event valueobject.Event
+			if err := json.Unmarshal(data, &event); err != nil {
+				t.Fatalf("decode frame: %v", err)
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"sync"
+)
+
+// userOrdering hands out one ordering lock per user, shared by every
+// connection and gateway request of that user on this node. Read-only
+// requests hold it shared and mutating requests hold it exclusively, so state
+// changes are applied in the order they arrive regardless of the transport.
+type userOrdering struct {
+	mu    sync.Mutex
+	locks map[int]*orderingLock
+}
+
+// orderingLock is a user's lock together with the number of requests using it
+type orderingLock struct {
+	sync.RWMutex
+	refs int
+}
+
+func newUserOrdering() *userOrdering {
+	return &userOrdering{locks: make(map[int]*orderingLock)}
+}
+
+// acquire returns the user's ordering lock, creating it if needed. Every call
+// must be paired with release once the lock has been unlocked.
+func (o *userOrdering) acquire(userID int) *orderingLock {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lock, ok := o.locks[userID]
+	if !ok {
+		lock = &orderingLock{}
+		o.locks[userID] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// release drops a reference taken by acquire, forgetting the lock once no
+// request uses it
+func (o *userOrdering) release(userID int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	lock, ok := o.locks[userID]
+	if !ok {
+		return
+	}
+	if lock.refs--; lock.refs <= 0 {
+		delete(o.locks, userID)
+	}
+}
+
+// len returns the number of users with requests holding or waiting for a lock
+func (o *userOrdering) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.locks)
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"sync"
+)
+
+// registryShards is the number of shards in a client registry. It is a power
+// of two so a shard can be picked with a mask.
+const registryShards = 64
+
+// clientRegistry holds the connected clients keyed by client ID. Clients are
+// spread over shards with their own locks so registrations on different
+// shards do not contend.
+type clientRegistry struct {
+	shards [registryShards]registryShard
+}
+
+type registryShard struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+	closed  bool // Set by drain; later registrations are refused
+}
+
+func newClientRegistry() *clientRegistry {
+	r := &clientRegistry{}
+	for i := range r.shards {
+		r.shards[i].clients = make(map[string]*Client)
+	}
+	return r
+}
+
+// shard returns the shard holding the client with the given ID
+func (r *clientRegistry) shard(id string) *registryShard {
+	// FNV-1a
+	hash := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		hash ^= uint32(id[i])
+		hash *= 16777619
+	}
+	return &r.shards[hash&(registryShards-1)]
+}
+
+// add registers a client. It returns false if the registry has been drained.
+func (r *clientRegistry) add(client *Client) bool {
+	s := r.shard(client.ID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.clients[client.ID] = client
+	return true
+}
+
+// remove unregisters a client and reports whether it was registered
+func (r *clientRegistry) remove(client *Client) bool {
+	s := r.shard(client.ID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clients[client.ID] != client {
+		return false
+	}
+	delete(s.clients, client.ID)
+	return true
+}
+
+// get returns the client with the given ID, or nil
+func (r *clientRegistry) get(id string) *Client {
+	s := r.shard(id)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clients[id]
+}
+
+// len returns the number of registered clients
+func (r *clientRegistry) len() int {
+	n := 0
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.RLock()
+		n += len(s.clients)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// fanOut calls fn for every registered client, one goroutine per shard, and
+// returns once all calls are done. fn runs without any shard lock held, so it
+// may register or unregister clients.
+func (r *clientRegistry) fanOut(fn func(*Client)) {
+	var wg sync.WaitGroup
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.RLock()
+		clients := make([]*Client, 0, len(s.clients))
+		for _, client := range s.clients {
+			clients = append(clients, client)
+		}
+		s.mu.RUnlock()
+
+		if len(clients) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, client := range clients {
+				fn(client)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// drain removes and returns every client and refuses further registrations
+func (r *clientRegistry) drain() []*Client {
+	var clients []*Client
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.Lock()
+		for id, client := range s.clients {
+			clients = append(clients, client)
+			delete(s.clients, id)
+		}
+		s.closed = true
+		s.mu.Unlock()
+	}
+	return clients
+}
@@ -0,0 +1,376 @@
+package websocket
+
+import (
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/config"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRegistry(t *testing.T) {
+	hub := newTestHub(t, config.WebSocketConfig{}, nil)
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, r *clientRegistry)
+	}{
+		{
+			name: "add, get and remove",
+			run: func(t *testing.T, r *clientRegistry) {
+				client := NewClient(nil, hub)
+				if !r.add(client) {
+					t.Fatal("add refused on an open registry")
+				}
+				if got := r.get(client.ID); got != client {
+					t.Errorf("get returned %p, want %p", got, client)
+				}
+				if !r.remove(client) {
+					t.Error("remove of a registered client returned false")
+				}
+				if r.remove(client) {
+					t.Error("second remove returned true")
+				}
+				if got := r.get(client.ID); got != nil {
+					t.Errorf("get after remove returned %p", got)
+				}
+			},
+		},
+		{
+			name: "remove keeps a newer client with the same ID",
+			run: func(t *testing.T, r *clientRegistry) {
+				old := NewClient(nil, hub)
+				newer := NewClient(nil, hub)
+				newer.ID = old.ID
+				r.add(old)
+				r.add(newer)
+				if r.remove(old) {
+					t.Error("remove of a replaced client returned true")
+				}
+				if got := r.get(old.ID); got != newer {
+					t.Error("newer client was removed")
+				}
+			},
+		},
+		{
+			name: "clients spread over every shard",
+			run: func(t *testing.T, r *clientRegistry) {
+				for i := 0; i < 10000; i++ {
+					r.add(NewClient(nil, hub))
+				}
+				if n := r.len(); n != 10000 {
+					t.Fatalf("len = %d, want 10000", n)
+				}
+				for i := range r.shards {
+					if n := len(r.shards[i].clients); n < 10000/registryShards/2 {
+						t.Errorf("shard %d holds %d clients", i, n)
+					}
+				}
+			},
+		},
+		{
+			name: "fanOut visits every client once and may remove",
+			run: func(t *testing.T, r *clientRegistry) {
+				for i := 0; i < 1000; i++ {
+					r.add(NewClient(nil, hub))
+				}
+				var visits sync.Map
+				var count atomic.Int32
+				r.fanOut(func(client *Client) {
+					if _, seen := visits.LoadOrStore(client, true); seen {
+						t.Errorf("client %s visited twice", client.ID)
+					}
+					count.Add(1)
+					r.remove(client)
+				})
+				if count.Load() != 1000 {
+					t.Errorf("visited %d clients, want 1000", count.Load())
+				}
+				if n := r.len(); n != 0 {
+					t.Errorf("%d clients left after removing all", n)
+				}
+			},
+		},
+		{
+			name: "drain returns everyone and refuses later clients",
+			run: func(t *testing.T, r *clientRegistry) {
+				for i := 0; i < 100; i++ {
+					r.add(NewClient(nil, hub))
+				}
+				if drained := r.drain(); len(drained) != 100 {
+					t.Errorf("drain returned %d clients, want 100", len(drained))
+				}
+				if r.add(NewClient(nil, hub)) {
+					t.Error("add accepted after drain")
+				}
+				if n := r.len(); n != 0 {
+					t.Errorf("len = %d after drain", n)
+				}
+			},
+		},
+		{
+			name: "concurrent add and remove",
+			run: func(t *testing.T, r *clientRegistry) {
+				var wg sync.WaitGroup
+				for g := 0; g < 8; g++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := 0; i < 500; i++ {
+							client := NewClient(nil, hub)
+							r.add(client)
+							r.get(client.ID)
+							r.remove(client)
+						}
+					}()
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 50; i++ {
+						r.fanOut(func(*Client) {})
+					}
+				}()
+				wg.Wait()
+				if n := r.len(); n != 0 {
+					t.Errorf("len = %d, want 0", n)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newClientRegistry())
+		})
+	}
+}
+
+// benchClients is the number of connected clients the benchmarks run against
+const benchClients = 50000
+
+// singleMapHub is the registry the hub used before sharding: every client
+// and user in one map pair behind one RWMutex
+type singleMapHub struct {
+	mu          sync.RWMutex
+	clients     map[*Client]bool
+	userClients map[int]*Client
+}
+
+func (h *singleMapHub) add(userID int, client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = true
+	h.userClients[userID] = client
+}
+
+func (h *singleMapHub) remove(userID int, client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, client)
+	delete(h.userClients, userID)
+}
+
+func (h *singleMapHub) broadcast(message []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		client.enqueue(message)
+	}
+}
+
+func (h *singleMapHub) client(userID int) *Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.userClients[userID]
+}
+
+// registryBench is one registry under benchmark
+type registryBench struct {
+	name      string
+	add       func(userID int, client *Client)
+	remove    func(userID int, client *Client)
+	broadcast func(message []byte)
+	sendTo    func(userID int, frame []byte)
+}
+
+// newRegistryBenches returns the sharded registry and the single map, each
+// with benchClients logged in clients
+func newRegistryBenches(b *testing.B) (*Hub, []registryBench) {
+	cfg := config.WebSocketConfig{SendBufferSize: 64}
+	hub := newTestHub(b, cfg, nil)
+	single := &singleMapHub{clients: make(map[*Client]bool), userClients: make(map[int]*Client)}
+
+	for userID := 1; userID <= benchClients; userID++ {
+		client := newTestClient(hub, userID)
+		hub.clients.add(client)
+		hub.userClients.Store(userID, client)
+		single.add(userID, client)
+	}
+
+	return hub, []registryBench{
+		{
+			name: "sharded",
+			add: func(userID int, client *Client) {
+				hub.clients.add(client)
+				hub.userClients.Store(userID, client)
+			},
+			remove: func(userID int, client *Client) {
+				hub.clients.remove(client)
+				hub.userClients.CompareAndDelete(userID, client)
+			},
+			broadcast: hub.broadcastMessage,
+			sendTo: func(userID int, frame []byte) {
+				deliver(hub.GetClientByUserID(userID), frame)
+			},
+		},
+		{
+			name:      "single map",
+			add:       single.add,
+			remove:    single.remove,
+			broadcast: single.broadcast,
+			sendTo: func(userID int, frame []byte) {
+				deliver(single.client(userID), frame)
+			},
+		},
+	}
+}
+
+// deliver queues a frame for client and takes one back off its buffer, as
+// its write pump would, so buffers never fill during a benchmark
+func deliver(client *Client, frame []byte) {
+	client.enqueue(frame)
+	<-client.Send
+}
+
+// drainAll empties the send buffer of every client in hub
+func drainAll(hub *Hub) {
+	hub.clients.fanOut(func(client *Client) {
+		for len(client.Send) > 0 {
+			<-client.Send
+		}
+	})
+}
+
+// churn registers and unregisters clients until stop is closed, like
+// players connecting and leaving during a broadcast
+func churn(hub *Hub, bench registryBench, stop <-chan struct{}, done *sync.WaitGroup) {
+	defer done.Done()
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		userID := benchClients + 1 + i%1000
+		client := newTestClient(hub, userID)
+		bench.add(userID, client)
+		bench.remove(userID, client)
+	}
+}
+
+func BenchmarkBroadcast(b *testing.B) {
+	hub, benches := newRegistryBenches(b)
+	message := []byte(`{"type":"event","event":"announcement","data":{}}`)
+
+	for _, bench := range benches {
+		for _, withChurn := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/churn=%v", bench.name, withChurn), func(b *testing.B) {
+				stop := make(chan struct{})
+				var done sync.WaitGroup
+				if withChurn {
+					done.Add(1)
+					go churn(hub, bench, stop, &done)
+				}
+
+				for i := 0; i < b.N; i++ {
+					bench.broadcast(message)
+
+					b.StopTimer()
+					drainAll(hub)
+					b.StartTimer()
+				}
+
+				b.StopTimer()
+				close(stop)
+				done.Wait()
+			})
+		}
+	}
+}
+
+func BenchmarkSendToUser(b *testing.B) {
+	hub, benches := newRegistryBenches(b)
+
+	for _, bench := range benches {
+		for _, withChurn := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/churn=%v", bench.name, withChurn), func(b *testing.B) {
+				stop := make(chan struct{})
+				var done sync.WaitGroup
+				if withChurn {
+					done.Add(1)
+					go churn(hub, bench, stop, &done)
+				}
+
+				frame, _ := valueobject.NewEvent("mail", nil).ToJSON()
+				var next atomic.Int64
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						userID := int(next.Add(7919)%benchClients) + 1
+						bench.sendTo(userID, frame)
+					}
+				})
+
+				b.StopTimer()
+				close(stop)
+				done.Wait()
+			})
+		}
+	}
+}
+
+// BenchmarkRegisterDuringBroadcast measures registering a client while
+// broadcasts run back to back; max-µs is the longest a registration waited
+func BenchmarkRegisterDuringBroadcast(b *testing.B) {
+	hub, benches := newRegistryBenches(b)
+	message := []byte(`{"type":"event","event":"announcement","data":{}}`)
+
+	for _, bench := range benches {
+		b.Run(bench.name, func(b *testing.B) {
+			stop := make(chan struct{})
+			var done sync.WaitGroup
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					bench.broadcast(message)
+					drainAll(hub)
+				}
+			}()
+
+			var slowest time.Duration
+			for i := 0; i < b.N; i++ {
+				userID := benchClients + 1 + i%1000
+				client := newTestClient(hub, userID)
+				start := time.Now()
+				bench.add(userID, client)
+				if wait := time.Since(start); wait > slowest {
+					slowest = wait
+				}
+				bench.remove(userID, client)
+			}
+
+			b.StopTimer()
+			close(stop)
+			done.Wait()
+			b.ReportMetric(float64(slowest.Microseconds()), "max-µs")
+		})
+	}
+}
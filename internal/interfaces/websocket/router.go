@@ -2,12 +2,14 @@ package websocket
 
 import (
 	"GameServer/internal/domain/valueobject"
+	"context"
 	"log"
 )
 
 // MessageRouter defines the interface for message routing
 type MessageRouter interface {
-	Handle(client *Client, message *valueobject.Message) *valueobject.Response
+	Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response
+	IsReadOnly(msgType valueobject.MessageType, action valueobject.MessageAction) bool
 }
 
 // messageRouter implements MessageRouter
@@ -18,15 +20,41 @@ type messageRouter struct {
 
 // MessageHandler defines the interface for message handlers
 type MessageHandler interface {
-	Handle(client *Client, message *valueobject.Message) *valueobject.Response
+	Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response
 }
 
 // MessageHandlerFunc is a function type that implements MessageHandler
-type MessageHandlerFunc func(client *Client, message *valueobject.Message) *valueobject.Response
+type MessageHandlerFunc func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response
 
 // Handle implements MessageHandler interface
-func (f MessageHandlerFunc) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
-	return f(client, message)
+func (f MessageHandlerFunc) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+	return f(ctx, client, message)
+}
+
+// readOnlyActions lists actions that neither mutate stored data nor the
+// client session, so they may be processed concurrently for the same client
+var readOnlyActions = map[valueobject.MessageType][]valueobject.MessageAction{
+	valueobject.MessageTypeHeartbeat: {valueobject.ActionPing},
+	valueobject.MessageTypePlayer: {
+		valueobject.ActionGetPlayerInfo,
+		valueobject.ActionGetStageMap,
+		valueobject.ActionGetProfile,
+		valueobject.ActionGetPrivacy,
+	},
+	valueobject.MessageTypeEquip: {valueobject.ActionGetEquip},
+	valueobject.MessageTypeUserEquip: {
+		valueobject.ActionGetEquippedItems,
+		valueobject.ActionGetEquipmentStats,
+		valueobject.ActionGetEquippedBySlot,
+	},
+	valueobject.MessageTypeFriend:  {valueobject.ActionGetFriends, valueobject.ActionGetFriendRank},
+	valueobject.MessageTypeRank:    {valueobject.ActionGetAllRank, valueobject.ActionGetRank},
+	valueobject.MessageTypeOnline:  {valueobject.ActionGetOnlineUsers},
+	valueobject.MessageTypeWallet:  {valueobject.ActionGetBalance, valueobject.ActionGetHistory},
+	valueobject.MessageTypeItem:    {valueobject.ActionListItems},
+	valueobject.MessageTypeCheckIn: {valueobject.ActionCheckInStatus},
+	valueobject.MessageTypeQuest:   {valueobject.ActionListQuests},
+	valueobject.MessageTypeMail:    {valueobject.ActionListMail},
 }
 
 // NewMessageRouter creates a new message router
@@ -43,7 +71,7 @@ func NewMessageRouter(services *ServiceContainer) MessageRouter {
 }
 
 // Handle routes a message to the appropriate handler
-func (r *messageRouter) Handle(client *Client, message *valueobject.Message) *valueobject.Response {
+func (r *messageRouter) Handle(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
 	// Find handler for message type
 	typeHandlers, exists := r.handlers[message.Type]
 	if !exists {
@@ -64,7 +92,7 @@ func (r *messageRouter) Handle(client *Client, message *valueobject.Message) *va
 	}
 
 	// Handle the message
-	return handler.Handle(client, message)
+	return handler.Handle(ctx, client, message)
 }
 
 // registerHandlers registers all message handlers
@@ -77,9 +105,28 @@ func (r *messageRouter) registerHandlers() {
 	// Heartbeat handlers
 	r.register(valueobject.MessageTypeHeartbeat, valueobject.ActionPing, NewHeartbeatHandler())
 
+	// Online handlers
+	r.register(valueobject.MessageTypeOnline, valueobject.ActionGetOnlineUsers, NewOnlineHandler())
+
+	// Session handlers
+	r.register(valueobject.MessageTypeSession, valueobject.ActionResume, NewSessionHandler())
+	r.register(valueobject.MessageTypeSession, valueobject.ActionAck, NewSessionHandler())
+
+	// Subscription handlers
+	r.register(valueobject.MessageTypeSub, valueobject.ActionSubscribe, NewSubscriptionHandler())
+	r.register(valueobject.MessageTypeSub, valueobject.ActionUnsubscribe, NewSubscriptionHandler())
+
 	// Player handlers
 	r.register(valueobject.MessageTypePlayer, valueobject.ActionGetPlayerInfo, NewPlayerHandler(r.services.PlayerService))
 	r.register(valueobject.MessageTypePlayer, valueobject.ActionUpdatePlayer, NewPlayerHandler(r.services.PlayerService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionGainExperience, NewPlayerHandler(r.services.PlayerService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionConsumeEnergy, NewPlayerHandler(r.services.PlayerService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionBuyEnergy, NewPlayerHandler(r.services.PlayerService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionGetStageMap, NewPlayerHandler(r.services.PlayerService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionGetProfile, NewProfileHandler(r.services.ProfileService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionGetPrivacy, NewProfileHandler(r.services.ProfileService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionUpdatePrivacy, NewProfileHandler(r.services.ProfileService))
+	r.register(valueobject.MessageTypePlayer, valueobject.ActionCompleteStage, NewPlayerHandler(r.services.PlayerService))
 
 	// Equipment handlers
 	r.register(valueobject.MessageTypeEquip, valueobject.ActionGetEquip, NewPlayerHandler(r.services.PlayerService))
@@ -105,6 +152,29 @@ func (r *messageRouter) registerHandlers() {
 	r.register(valueobject.MessageTypeUserEquip, valueobject.ActionUnequipItem, NewUserEquipHandler(r.services.UserEquipService))
 	r.register(valueobject.MessageTypeUserEquip, valueobject.ActionGetEquipmentStats, NewUserEquipHandler(r.services.UserEquipService))
 	r.register(valueobject.MessageTypeUserEquip, valueobject.ActionGetEquippedBySlot, NewUserEquipHandler(r.services.UserEquipService))
+
+	// Wallet handlers
+	r.register(valueobject.MessageTypeWallet, valueobject.ActionGetBalance, NewWalletHandler(r.services.WalletService))
+	r.register(valueobject.MessageTypeWallet, valueobject.ActionGetHistory, NewWalletHandler(r.services.WalletService))
+
+	// Item handlers
+	r.register(valueobject.MessageTypeItem, valueobject.ActionListItems, NewItemHandler(r.services.InventoryService))
+	r.register(valueobject.MessageTypeItem, valueobject.ActionUseItem, NewItemHandler(r.services.InventoryService))
+
+	// Check-in handlers
+	r.register(valueobject.MessageTypeCheckIn, valueobject.ActionCheckInStatus, NewCheckInHandler(r.services.CheckInService))
+	r.register(valueobject.MessageTypeCheckIn, valueobject.ActionCheckInClaim, NewCheckInHandler(r.services.CheckInService))
+
+	// Quest handlers
+	r.register(valueobject.MessageTypeQuest, valueobject.ActionListQuests, NewQuestHandler(r.services.QuestService))
+	r.register(valueobject.MessageTypeQuest, valueobject.ActionClaimQuest, NewQuestHandler(r.services.QuestService))
+
+	// Mail handlers
+	r.register(valueobject.MessageTypeMail, valueobject.ActionListMail, NewMailHandler(r.services.MailService))
+	r.register(valueobject.MessageTypeMail, valueobject.ActionReadMail, NewMailHandler(r.services.MailService))
+	r.register(valueobject.MessageTypeMail, valueobject.ActionClaimMail, NewMailHandler(r.services.MailService))
+	r.register(valueobject.MessageTypeMail, valueobject.ActionDeleteMail, NewMailHandler(r.services.MailService))
+	r.register(valueobject.MessageTypeMail, valueobject.ActionSendMail, NewMailHandler(r.services.MailService))
 }
 
 // register registers a handler for a message type and action
@@ -116,6 +186,17 @@ func (r *messageRouter) register(msgType valueobject.MessageType, action valueob
 	log.Printf("Registered handler for %s:%s", msgType, action)
 }
 
+// IsReadOnly reports whether a message type/action can run concurrently with
+// other read-only requests from the same client
+func (r *messageRouter) IsReadOnly(msgType valueobject.MessageType, action valueobject.MessageAction) bool {
+	for _, readOnly := range readOnlyActions[msgType] {
+		if readOnly == action {
+			return true
+		}
+	}
+	return false
+}
+
 // requiresAuth checks if a message type/action requires authentication
 func (r *messageRouter) requiresAuth(msgType valueobject.MessageType, action valueobject.MessageAction) bool {
 	// Authentication not required for these actions
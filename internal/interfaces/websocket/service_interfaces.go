@@ -1,49 +1,97 @@
 package websocket
 
-import "GameServer/internal/application/dto"
+import (
+	"GameServer/internal/application/dto"
+	"context"
+)
 
 // AuthServiceInterface defines the interface for auth service used by websocket handlers
 type AuthServiceInterface interface {
-	Login(req *dto.LoginRequest) (*dto.LoginResponse, error)
-	Register(req *dto.RegisterRequest) (*dto.RegisterResponse, error)
-	GetUserProfile(userID int) (*dto.UserProfile, error)
-	Logout(userID int) error
+	Login(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
+	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.RegisterResponse, error)
+	GetUserProfile(ctx context.Context, userID int) (*dto.UserProfile, error)
+	Logout(ctx context.Context, userID int) error
 }
 
 // PlayerServiceInterface defines the interface for player service used by websocket handlers
 type PlayerServiceInterface interface {
-	GetPlayerInfo(userID int) (*dto.PlayerInfoResponse, error)
-	UpdatePlayer(req *dto.UpdatePlayerRequest) error
-	GetUserEquipment(userID int) ([]*dto.EquipmentResponse, error)
-	SaveEquipment(req *dto.SaveEquipmentRequest) (*dto.EquipmentResponse, error)
-	DeleteEquipment(equipID, userID int) error
-	GetUserSourceStones(userID int) ([]*dto.SourceStoneResponse, error)
+	GetPlayerInfo(ctx context.Context, userID int) (*dto.PlayerInfoResponse, error)
+	UpdatePlayer(ctx context.Context, req *dto.UpdatePlayerRequest) error
+	GainExperience(ctx context.Context, req *dto.GainExperienceRequest) (*dto.GainExperienceResponse, error)
+	ConsumeEnergy(ctx context.Context, req *dto.ConsumeEnergyRequest) (*dto.EnergyResponse, error)
+	BuyEnergy(ctx context.Context, userID int) (*dto.EnergyResponse, error)
+	GetStageMap(ctx context.Context, userID int) (*dto.StageMapResponse, error)
+	CompleteStage(ctx context.Context, req *dto.CompleteStageRequest) (*dto.CompleteStageResponse, error)
+	GetUserEquipment(ctx context.Context, userID int) ([]*dto.EquipmentResponse, error)
+	SaveEquipment(ctx context.Context, req *dto.SaveEquipmentRequest) (*dto.EquipmentResponse, error)
+	DeleteEquipment(ctx context.Context, equipID, userID int) error
+	GetUserSourceStones(ctx context.Context, userID int) ([]*dto.SourceStoneResponse, error)
 }
 
 // FriendServiceInterface defines the interface for friend service used by websocket handlers
 type FriendServiceInterface interface {
-	GetFriends(userID int) ([]*dto.FriendResponse, error)
-	GetFriendRequests(userID int) ([]*dto.FriendRequestResponse, error)
-	SendFriendRequest(fromUserID int, req *dto.AddFriendRequest) error
-	AcceptFriendRequest(userID int, req *dto.FriendActionRequest) error
-	RejectFriendRequest(userID int, req *dto.FriendActionRequest) error
-	RemoveFriend(userID int, req *dto.RemoveFriendRequest) error
-	GetFriendRanking(userID int) ([]*dto.FriendRankResponse, error)
+	GetFriends(ctx context.Context, userID int) ([]*dto.FriendResponse, error)
+	GetFriendRequests(ctx context.Context, userID int) ([]*dto.FriendRequestResponse, error)
+	SendFriendRequest(ctx context.Context, fromUserID int, req *dto.AddFriendRequest) error
+	AcceptFriendRequest(ctx context.Context, userID int, req *dto.FriendActionRequest) error
+	RejectFriendRequest(ctx context.Context, userID int, req *dto.FriendActionRequest) error
+	RemoveFriend(ctx context.Context, userID int, req *dto.RemoveFriendRequest) error
+	GetFriendRanking(ctx context.Context, userID int) ([]*dto.FriendRankResponse, error)
 }
 
 // RankingServiceInterface defines the interface for ranking service used by websocket handlers
 type RankingServiceInterface interface {
-	GetRanking(req *dto.GetRankingRequest) ([]*dto.RankingResponse, error)
-	GetUserRanking(userID int, rankType string) (*dto.UserRankingResponse, error)
-	UpdateUserRankings(userID int) error
-	RefreshAllRankings() error
+	GetRanking(ctx context.Context, req *dto.GetRankingRequest) ([]*dto.RankingResponse, error)
+	GetUserRanking(ctx context.Context, userID int, rankType string) (*dto.UserRankingResponse, error)
+	UpdateUserRankings(ctx context.Context, userID int) error
+	RefreshAllRankings(ctx context.Context) error
 }
 
 // UserEquipServiceInterface defines the interface for user equipment service used by websocket handlers
 type UserEquipServiceInterface interface {
-	GetUserEquippedItems(userID int) (map[string]interface{}, error)
-	EquipItem(userID int, slot string, equipID int) error
-	UnequipItem(userID int, slot string) error
-	GetEquippedItemsBySlot(userID int, slot string) (interface{}, error)
-	GetEquipmentStats(userID int) (map[string]int, error)
-}
\ No newline at end of file
+	GetUserEquippedItems(ctx context.Context, userID int) (map[string]interface{}, error)
+	EquipItem(ctx context.Context, userID int, slot string, equipID int) error
+	UnequipItem(ctx context.Context, userID int, slot string) error
+	GetEquippedItemsBySlot(ctx context.Context, userID int, slot string) (interface{}, error)
+	GetEquipmentStats(ctx context.Context, userID int) (map[string]int, error)
+}
+
+// WalletServiceInterface defines the interface for wallet service used by websocket handlers
+type WalletServiceInterface interface {
+	GetBalance(ctx context.Context, userID int) (*dto.WalletBalanceResponse, error)
+	GetHistory(ctx context.Context, req *dto.WalletHistoryRequest) (*dto.WalletHistoryResponse, error)
+}
+
+// InventoryServiceInterface defines the interface for inventory service used by websocket handlers
+type InventoryServiceInterface interface {
+	ListItems(ctx context.Context, userID int) ([]*dto.InventoryItemResponse, error)
+	UseItem(ctx context.Context, req *dto.UseItemRequest) (*dto.UseItemResponse, error)
+}
+
+// CheckInServiceInterface defines the interface for check-in service used by websocket handlers
+type CheckInServiceInterface interface {
+	GetStatus(ctx context.Context, userID int) (*dto.CheckInStatusResponse, error)
+	Claim(ctx context.Context, req *dto.CheckInClaimRequest) (*dto.CheckInClaimResponse, error)
+}
+
+// QuestServiceInterface defines the interface for quest service used by websocket handlers
+type QuestServiceInterface interface {
+	ListQuests(ctx context.Context, userID int) ([]*dto.QuestResponse, error)
+	Claim(ctx context.Context, req *dto.QuestClaimRequest) (*dto.QuestClaimResponse, error)
+}
+
+// MailServiceInterface defines the interface for mail service used by websocket handlers
+type MailServiceInterface interface {
+	ListMail(ctx context.Context, userID int) ([]*dto.MailResponse, error)
+	ReadMail(ctx context.Context, req *dto.MailActionRequest) (*dto.MailResponse, error)
+	ClaimMail(ctx context.Context, req *dto.MailActionRequest) (*dto.MailClaimResponse, error)
+	DeleteMail(ctx context.Context, req *dto.MailActionRequest) error
+	SendMail(ctx context.Context, req *dto.SendMailRequest) (*dto.SendMailResponse, error)
+}
+
+// ProfileServiceInterface defines the interface for profile service used by websocket handlers
+type ProfileServiceInterface interface {
+	GetProfile(ctx context.Context, req *dto.GetProfileRequest) (*dto.ProfileResponse, error)
+	GetPrivacySettings(ctx context.Context, userID int) (*dto.PrivacySettingsResponse, error)
+	UpdatePrivacySettings(ctx context.Context, req *dto.UpdatePrivacyRequest) (*dto.PrivacySettingsResponse, error)
+}
@@ -0,0 +1,218 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"GameServer/internal/domain/valueobject"
+)
+
+// queuedEvent is an event frame kept for replay
+type queuedEvent struct {
+	seq   uint64
+	frame []byte
+	at    time.Time
+}
+
+// eventQueue holds the most recent events pushed to one user. Sequence
+// numbers restart at 1 when a queue is recreated, e.g. after it was evicted
+// for being idle or the node restarted, so every queue gets a new epoch and
+// a sequence number is only meaningful together with the epoch it came from.
+type eventQueue struct {
+	mu       sync.Mutex
+	epoch    string
+	lastSeq  uint64
+	lastPush time.Time
+	events   []queuedEvent
+	removed  bool // Set by cleanup once the queue is no longer in the store
+}
+
+// sessionStore keeps bounded per-user event queues so a client that
+// reconnects can replay the events it missed. Queues live on the node that
+// pushed the events, so replay needs the client to reconnect to that node.
+type sessionStore struct {
+	mu        sync.Mutex // Guards queues; each queue has its own lock
+	queues    map[int]*eventQueue
+	capacity  int
+	retention time.Duration
+}
+
+// newSessionStore creates a store and starts its cleanup goroutine
+func newSessionStore(capacity int, retention time.Duration) *sessionStore {
+	store := &sessionStore{
+		queues:    make(map[int]*eventQueue),
+		capacity:  capacity,
+		retention: retention,
+	}
+
+	go store.cleanup()
+
+	return store
+}
+
+// newEpoch returns a random identifier for a new queue
+func newEpoch() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// queue returns the user's queue, creating it when create is set, with its
+// lock held. It returns nil if the user has no queue and create is not set.
+func (s *sessionStore) queue(userID int, create bool) *eventQueue {
+	for {
+		s.mu.Lock()
+		queue := s.queues[userID]
+		if queue == nil {
+			if !create {
+				s.mu.Unlock()
+				return nil
+			}
+			queue = &eventQueue{epoch: newEpoch()}
+			s.queues[userID] = queue
+		}
+		s.mu.Unlock()
+
+		queue.mu.Lock()
+		if !queue.removed {
+			return queue
+		}
+		// cleanup evicted the queue while we waited for it
+		queue.mu.Unlock()
+	}
+}
+
+// push assigns the next sequence number to the event, stores it and, when a
+// client is given, queues it for sending. Sequencing and sending happen under
+// the user's queue lock so a client always receives a user's events in order,
+// while pushes to different users do not wait for each other.
+func (s *sessionStore) push(userID int, event *valueobject.Event, client *Client) bool {
+	queue := s.queue(userID, true)
+	defer queue.mu.Unlock()
+
+	event.Seq = queue.lastSeq + 1
+	event.Epoch = queue.epoch
+	frame, err := event.ToJSON()
+	if err != nil {
+		return false
+	}
+	queue.lastSeq = event.Seq
+	queue.lastPush = time.Now()
+
+	if len(queue.events) >= s.capacity {
+		queue.events = queue.events[1:]
+	}
+	queue.events = append(queue.events, queuedEvent{seq: event.Seq, frame: frame, at: queue.lastPush})
+
+	if client == nil {
+		return false
+	}
+	return client.enqueue(frame)
+}
+
+// resume returns the retained events after lastSeq together with the current
+// epoch and latest sequence number, and acknowledges everything up to lastSeq.
+// lastSeq only counts when epoch matches the queue's; a client that has seen
+// no events passes an empty epoch and zero. complete is false when some of
+// the missed events are no longer retained or the queue was recreated since
+// the client last saw it, in which case the client should reload its state.
+func (s *sessionStore) resume(userID int, epoch string, lastSeq uint64) (events []json.RawMessage, currentEpoch string, currentSeq uint64, complete bool) {
+	events = []json.RawMessage{}
+	fresh := epoch == "" && lastSeq == 0
+
+	queue := s.queue(userID, false)
+	if queue == nil {
+		return events, "", 0, fresh
+	}
+	defer queue.mu.Unlock()
+
+	s.expire(queue, time.Now())
+
+	if epoch != queue.epoch || lastSeq > queue.lastSeq {
+		// The client's position belongs to an older queue; hand over what is
+		// retained so it can continue from the current epoch
+		lastSeq = 0
+		complete = fresh
+	} else {
+		queue.ack(lastSeq)
+		complete = true
+	}
+
+	// Events after lastSeq are missing if the oldest retained one is newer
+	if len(queue.events) > 0 && queue.events[0].seq > lastSeq+1 {
+		complete = false
+	}
+	if len(queue.events) == 0 && queue.lastSeq > lastSeq {
+		complete = false
+	}
+
+	for _, event := range queue.events {
+		if event.seq > lastSeq {
+			events = append(events, event.frame)
+		}
+	}
+	return events, queue.epoch, queue.lastSeq, complete
+}
+
+// ack drops the events the client has confirmed receiving. Acknowledgements
+// for an earlier epoch are ignored.
+func (s *sessionStore) ack(userID int, epoch string, seq uint64) {
+	queue := s.queue(userID, false)
+	if queue == nil {
+		return
+	}
+	defer queue.mu.Unlock()
+
+	if epoch == queue.epoch {
+		queue.ack(seq)
+	}
+}
+
+// ack drops events up to seq; q.mu must be held
+func (q *eventQueue) ack(seq uint64) {
+	i := 0
+	for i < len(q.events) && q.events[i].seq <= seq {
+		i++
+	}
+	q.events = q.events[i:]
+}
+
+// expire drops events older than the retention window; queue.mu must be held
+func (s *sessionStore) expire(queue *eventQueue, now time.Time) {
+	i := 0
+	for i < len(queue.events) && now.Sub(queue.events[i].at) > s.retention {
+		i++
+	}
+	queue.events = queue.events[i:]
+}
+
+// cleanup periodically expires old events and forgets users that have not
+// been sent anything within the retention window
+func (s *sessionStore) cleanup() {
+	ticker := time.NewTicker(s.retention)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.evictIdle(time.Now())
+	}
+}
+
+// evictIdle expires old events and removes queues idle since before the
+// retention window
+func (s *sessionStore) evictIdle(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, queue := range s.queues {
+		queue.mu.Lock()
+		s.expire(queue, now)
+		if now.Sub(queue.lastPush) > s.retention {
+			queue.removed = true
+			delete(s.queues, userID)
+		}
+		queue.mu.Unlock()
+	}
+}
@@ -0,0 +1,233 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"GameServer/internal/domain/valueobject"
+)
+
+const testUser = 7
+
+// pushN pushes n events to testUser and returns the epoch they were given
+func pushN(s *sessionStore, n int) string {
+	var epoch string
+	for i := 0; i < n; i++ {
+		event := valueobject.NewEvent("test", i)
+		s.push(testUser, event, nil)
+		epoch = event.Epoch
+	}
+	return epoch
+}
+
+// seqs decodes the sequence numbers of replayed frames
+func seqs(t *testing.T, frames []json.RawMessage) []uint64 {
+	t.Helper()
+	var out []uint64
+	for _, frame := range frames {
+		var event valueobject.Event
+		if err := json.Unmarshal(frame, &event); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		out = append(out, event.Seq)
+	}
+	return out
+}
+
+func TestSessionResume(t *testing.T) {
+	tests := []struct {
+		name         string
+		capacity     int
+		setup        func(s *sessionStore) (epoch string, lastSeq uint64)
+		wantSeqs     []uint64
+		wantLastSeq  uint64
+		wantComplete bool
+	}{
+		{
+			name: "no events yet",
+			setup: func(s *sessionStore) (string, uint64) {
+				return "", 0
+			},
+			wantComplete: true,
+		},
+		{
+			name: "fresh client gets everything",
+			setup: func(s *sessionStore) (string, uint64) {
+				pushN(s, 3)
+				return "", 0
+			},
+			wantSeqs:     []uint64{1, 2, 3},
+			wantLastSeq:  3,
+			wantComplete: true,
+		},
+		{
+			name: "events after the last seen one",
+			setup: func(s *sessionStore) (string, uint64) {
+				return pushN(s, 3), 1
+			},
+			wantSeqs:     []uint64{2, 3},
+			wantLastSeq:  3,
+			wantComplete: true,
+		},
+		{
+			name: "up to date",
+			setup: func(s *sessionStore) (string, uint64) {
+				return pushN(s, 3), 3
+			},
+			wantLastSeq:  3,
+			wantComplete: true,
+		},
+		{
+			name:     "older events pushed out of the queue",
+			capacity: 2,
+			setup: func(s *sessionStore) (string, uint64) {
+				return pushN(s, 4), 1
+			},
+			wantSeqs:     []uint64{3, 4},
+			wantLastSeq:  4,
+			wantComplete: false,
+		},
+		{
+			name: "queue evicted and recreated with fewer events",
+			setup: func(s *sessionStore) (string, uint64) {
+				epoch := pushN(s, 5)
+				s.evictIdle(time.Now().Add(2 * time.Hour))
+				pushN(s, 3)
+				return epoch, 2
+			},
+			wantSeqs:     []uint64{1, 2, 3},
+			wantLastSeq:  3,
+			wantComplete: false,
+		},
+		{
+			name: "queue evicted and recreated with more events",
+			setup: func(s *sessionStore) (string, uint64) {
+				epoch := pushN(s, 2)
+				s.evictIdle(time.Now().Add(2 * time.Hour))
+				pushN(s, 4)
+				return epoch, 2
+			},
+			wantSeqs:     []uint64{1, 2, 3, 4},
+			wantLastSeq:  4,
+			wantComplete: false,
+		},
+		{
+			name: "queue evicted and not recreated",
+			setup: func(s *sessionStore) (string, uint64) {
+				epoch := pushN(s, 2)
+				s.evictIdle(time.Now().Add(2 * time.Hour))
+				return epoch, 2
+			},
+			wantComplete: false,
+		},
+		{
+			name: "client ahead of the queue",
+			setup: func(s *sessionStore) (string, uint64) {
+				return pushN(s, 2), 9
+			},
+			wantSeqs:     []uint64{1, 2},
+			wantLastSeq:  2,
+			wantComplete: false,
+		},
+		{
+			name: "sequence without epoch",
+			setup: func(s *sessionStore) (string, uint64) {
+				pushN(s, 3)
+				return "", 2
+			},
+			wantSeqs:     []uint64{1, 2, 3},
+			wantLastSeq:  3,
+			wantComplete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity := tt.capacity
+			if capacity == 0 {
+				capacity = 16
+			}
+			s := &sessionStore{queues: make(map[int]*eventQueue), capacity: capacity, retention: time.Hour}
+
+			epoch, lastSeq := tt.setup(s)
+			events, currentEpoch, currentSeq, complete := s.resume(testUser, epoch, lastSeq)
+
+			if got := seqs(t, events); fmt.Sprint(got) != fmt.Sprint(tt.wantSeqs) {
+				t.Errorf("replayed %v, want %v", got, tt.wantSeqs)
+			}
+			if currentSeq != tt.wantLastSeq {
+				t.Errorf("lastSeq = %d, want %d", currentSeq, tt.wantLastSeq)
+			}
+			if complete != tt.wantComplete {
+				t.Errorf("complete = %v, want %v", complete, tt.wantComplete)
+			}
+			if tt.wantLastSeq > 0 && currentEpoch == "" {
+				t.Error("no epoch returned for an existing queue")
+			}
+		})
+	}
+}
+
+func TestSessionAck(t *testing.T) {
+	s := &sessionStore{queues: make(map[int]*eventQueue), capacity: 16, retention: time.Hour}
+	epoch := pushN(s, 4)
+
+	s.ack(testUser, "stale", 3)
+	if events, _, _, _ := s.resume(testUser, "", 0); len(events) != 4 {
+		t.Errorf("ack with a stale epoch dropped events, %d left", len(events))
+	}
+
+	s.ack(testUser, epoch, 3)
+	events, _, _, complete := s.resume(testUser, epoch, 3)
+	if got := seqs(t, events); fmt.Sprint(got) != "[4]" || !complete {
+		t.Errorf("after ack replayed %v (complete %v), want [4] complete", got, complete)
+	}
+}
+
+func TestSessionPushOrderPerUser(t *testing.T) {
+	hub := newTestHub(t, configForSessions(), nil)
+	s := hub.sessions
+
+	const users, perUser = 8, 200
+	clients := make([]*Client, users)
+	for i := range clients {
+		clients[i] = newTestClient(hub, i+1)
+	}
+
+	var wg sync.WaitGroup
+	for i := range clients {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(client *Client) {
+				defer wg.Done()
+				for k := 0; k < perUser/2; k++ {
+					s.push(client.GetUserID(), valueobject.NewEvent("test", k), client)
+				}
+			}(clients[i])
+		}
+	}
+	// A sweep finding nothing idle must not disturb the queues
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			s.evictIdle(time.Now())
+		}
+	}()
+	wg.Wait()
+
+	for _, client := range clients {
+		events := drainEvents(t, client)
+		if len(events) != perUser {
+			t.Fatalf("user %d received %d events, want %d", client.GetUserID(), len(events), perUser)
+		}
+		for i, event := range events {
+			if event.Seq != uint64(i+1) || event.Epoch != events[0].Epoch {
+				t.Fatalf("user %d event %d has seq %d epoch %s", client.GetUserID(), i, event.Seq, event.Epoch)
+			}
+		}
+	}
+}
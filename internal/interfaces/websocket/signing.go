@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/valueobject"
+)
+
+// signingKeySize is the length of a session signing key in bytes
+const signingKeySize = 32
+
+// sessionSigner holds the signing key issued to a client at login and the
+// nonces it has used recently
+type sessionSigner struct {
+	mu     sync.Mutex
+	key    []byte
+	nonces map[string]time.Time // Nonce to the time it can be forgotten
+}
+
+// issueSigningKey generates a new session key for the client and returns it
+// encoded for the login response. Any previous key and nonces are discarded.
+func (c *Client) issueSigningKey() (string, error) {
+	key := make([]byte, signingKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	c.signer.mu.Lock()
+	defer c.signer.mu.Unlock()
+	c.signer.key = key
+	c.signer.nonces = make(map[string]time.Time)
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// clearSigningKey discards the session key, e.g. on logout
+func (c *Client) clearSigningKey() {
+	c.signer.mu.Lock()
+	defer c.signer.mu.Unlock()
+	c.signer.key = nil
+	c.signer.nonces = nil
+}
+
+// verifySignature checks that a message was signed with the client's session
+// key, that its timestamp is within maxSkew of server time and that its nonce
+// has not been seen before
+func (c *Client) verifySignature(message *valueobject.Message, maxSkew time.Duration) error {
+	c.signer.mu.Lock()
+	defer c.signer.mu.Unlock()
+
+	if c.signer.key == nil {
+		return entity.NewDomainError("no signing key issued for this session")
+	}
+	if message.Nonce == "" || message.Signature == "" {
+		return entity.NewDomainError("message must be signed")
+	}
+
+	expected := signMessage(c.signer.key, message)
+	signature, err := hex.DecodeString(message.Signature)
+	if err != nil || !hmac.Equal(signature, expected) {
+		return entity.NewDomainError("invalid signature")
+	}
+
+	now := time.Now()
+	sent := time.Unix(message.Timestamp, 0)
+	if sent.Before(now.Add(-maxSkew)) || sent.After(now.Add(maxSkew)) {
+		return entity.NewDomainError("message timestamp outside allowed window")
+	}
+
+	// A nonce only needs remembering while its timestamp is still acceptable
+	for nonce, expiry := range c.signer.nonces {
+		if now.After(expiry) {
+			delete(c.signer.nonces, nonce)
+		}
+	}
+	if _, seen := c.signer.nonces[message.Nonce]; seen {
+		return entity.NewDomainError("message replayed")
+	}
+	c.signer.nonces[message.Nonce] = sent.Add(maxSkew)
+
+	return nil
+}
+
+// signMessage computes the HMAC-SHA256 of a message's canonical form:
+// type, action, requestId, timestamp and nonce each followed by a newline,
+// then the raw data bytes exactly as sent
+func signMessage(key []byte, message *valueobject.Message) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(string(message.Type) + "\n"))
+	mac.Write([]byte(string(message.Action) + "\n"))
+	mac.Write([]byte(message.RequestID + "\n"))
+	mac.Write([]byte(strconv.FormatInt(message.Timestamp, 10) + "\n"))
+	mac.Write([]byte(message.Nonce + "\n"))
+	mac.Write(message.Data)
+	return mac.Sum(nil)
+}
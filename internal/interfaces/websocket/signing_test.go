@@ -0,0 +1,206 @@
+package websocket
+
+import (
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/config"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// signedConfig requires test:write to be signed
+func signedConfig() config.WebSocketConfig {
+	return config.WebSocketConfig{SignedActions: []string{"test:write"}, SignatureMaxSkew: 30 * time.Second}
+}
+
+// sign fills in the nonce and signature of message with key
+func sign(key []byte, message *valueobject.Message, nonce string) *valueobject.Message {
+	message.Nonce = nonce
+	message.Signature = hex.EncodeToString(signMessage(key, message))
+	return message
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now().Unix()
+	other := make([]byte, signingKeySize)
+
+	tests := []struct {
+		name string
+		// build returns the messages to send in order; only the last one's
+		// result is checked, the others must be accepted
+		build    func(key []byte) []*valueobject.Message
+		noKey    bool
+		wantCode valueobject.ResponseCode
+	}{
+		{
+			name: "valid signature",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{sign(key, testMessage(now, `{"a":1}`), "n1")}
+			},
+			wantCode: valueobject.CodeSuccess,
+		},
+		{
+			name: "signed with another key",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{sign(other, testMessage(now, `{"a":1}`), "n1")}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "data changed after signing",
+			build: func(key []byte) []*valueobject.Message {
+				message := sign(key, testMessage(now, `{"a":1}`), "n1")
+				message.Data = json.RawMessage(`{"a":2}`)
+				return []*valueobject.Message{message}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "signature not hex",
+			build: func(key []byte) []*valueobject.Message {
+				message := sign(key, testMessage(now, `{}`), "n1")
+				message.Signature = "zz"
+				return []*valueobject.Message{message}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "unsigned",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{testMessage(now, `{}`)}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "timestamp too old",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{sign(key, testMessage(now-31, `{}`), "n1")}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "timestamp too far ahead",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{sign(key, testMessage(now+31, `{}`), "n1")}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "timestamp within skew",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{sign(key, testMessage(now-20, `{}`), "n1")}
+			},
+			wantCode: valueobject.CodeSuccess,
+		},
+		{
+			name: "nonce replayed",
+			build: func(key []byte) []*valueobject.Message {
+				message := sign(key, testMessage(now, `{}`), "n1")
+				return []*valueobject.Message{message, message}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "nonce reused with a new request",
+			build: func(key []byte) []*valueobject.Message {
+				first := sign(key, testMessage(now, `{}`), "n1")
+				second := testMessage(now, `{}`)
+				second.RequestID = "r2"
+				return []*valueobject.Message{first, sign(key, second, "n1")}
+			},
+			wantCode: valueobject.CodeForbidden,
+		},
+		{
+			name: "distinct nonces",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{
+					sign(key, testMessage(now, `{}`), "n1"),
+					sign(key, testMessage(now, `{}`), "n2"),
+				}
+			},
+			wantCode: valueobject.CodeSuccess,
+		},
+		{
+			name: "no key issued",
+			build: func(key []byte) []*valueobject.Message {
+				return []*valueobject.Message{sign(other, testMessage(now, `{}`), "n1")}
+			},
+			noKey:    true,
+			wantCode: valueobject.CodeForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := &testRouter{handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				return valueobject.NewSuccessResponse(message.RequestID, nil)
+			}}
+			hub := newTestHub(t, signedConfig(), router)
+			client := newTestClient(hub, 7)
+
+			var key []byte
+			if !tt.noKey {
+				encoded, err := client.issueSigningKey()
+				if err != nil {
+					t.Fatalf("issueSigningKey: %v", err)
+				}
+				key, _ = base64.StdEncoding.DecodeString(encoded)
+			}
+
+			messages := tt.build(key)
+			for i, message := range messages {
+				response := client.ProcessMessage(message)
+				if i < len(messages)-1 {
+					if response.Code != int(valueobject.CodeSuccess) {
+						t.Fatalf("message %d rejected: %s", i, response.Message)
+					}
+					continue
+				}
+				if response.Code != int(tt.wantCode) {
+					t.Errorf("code = %d (%s), want %d", response.Code, response.Message, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayRejectsSignedActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   valueobject.MessageAction
+		wantCode valueobject.ResponseCode
+	}{
+		{name: "signed action", action: actionWrite, wantCode: valueobject.CodeForbidden},
+		{name: "unsigned action", action: actionRead, wantCode: valueobject.CodeSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := &testRouter{handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+				return valueobject.NewSuccessResponse(message.RequestID, nil)
+			}}
+			hub := newTestHub(t, signedConfig(), router)
+			client := NewSyntheticClient(context.Background(), hub, 7)
+			defer client.Close()
+
+			response := client.ProcessMessage(&valueobject.Message{Type: "test", Action: tt.action, Timestamp: time.Now().Unix()})
+			if response.Code != int(tt.wantCode) {
+				t.Errorf("code = %d, want %d", response.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// testMessage returns an unsigned test:write message
+func testMessage(timestamp int64, data string) *valueobject.Message {
+	return &valueobject.Message{
+		Type:      "test",
+		Action:    actionWrite,
+		RequestID: "r1",
+		Timestamp: timestamp,
+		Data:      json.RawMessage(data),
+	}
+}
@@ -0,0 +1,175 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// sseKeepAlivePeriod is how often a comment is written to keep idle
+	// proxies from closing the stream
+	sseKeepAlivePeriod = 15 * time.Second
+
+	// sseWriteWait is the time allowed to write a frame to the stream
+	sseWriteWait = 10 * time.Second
+
+	// sseMaxMessageSize limits the body of a posted message
+	sseMaxMessageSize = 1 << 20
+
+	// sseTokenHeader carries the stream token on posted messages
+	sseTokenHeader = "X-Stream-Token"
+)
+
+// HandleSSE opens a Server-Sent Events stream for clients that cannot use
+// WebSocket. The stream carries the same frames a WebSocket client receives;
+// the first event, "open", tells the client the ID to post messages to and
+// the token that must accompany them.
+func (h *Hub) HandleSSE(w http.ResponseWriter, r *http.Request) {
+	ip, ok := h.admit(w, r)
+	if !ok {
+		return
+	}
+	defer h.releaseConnection(ip)
+
+	token, err := newStreamToken()
+	if err != nil {
+		log.Printf("Failed to issue SSE stream token: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	client := NewClient(nil, h)
+	client.remoteIP = ip
+	client.streamDone = make(chan struct{})
+	client.streamToken = token
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	rc := http.NewResponseController(w)
+	open, _ := json.Marshal(map[string]string{"clientId": client.ID, "streamToken": token})
+	if err := writeSSE(rc, w, "open", open); err != nil {
+		log.Printf("Failed to open SSE stream: %v", err)
+		return
+	}
+
+	client.watchLogin(h.Config.LoginTimeout)
+	h.registerClient(client)
+
+	client.startReceiving()
+	client.streamPump(rc, w, r)
+
+	client.stopReceiving()
+	h.unregisterClient(client)
+}
+
+// HandleSSEMessage accepts a message posted by an SSE client. The body has
+// the same format as a WebSocket frame; the response is delivered on the
+// client's stream. The stream token from the "open" event must be sent in the
+// X-Stream-Token header; a wrong token is answered like an unknown stream.
+func (h *Hub) HandleSSEMessage(w http.ResponseWriter, r *http.Request) {
+	client := h.clients.get(r.PathValue("clientId"))
+	if client == nil || client.streamDone == nil ||
+		subtle.ConstantTimeCompare([]byte(r.Header.Get(sseTokenHeader)), []byte(client.streamToken)) != 1 {
+		http.Error(w, "Unknown stream", http.StatusNotFound)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, sseMaxMessageSize))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !client.receive(data) {
+		http.Error(w, "Stream closed", http.StatusGone)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// newStreamToken returns a random secret for authenticating posted messages
+func newStreamToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// streamPump writes queued frames to the SSE stream until the stream ends,
+// the client is disconnected or the send channel is closed
+func (c *Client) streamPump(rc *http.ResponseController, w io.Writer, r *http.Request) {
+	ticker := time.NewTicker(sseKeepAlivePeriod)
+	defer func() {
+		ticker.Stop()
+		close(c.writeDone)
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.Send:
+			if !ok {
+				if c.Hub.IsDraining() {
+					writeSSEClose(rc, w, CloseServerShutdown, CloseReason(CloseServerShutdown))
+				}
+				return
+			}
+			if err := writeSSE(rc, w, "", message); err != nil {
+				log.Printf("Failed to write message: %v", err)
+				return
+			}
+
+		case <-ticker.C:
+			if c.disconnectIfIdle() {
+				return
+			}
+			rc.SetWriteDeadline(time.Now().Add(sseWriteWait))
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+
+		case <-c.streamDone:
+			writeSSEClose(rc, w, c.closeCode, c.closeReason)
+			return
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEClose writes the final "close" event telling the client why the
+// stream ends; it carries the same codes as a WebSocket close frame
+func writeSSEClose(rc *http.ResponseController, w io.Writer, code int, reason string) {
+	data, _ := json.Marshal(map[string]interface{}{"code": code, "reason": reason})
+	writeSSE(rc, w, "close", data)
+}
+
+// writeSSE writes one event and flushes it. Frames are single-line JSON, so
+// each fits in one data field.
+func writeSSE(rc *http.ResponseController, w io.Writer, event string, data []byte) error {
+	rc.SetWriteDeadline(time.Now().Add(sseWriteWait))
+	if event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
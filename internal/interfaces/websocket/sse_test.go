@@ -0,0 +1,115 @@
+package websocket
+
+import (
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/config"
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// openSSE opens a stream on server and returns the contents of its "open"
+// event together with a reader for the events that follow
+func openSSE(t *testing.T, server *httptest.Server) (clientID, token string, events *bufio.Reader) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + "/sse")
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("open stream: status %d", resp.StatusCode)
+	}
+
+	events = bufio.NewReader(resp.Body)
+	data := readSSEData(t, events)
+	var open struct {
+		ClientID    string `json:"clientId"`
+		StreamToken string `json:"streamToken"`
+	}
+	if err := json.Unmarshal([]byte(data), &open); err != nil {
+		t.Fatalf("decode open event: %v", err)
+	}
+	return open.ClientID, open.StreamToken, events
+}
+
+// readSSEData returns the data field of the next event
+func readSSEData(t *testing.T, events *bufio.Reader) string {
+	t.Helper()
+	for {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "data: "); ok {
+			return data
+		}
+	}
+}
+
+func TestSSEMessageRequiresStreamToken(t *testing.T) {
+	router := &testRouter{handle: func(ctx context.Context, client *Client, message *valueobject.Message) *valueobject.Response {
+		return valueobject.NewSuccessResponse(message.RequestID, nil)
+	}}
+	hub := newTestHub(t, config.WebSocketConfig{SendBufferSize: 16}, router)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", hub.HandleSSE)
+	mux.HandleFunc("POST /sse/{clientId}", hub.HandleSSEMessage)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	clientID, token, events := openSSE(t, server)
+	if clientID == "" || len(token) < 40 {
+		t.Fatalf("open event carries clientId %q and token %q", clientID, token)
+	}
+	if _, other, _ := openSSE(t, server); other == token {
+		t.Fatal("two streams were issued the same token")
+	}
+
+	tests := []struct {
+		name       string
+		clientID   string
+		token      string
+		wantStatus int
+	}{
+		{name: "missing token", clientID: clientID, wantStatus: http.StatusNotFound},
+		{name: "wrong token", clientID: clientID, token: token[:len(token)-1] + "x", wantStatus: http.StatusNotFound},
+		{name: "token prefix", clientID: clientID, token: token[:10], wantStatus: http.StatusNotFound},
+		{name: "unknown stream", clientID: "unknown", token: token, wantStatus: http.StatusNotFound},
+		{name: "valid token", clientID: clientID, token: token, wantStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.NewReader(`{"type":"test","action":"write","requestId":"` + tt.name + `"}`)
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/sse/"+tt.clientID, body)
+			if tt.token != "" {
+				req.Header.Set(sseTokenHeader, tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("post message: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+
+	// Only the accepted message reaches the stream
+	var response valueobject.Response
+	if err := json.Unmarshal([]byte(readSSEData(t, events)), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.RequestID != "valid token" {
+		t.Errorf("stream delivered response to %q, want %q", response.RequestID, "valid token")
+	}
+}
@@ -0,0 +1,197 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+
+	"GameServer/internal/domain/entity"
+	"GameServer/internal/domain/valueobject"
+)
+
+// publicTopics are shared by every subscriber
+var publicTopics = map[string]bool{
+	valueobject.TopicAnnouncements: true,
+	"rank:level":                   true,
+	"rank:experience":              true,
+	"rank:equipment_power":         true,
+}
+
+// userTopics are scoped to the subscriber: each user follows their own copy,
+// e.g. the presence of their own friends
+var userTopics = map[string]bool{
+	valueobject.TopicFriendPresence: true,
+}
+
+// userTopicKey returns the key under which a user's copy of topic is routed
+func userTopicKey(topic string, userID int) string {
+	return topic + ":" + strconv.Itoa(userID)
+}
+
+// topicKey returns the key the client's subscription to topic is routed
+// under, or an error if the client may not subscribe to it
+func topicKey(client *Client, topic string) (string, error) {
+	if publicTopics[topic] {
+		return topic, nil
+	}
+	if userTopics[topic] {
+		userID := client.GetUserID()
+		if userID <= 0 {
+			return "", entity.NewDomainError("login required for this topic")
+		}
+		return userTopicKey(topic, userID), nil
+	}
+	return "", entity.NewDomainError("unknown topic")
+}
+
+// Subscribe adds the client to the subscribers of topic. Subscribing to a
+// topic the client already follows is a no-op.
+func (h *Hub) Subscribe(client *Client, topic string) error {
+	key, err := topicKey(client, topic)
+	if err != nil {
+		return err
+	}
+
+	h.topicMu.Lock()
+	defer h.topicMu.Unlock()
+
+	if client.topicsClosed {
+		return entity.NewDomainError("connection closed")
+	}
+	if _, ok := client.topics[topic]; ok {
+		return nil
+	}
+	if len(client.topics) >= h.Config.MaxSubscriptions {
+		return entity.NewDomainError("too many subscriptions")
+	}
+
+	if client.topics == nil {
+		client.topics = make(map[string]string)
+	}
+	client.topics[topic] = key
+
+	subscribers := h.topics[key]
+	if subscribers == nil {
+		subscribers = make(map[*Client]struct{})
+		h.topics[key] = subscribers
+	}
+	subscribers[client] = struct{}{}
+	return nil
+}
+
+// Unsubscribe removes the client from the subscribers of topic and reports
+// whether it was subscribed
+func (h *Hub) Unsubscribe(client *Client, topic string) bool {
+	h.topicMu.Lock()
+	defer h.topicMu.Unlock()
+
+	key, ok := client.topics[topic]
+	if !ok {
+		return false
+	}
+	delete(client.topics, topic)
+	h.removeSubscriber(key, client)
+	return true
+}
+
+// Subscriptions returns the topics the client is subscribed to, sorted
+func (h *Hub) Subscriptions(client *Client) []string {
+	h.topicMu.RLock()
+	defer h.topicMu.RUnlock()
+
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// unsubscribeAll drops every subscription of a client that is going away
+// and refuses new ones
+func (h *Hub) unsubscribeAll(client *Client) {
+	h.topicMu.Lock()
+	defer h.topicMu.Unlock()
+
+	for _, key := range client.topics {
+		h.removeSubscriber(key, client)
+	}
+	client.topics = nil
+	client.topicsClosed = true
+}
+
+// unsubscribeUserTopics drops the client's subscriptions to user topics,
+// which follow the user it was logged in as
+func (h *Hub) unsubscribeUserTopics(client *Client) {
+	h.topicMu.Lock()
+	defer h.topicMu.Unlock()
+
+	for topic, key := range client.topics {
+		if userTopics[topic] {
+			delete(client.topics, topic)
+			h.removeSubscriber(key, client)
+		}
+	}
+}
+
+// removeSubscriber removes client from the topic routed under key; the
+// caller holds topicMu
+func (h *Hub) removeSubscriber(key string, client *Client) {
+	subscribers := h.topics[key]
+	delete(subscribers, client)
+	if len(subscribers) == 0 {
+		delete(h.topics, key)
+	}
+}
+
+// Publish pushes an event to every client subscribed to topic on any node.
+// The event is encoded once for all subscribers.
+func (h *Hub) Publish(topic, event string, data interface{}) {
+	h.publish(topic, topic, event, data)
+}
+
+// PublishToUser pushes an event to the clients of a user subscribed to their
+// copy of a user topic such as TopicFriendPresence, on any node
+func (h *Hub) PublishToUser(userID int, topic, event string, data interface{}) {
+	h.publish(userTopicKey(topic, userID), topic, event, data)
+}
+
+// publish sends an event for topic to the subscribers routed under key
+func (h *Hub) publish(key, topic, event string, data interface{}) {
+	e := valueobject.NewEvent(event, data)
+	e.Topic = topic
+	payload, err := e.ToJSON()
+	if err != nil {
+		log.Printf("Failed to encode %s event for topic %s: %v", event, topic, err)
+		return
+	}
+
+	h.deliverTopic(key, payload)
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.Config.RequestTimeout)
+	defer cancel()
+	if err := h.backplane.PublishTopic(ctx, key, payload); err != nil {
+		log.Printf("Failed to publish to topic %s: %v", key, err)
+	}
+}
+
+// DeliverToTopic delivers a topic frame published by another node to local
+// subscribers; key is the topic or, for a user topic, the user's copy of it
+func (h *Hub) DeliverToTopic(key string, payload []byte) {
+	h.deliverTopic(key, payload)
+}
+
+// deliverTopic queues a frame for the local subscribers routed under key
+func (h *Hub) deliverTopic(key string, payload []byte) {
+	h.topicMu.RLock()
+	subscribers := make([]*Client, 0, len(h.topics[key]))
+	for client := range h.topics[key] {
+		subscribers = append(subscribers, client)
+	}
+	h.topicMu.RUnlock()
+
+	for _, client := range subscribers {
+		client.enqueue(payload)
+	}
+}
@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"GameServer/internal/application/dto"
+	"GameServer/internal/domain/valueobject"
+	"GameServer/internal/infrastructure/backplane"
+	"GameServer/internal/infrastructure/config"
+	"context"
+	"testing"
+)
+
+// fakeFriendService knows the friendships of each user
+type fakeFriendService struct {
+	FriendServiceInterface
+	friends map[int][]int
+}
+
+func (s *fakeFriendService) GetFriends(ctx context.Context, userID int) ([]*dto.FriendResponse, error) {
+	var response []*dto.FriendResponse
+	for _, friendID := range s.friends[userID] {
+		response = append(response, &dto.FriendResponse{FromUserID: friendID, ToUserID: userID})
+	}
+	return response, nil
+}
+
+func TestSubscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int // 0 for a client that has not logged in
+		topic   string
+		wantErr bool
+	}{
+		{name: "ranking", userID: 7, topic: "rank:level"},
+		{name: "announcements", userID: 7, topic: valueobject.TopicAnnouncements},
+		{name: "friend presence", userID: 7, topic: valueobject.TopicFriendPresence},
+		{name: "friend presence before login", topic: valueobject.TopicFriendPresence, wantErr: true},
+		{name: "unknown ranking", userID: 7, topic: "rank:gold", wantErr: true},
+		{name: "another user's presence", userID: 7, topic: "presence:friends:8", wantErr: true},
+		{name: "empty topic", userID: 7, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newTestHub(t, config.WebSocketConfig{MaxSubscriptions: 4}, nil)
+			client := newTestClient(hub, tt.userID)
+
+			err := hub.Subscribe(client, tt.topic)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Subscribe error = %v, want error %v", err, tt.wantErr)
+			}
+			if subscriptions := hub.Subscriptions(client); (len(subscriptions) == 0) != tt.wantErr {
+				t.Errorf("subscriptions = %v after subscribing to %q", subscriptions, tt.topic)
+			}
+		})
+	}
+}
+
+func TestFriendPresence(t *testing.T) {
+	bus := backplane.NewMemoryBus()
+	cfg := config.WebSocketConfig{MaxSubscriptions: 4, SendBufferSize: 16}
+	a := newTestNode(t, cfg, nil, bus, "a")
+	b := newTestNode(t, cfg, nil, bus, "b")
+	friends := &fakeFriendService{friends: map[int][]int{7: {8, 9}}}
+	a.Services.FriendService = friends
+	b.Services.FriendService = friends
+
+	// Friends 8 and 9 follow presence on both nodes; 10 is not a friend
+	subscribers := map[int]*Client{8: loginTestClient(a, 8), 9: loginTestClient(b, 9), 10: loginTestClient(a, 10)}
+	for userID, client := range subscribers {
+		if err := client.Hub.Subscribe(client, valueobject.TopicFriendPresence); err != nil {
+			t.Fatalf("user %d subscribe: %v", userID, err)
+		}
+		drainEvents(t, client)
+	}
+
+	user := loginTestClient(a, 7)
+	a.unregisterClient(user)
+
+	for userID, client := range subscribers {
+		events := drainEvents(t, client)
+		if userID == 10 {
+			if len(events) != 0 {
+				t.Errorf("user 10 saw the presence of a stranger: %v", events)
+			}
+			continue
+		}
+
+		if len(events) != 2 {
+			t.Fatalf("user %d received %d events, want 2", userID, len(events))
+		}
+		for i, wantOnline := range []bool{true, false} {
+			data := events[i].Data.(map[string]interface{})
+			if events[i].Event != valueobject.EventFriendPresence || events[i].Topic != valueobject.TopicFriendPresence ||
+				data["userId"] != float64(7) || data["online"] != wantOnline {
+				t.Errorf("user %d event %d = %+v, want user 7 online %v", userID, i, events[i], wantOnline)
+			}
+		}
+	}
+}
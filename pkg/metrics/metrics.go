@@ -13,10 +13,29 @@ type Metrics struct {
 	MessagesProcessed    int64                  `json:"messages_processed"`
 	ErrorCount           int64                  `json:"error_count"`
 	DatabaseQueries      int64                  `json:"database_queries"`
+	DroppedFrames        int64                  `json:"dropped_frames"`
+	SlowConsumers        int64                  `json:"slow_consumer_disconnects"`
+	RejectedHandshakes   int64                  `json:"rejected_handshakes"`
+	LoginTimeouts        int64                  `json:"login_timeouts"`
 	RequestDurations     map[string][]int64     `json:"request_durations"`
 	LastUpdated          time.Time              `json:"last_updated"`
 }
 
+// Snapshot is a point-in-time copy of the metrics, safe to serialize
+type Snapshot struct {
+	ConnectionCount    int64              `json:"connection_count"`
+	TotalConnections   int64              `json:"total_connections"`
+	MessagesProcessed  int64              `json:"messages_processed"`
+	ErrorCount         int64              `json:"error_count"`
+	DatabaseQueries    int64              `json:"database_queries"`
+	DroppedFrames      int64              `json:"dropped_frames"`
+	SlowConsumers      int64              `json:"slow_consumer_disconnects"`
+	RejectedHandshakes int64              `json:"rejected_handshakes"`
+	LoginTimeouts      int64              `json:"login_timeouts"`
+	RequestDurations   map[string][]int64 `json:"request_durations"`
+	LastUpdated        time.Time          `json:"last_updated"`
+}
+
 var globalMetrics *Metrics
 var once sync.Once
 
@@ -88,6 +107,54 @@ func IncrementDatabaseQueries() {
 	globalMetrics.mutex.Unlock()
 }
 
+// IncrementDroppedFrames increments the count of outbound frames dropped
+// because a client's send buffer was full
+func IncrementDroppedFrames() {
+	if globalMetrics == nil {
+		return
+	}
+	globalMetrics.mutex.Lock()
+	globalMetrics.DroppedFrames++
+	globalMetrics.LastUpdated = time.Now()
+	globalMetrics.mutex.Unlock()
+}
+
+// IncrementSlowConsumers increments the count of clients disconnected for
+// not reading their frames fast enough
+func IncrementSlowConsumers() {
+	if globalMetrics == nil {
+		return
+	}
+	globalMetrics.mutex.Lock()
+	globalMetrics.SlowConsumers++
+	globalMetrics.LastUpdated = time.Now()
+	globalMetrics.mutex.Unlock()
+}
+
+// IncrementRejectedHandshakes increments the count of connection attempts
+// refused before the connection was established
+func IncrementRejectedHandshakes() {
+	if globalMetrics == nil {
+		return
+	}
+	globalMetrics.mutex.Lock()
+	globalMetrics.RejectedHandshakes++
+	globalMetrics.LastUpdated = time.Now()
+	globalMetrics.mutex.Unlock()
+}
+
+// IncrementLoginTimeouts increments the count of connections closed for not
+// logging in within the deadline
+func IncrementLoginTimeouts() {
+	if globalMetrics == nil {
+		return
+	}
+	globalMetrics.mutex.Lock()
+	globalMetrics.LoginTimeouts++
+	globalMetrics.LastUpdated = time.Now()
+	globalMetrics.mutex.Unlock()
+}
+
 // RecordRequestDuration records request duration for a specific action
 func RecordRequestDuration(action string, duration time.Duration) {
 	if globalMetrics == nil {
@@ -109,22 +176,26 @@ func RecordRequestDuration(action string, duration time.Duration) {
 }
 
 // GetMetrics returns a copy of current metrics
-func GetMetrics() Metrics {
+func GetMetrics() Snapshot {
 	if globalMetrics == nil {
-		return Metrics{}
+		return Snapshot{}
 	}
 	globalMetrics.mutex.RLock()
 	defer globalMetrics.mutex.RUnlock()
 	
 	// Create a deep copy
-	metrics := Metrics{
-		ConnectionCount:   globalMetrics.ConnectionCount,
-		TotalConnections:  globalMetrics.TotalConnections,
-		MessagesProcessed: globalMetrics.MessagesProcessed,
-		ErrorCount:        globalMetrics.ErrorCount,
-		DatabaseQueries:   globalMetrics.DatabaseQueries,
-		LastUpdated:       globalMetrics.LastUpdated,
-		RequestDurations:  make(map[string][]int64),
+	metrics := Snapshot{
+		ConnectionCount:    globalMetrics.ConnectionCount,
+		TotalConnections:   globalMetrics.TotalConnections,
+		MessagesProcessed:  globalMetrics.MessagesProcessed,
+		ErrorCount:         globalMetrics.ErrorCount,
+		DatabaseQueries:    globalMetrics.DatabaseQueries,
+		DroppedFrames:      globalMetrics.DroppedFrames,
+		SlowConsumers:      globalMetrics.SlowConsumers,
+		RejectedHandshakes: globalMetrics.RejectedHandshakes,
+		LoginTimeouts:      globalMetrics.LoginTimeouts,
+		LastUpdated:        globalMetrics.LastUpdated,
+		RequestDurations:   make(map[string][]int64),
 	}
 	
 	// Copy request durations
@@ -147,6 +218,10 @@ func Reset() {
 	globalMetrics.MessagesProcessed = 0
 	globalMetrics.ErrorCount = 0
 	globalMetrics.DatabaseQueries = 0
+	globalMetrics.DroppedFrames = 0
+	globalMetrics.SlowConsumers = 0
+	globalMetrics.RejectedHandshakes = 0
+	globalMetrics.LoginTimeouts = 0
 	globalMetrics.RequestDurations = make(map[string][]int64)
 	globalMetrics.LastUpdated = time.Now()
 	globalMetrics.mutex.Unlock()
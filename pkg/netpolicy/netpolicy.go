@@ -0,0 +1,204 @@
+package netpolicy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Rules is a pair of allow and deny lists of CIDRs or bare IP addresses.
+// Deny always wins; an empty allow list allows every address not denied.
+type Rules struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// Config holds the network policy for client-facing and admin endpoints
+type Config struct {
+	Client         Rules    `json:"client"`
+	Admin          Rules    `json:"admin"`
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// ruleSet is the parsed form of Rules
+type ruleSet struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// compiled is the parsed form of Config
+type compiled struct {
+	client  ruleSet
+	admin   ruleSet
+	proxies []*net.IPNet
+}
+
+// Policy enforces a network policy on HTTP requests. It can be updated while
+// requests are being served.
+type Policy struct {
+	current       atomic.Pointer[compiled]
+	adminPrefixes []string
+}
+
+type contextKey struct{}
+
+// New creates a policy. Requests whose path starts with one of adminPrefixes
+// are checked against the admin rules, all others against the client rules.
+func New(cfg Config, adminPrefixes ...string) (*Policy, error) {
+	p := &Policy{adminPrefixes: adminPrefixes}
+	if err := p.Update(cfg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update replaces the rules. On error the previous rules stay in effect.
+func (p *Policy) Update(cfg Config) error {
+	client, err := parseRules(cfg.Client)
+	if err != nil {
+		return fmt.Errorf("client rules: %w", err)
+	}
+	admin, err := parseRules(cfg.Admin)
+	if err != nil {
+		return fmt.Errorf("admin rules: %w", err)
+	}
+	proxies, err := parseNets(cfg.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("trusted proxies: %w", err)
+	}
+
+	p.current.Store(&compiled{client: client, admin: admin, proxies: proxies})
+	return nil
+}
+
+// Middleware rejects requests from addresses the policy does not allow and
+// records the resolved client address in the request context
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := p.current.Load()
+		ip := rules.clientIP(r)
+
+		set := rules.client
+		if p.isAdminPath(r.URL.Path) {
+			set = rules.admin
+		}
+		if !set.allows(ip) {
+			log.Printf("Network policy denied %s %s from %s", r.Method, r.URL.Path, ip)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if ip != nil {
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, ip.String()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP returns the client address resolved by Middleware
+func ClientIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(contextKey{}).(string)
+	return ip, ok
+}
+
+// isAdminPath reports whether path is an admin endpoint
+func (p *Policy) isAdminPath(path string) bool {
+	for _, prefix := range p.adminPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP resolves the address of the client. X-Forwarded-For is only
+// honored when the peer is a trusted proxy; the chain is walked from the
+// right and the first address that is not a trusted proxy is the client.
+func (c *compiled) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !contains(c.proxies, ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !contains(c.proxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// allows reports whether ip passes the rules
+func (s ruleSet) allows(ip net.IP) bool {
+	if ip == nil {
+		return len(s.allow) == 0 && len(s.deny) == 0
+	}
+	if contains(s.deny, ip) {
+		return false
+	}
+	return len(s.allow) == 0 || contains(s.allow, ip)
+}
+
+func parseRules(rules Rules) (ruleSet, error) {
+	allow, err := parseNets(rules.Allow)
+	if err != nil {
+		return ruleSet{}, err
+	}
+	deny, err := parseNets(rules.Deny)
+	if err != nil {
+		return ruleSet{}, err
+	}
+	return ruleSet{allow: allow, deny: deny}, nil
+}
+
+// parseNets parses CIDRs; bare addresses are treated as single-host networks
+func parseNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", entry)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package netpolicy
+
+import (
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Denied requests log a line each
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestParseNets(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		wantErr  bool
+		contains []string
+		excludes []string
+	}{
+		{name: "IPv4 CIDR", entries: []string{"10.0.0.0/8"}, contains: []string{"10.1.2.3"}, excludes: []string{"11.0.0.1"}},
+		{name: "bare IPv4 address", entries: []string{"192.168.1.5"}, contains: []string{"192.168.1.5"}, excludes: []string{"192.168.1.6"}},
+		{name: "bare IPv6 address", entries: []string{"::1"}, contains: []string{"::1"}, excludes: []string{"::2"}},
+		{name: "IPv6 CIDR", entries: []string{"fd00::/8"}, contains: []string{"fd12::1"}, excludes: []string{"fe80::1"}},
+		{name: "IPv4-mapped IPv6 matches IPv4", entries: []string{"127.0.0.1"}, contains: []string{"::ffff:127.0.0.1"}},
+		{name: "blank entries and spaces", entries: []string{"", " 10.0.0.1 "}, contains: []string{"10.0.0.1"}},
+		{name: "invalid address", entries: []string{"10.0.0"}, wantErr: true},
+		{name: "invalid CIDR", entries: []string{"10.0.0.0/33"}, wantErr: true},
+		{name: "hostname", entries: []string{"localhost"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nets, err := parseNets(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNets error = %v, want error %v", err, tt.wantErr)
+			}
+			for _, addr := range tt.contains {
+				if !contains(nets, net.ParseIP(addr)) {
+					t.Errorf("%s not matched", addr)
+				}
+			}
+			for _, addr := range tt.excludes {
+				if contains(nets, net.ParseIP(addr)) {
+					t.Errorf("%s matched", addr)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg := Config{
+		Client:         Rules{Deny: []string{"203.0.113.0/24"}},
+		Admin:          Rules{Allow: []string{"127.0.0.1", "::1", "10.0.0.0/8"}, Deny: []string{"10.9.0.0/16"}},
+		TrustedProxies: []string{"192.168.0.10", "192.168.0.11"},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		forwarded  []string // X-Forwarded-For header values
+		wantStatus int
+		wantIP     string
+	}{
+		{name: "client allowed by empty allow list", path: "/ws", remoteAddr: "198.51.100.7:4000", wantStatus: http.StatusOK, wantIP: "198.51.100.7"},
+		{name: "client denied", path: "/ws", remoteAddr: "203.0.113.9:4000", wantStatus: http.StatusForbidden},
+		{name: "admin from loopback", path: "/admin/mail", remoteAddr: "127.0.0.1:4000", wantStatus: http.StatusOK, wantIP: "127.0.0.1"},
+		{name: "admin from IPv6 loopback", path: "/metrics", remoteAddr: "[::1]:4000", wantStatus: http.StatusOK, wantIP: "::1"},
+		{name: "admin from allowed CIDR", path: "/admin/mail", remoteAddr: "10.1.2.3:4000", wantStatus: http.StatusOK, wantIP: "10.1.2.3"},
+		{name: "admin deny wins over allow", path: "/admin/mail", remoteAddr: "10.9.1.1:4000", wantStatus: http.StatusForbidden},
+		{name: "admin from outside", path: "/admin/mail", remoteAddr: "198.51.100.7:4000", wantStatus: http.StatusForbidden},
+		{
+			name:       "forwarded header from untrusted peer ignored",
+			path:       "/admin/mail",
+			remoteAddr: "198.51.100.7:4000",
+			forwarded:  []string{"127.0.0.1"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "forwarded header from trusted proxy",
+			path:       "/ws",
+			remoteAddr: "192.168.0.10:4000",
+			forwarded:  []string{"198.51.100.7"},
+			wantStatus: http.StatusOK,
+			wantIP:     "198.51.100.7",
+		},
+		{
+			name:       "trusted proxy forwarding a denied client",
+			path:       "/ws",
+			remoteAddr: "192.168.0.10:4000",
+			forwarded:  []string{"203.0.113.9"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "proxy chain walked from the right",
+			path:       "/ws",
+			remoteAddr: "192.168.0.10:4000",
+			forwarded:  []string{"127.0.0.1, 198.51.100.7", "192.168.0.11"},
+			wantStatus: http.StatusOK,
+			wantIP:     "198.51.100.7",
+		},
+		{
+			name:       "spoofed loopback behind a client is ignored",
+			path:       "/admin/mail",
+			remoteAddr: "192.168.0.10:4000",
+			forwarded:  []string{"127.0.0.1, 198.51.100.7"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "garbage hop stops the walk",
+			path:       "/ws",
+			remoteAddr: "192.168.0.10:4000",
+			forwarded:  []string{"198.51.100.7, not-an-ip, 192.168.0.11"},
+			wantStatus: http.StatusOK,
+			wantIP:     "192.168.0.11",
+		},
+		{
+			name:       "proxy without a forwarded header",
+			path:       "/ws",
+			remoteAddr: "192.168.0.10:4000",
+			wantStatus: http.StatusOK,
+			wantIP:     "192.168.0.10",
+		},
+	}
+
+	policy, err := New(cfg, "/metrics", "/admin/")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIP string
+			handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotIP, _ = ClientIP(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if gotIP != tt.wantIP {
+				t.Errorf("client IP = %q, want %q", gotIP, tt.wantIP)
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsRulesOnError(t *testing.T) {
+	policy, err := New(Config{Client: Rules{Deny: []string{"203.0.113.9"}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+		denied  bool // Whether 203.0.113.9 is denied afterwards
+	}{
+		{name: "invalid client rule", cfg: Config{Client: Rules{Allow: []string{"bad"}}}, wantErr: true, denied: true},
+		{name: "invalid trusted proxy", cfg: Config{TrustedProxies: []string{"1.2.3.4/40"}}, wantErr: true, denied: true},
+		{name: "valid update", cfg: Config{}, denied: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := policy.Update(tt.cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("Update error = %v, want error %v", err, tt.wantErr)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+			req.RemoteAddr = "203.0.113.9:4000"
+			recorder := httptest.NewRecorder()
+			policy.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(recorder, req)
+			if denied := recorder.Code == http.StatusForbidden; denied != tt.denied {
+				t.Errorf("denied = %v, want %v", denied, tt.denied)
+			}
+		})
+	}
+}
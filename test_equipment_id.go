@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -61,7 +62,7 @@ func main() {
 
 	// Test creating equipment
 	fmt.Println("Creating test equipment...")
-	err = equipRepo.Create(testEquipment)
+	err = equipRepo.Create(context.Background(), testEquipment)
 	if err != nil {
 		log.Printf("Error creating equipment: %v", err)
 	} else {
@@ -70,7 +71,7 @@ func main() {
 
 	// Test retrieving equipment
 	fmt.Println("Retrieving created equipment...")
-	retrieved, err := equipRepo.GetByEquipID(testEquipment.EquipID)
+	retrieved, err := equipRepo.GetByEquipID(context.Background(), testEquipment.EquipID)
 	if err != nil {
 		log.Printf("Error retrieving equipment: %v", err)
 	} else if retrieved != nil {